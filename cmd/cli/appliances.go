@@ -9,9 +9,11 @@ import (
 )
 
 type applianceCreateCmd struct {
-	Name string `arg:"-n,required"`
-	Kind string `arg:"-k" default:"noop"`
-	URI  string `arg:"-u" default:"noop:///"`
+	Name     string `arg:"-n,required"`
+	Kind     string `arg:"-k" default:"noop"`
+	URI      string `arg:"-u" default:"noop:///"`
+	Username string `arg:"--username" help:"username when appliance requires authentication"`
+	Password string `arg:"--password,env:FORESTER_APPLIANCE_PASSWORD" help:"password when appliance requires authentication"`
 }
 
 type applianceListCmd struct {
@@ -19,20 +21,27 @@ type applianceListCmd struct {
 	Offset int64 `arg:"-o" default:"0"`
 }
 
+type applianceUpdateCredentialsCmd struct {
+	Name     string `arg:"positional,required" placeholder:"APPLIANCE_NAME"`
+	Username string `arg:"--username" help:"username, blank clears credentials"`
+	Password string `arg:"--password,env:FORESTER_APPLIANCE_PASSWORD" help:"password"`
+}
+
 type applianceEnlistCmd struct {
 	Name          string `arg:"positional,required" placeholder:"APPLIANCE_NAME"`
 	SystemPattern string `arg:"-n" placeholder:"REGEXP_SYSTEM_PATTERN" default:".*"`
 }
 
 type applianceCmd struct {
-	Create *applianceCreateCmd `arg:"subcommand:create" help:"create appliance"`
-	List   *applianceListCmd   `arg:"subcommand:list" help:"list appliances"`
-	Enlist *applianceEnlistCmd `arg:"subcommand:enlist" help:"enlist systems of appliance"`
+	Create            *applianceCreateCmd            `arg:"subcommand:create" help:"create appliance"`
+	List              *applianceListCmd              `arg:"subcommand:list" help:"list appliances"`
+	Enlist            *applianceEnlistCmd            `arg:"subcommand:enlist" help:"enlist systems of appliance"`
+	UpdateCredentials *applianceUpdateCredentialsCmd `arg:"subcommand:update-credentials" help:"set username and password of appliance"`
 }
 
 func applianceCreate(ctx context.Context, cmdArgs *applianceCreateCmd) error {
 	client := ctl.NewApplianceServiceClient(args.URL, http.DefaultClient)
-	err := client.Create(ctx, cmdArgs.Name, ctl.ApplianceKindToInt(cmdArgs.Kind), cmdArgs.URI)
+	err := client.Create(ctx, cmdArgs.Name, ctl.ApplianceKindToInt(cmdArgs.Kind), cmdArgs.URI, cmdArgs.Username, cmdArgs.Password)
 	if err != nil {
 		return fmt.Errorf("cannot create appliance: %w", err)
 	}
@@ -48,15 +57,25 @@ func applianceList(ctx context.Context, cmdArgs *applianceListCmd) error {
 	}
 
 	w := newTabWriter()
-	fmt.Fprintln(w, "ID\tName\tKind\tURI")
+	fmt.Fprintln(w, "ID\tName\tKind\tURI\tUsername")
 	for _, a := range appliances {
-		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", a.ID, a.Name, ctl.ApplianceIntToKind(a.Kind), a.URI)
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", a.ID, a.Name, ctl.ApplianceIntToKind(a.Kind), a.URI, a.Username)
 	}
 	w.Flush()
 
 	return nil
 }
 
+func applianceUpdateCredentials(ctx context.Context, cmdArgs *applianceUpdateCredentialsCmd) error {
+	client := ctl.NewApplianceServiceClient(args.URL, http.DefaultClient)
+	err := client.UpdateCredentials(ctx, cmdArgs.Name, cmdArgs.Username, cmdArgs.Password)
+	if err != nil {
+		return fmt.Errorf("cannot update credentials: %w", err)
+	}
+
+	return nil
+}
+
 func applianceEnlist(ctx context.Context, cmdArgs *applianceEnlistCmd) error {
 	client := ctl.NewApplianceServiceClient(args.URL, http.DefaultClient)
 	err := client.Enlist(ctx, cmdArgs.Name, cmdArgs.SystemPattern)
@@ -20,6 +20,7 @@ var args struct {
 	Snippet   *snippetCmd   `arg:"subcommand:snippet" help:"snippet related commands"`
 	System    *systemCmd    `arg:"subcommand:system" help:"system related commands"`
 	Appliance *applianceCmd `arg:"subcommand:appliance" help:"appliance related commands"`
+	Job       *jobCmd       `arg:"subcommand:job" help:"power job related commands"`
 	URL       string        `default:"http://localhost:8000"`
 	Config    string        `default:"config/forester.env"`
 	Quiet     bool
@@ -53,10 +54,20 @@ func main() {
 	case args.Image != nil:
 		if cmd := args.Image.Upload; cmd != nil {
 			err = imageUpload(ctx, cmd)
+		} else if cmd := args.Image.Import; cmd != nil {
+			err = imageImport(ctx, cmd)
 		} else if cmd := args.Image.Show; cmd != nil {
 			err = imageShow(ctx, cmd)
+		} else if cmd := args.Image.UpdateContainer; cmd != nil {
+			err = imageUpdateContainer(ctx, cmd)
+		} else if cmd := args.Image.UpdateKernelArgs; cmd != nil {
+			err = imageUpdateKernelArgs(ctx, cmd)
 		} else if cmd := args.Image.List; cmd != nil {
 			err = imageList(ctx, cmd)
+		} else if cmd := args.Image.Extract; cmd != nil {
+			err = imageExtract(ctx, cmd)
+		} else if cmd := args.Image.Delete; cmd != nil {
+			err = imageDelete(ctx, cmd)
 		} else {
 			_ = parser.FailSubcommand("unknown subcommand", "image")
 		}
@@ -77,6 +88,10 @@ func main() {
 			err = systemDeploy(ctx, cmd)
 		} else if cmd := args.System.Rename; cmd != nil {
 			err = systemRename(ctx, cmd)
+		} else if cmd := args.System.UpdateKernelArgs; cmd != nil {
+			err = systemUpdateKernelArgs(ctx, cmd)
+		} else if cmd := args.System.UpdateNetwork; cmd != nil {
+			err = systemUpdateNetwork(ctx, cmd)
 		} else if cmd := args.System.Acquire; cmd != nil {
 			err = systemAcquire(ctx, cmd)
 		} else if cmd := args.System.Release; cmd != nil {
@@ -85,6 +100,18 @@ func main() {
 			err = systemBootNetwork(ctx, cmd)
 		} else if cmd := args.System.BootLocal; cmd != nil {
 			err = systemBootLocal(ctx, cmd)
+		} else if pcmd := args.System.Power; pcmd != nil {
+			if cmd := pcmd.Status; cmd != nil {
+				err = systemPowerStatus(ctx, cmd)
+			} else if cmd := pcmd.On; cmd != nil {
+				err = systemPowerOn(ctx, cmd)
+			} else if cmd := pcmd.Off; cmd != nil {
+				err = systemPowerOff(ctx, cmd)
+			} else if cmd := pcmd.Cycle; cmd != nil {
+				err = systemPowerCycle(ctx, cmd)
+			} else {
+				_ = parser.FailSubcommand("unknown subcommand", "system", "power")
+			}
 		} else {
 			_ = parser.FailSubcommand("unknown subcommand", "system")
 		}
@@ -95,6 +122,8 @@ func main() {
 			err = applianceList(ctx, cmd)
 		} else if cmd := args.Appliance.Enlist; cmd != nil {
 			err = applianceEnlist(ctx, cmd)
+		} else if cmd := args.Appliance.UpdateCredentials; cmd != nil {
+			err = applianceUpdateCredentials(ctx, cmd)
 		} else {
 			_ = parser.FailSubcommand("unknown subcommand", "appliance")
 		}
@@ -110,6 +139,14 @@ func main() {
 		} else {
 			_ = parser.FailSubcommand("unknown subcommand", "appliance")
 		}
+	case args.Job != nil:
+		if cmd := args.Job.Show; cmd != nil {
+			err = jobShow(ctx, cmd)
+		} else if cmd := args.Job.List; cmd != nil {
+			err = jobList(ctx, cmd)
+		} else {
+			_ = parser.FailSubcommand("unknown subcommand", "job")
+		}
 	default:
 		parser.Fail("missing subcommand")
 	}
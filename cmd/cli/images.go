@@ -2,38 +2,100 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"forester/internal/api/ctl"
+	"forester/internal/ptr"
 )
 
 type imageUploadCmd struct {
 	ImageFile string `arg:"positional,required" placeholder:"IMAGE_FILE"`
 	Name      string `arg:"-n,required"`
+	ChunkSize int64  `arg:"--chunk-size" default:"67108864" help:"size of upload chunks in bytes"`
+	Retries   int    `arg:"--retries" default:"10" help:"number of attempts to resume interrupted upload"`
+	imageContainerArgs
+	kernelArgs
+}
+
+type imageImportCmd struct {
+	URL    string `arg:"positional,required" placeholder:"IMAGE_URL"`
+	Name   string `arg:"-n,required"`
+	Sha256 string `arg:"--sha256" help:"expected SHA256 checksum of the ISO"`
+	imageContainerArgs
+	kernelArgs
+}
+
+// imageContainerArgs are nil when not given, only given fields are updated
+type imageContainerArgs struct {
+	ContainerRef    *string `arg:"--container-ref" help:"install container from registry reference instead of the ISO (empty to unset)"`
+	BootcRef        *string `arg:"--bootc-ref" help:"switch installed system to registry reference via bootc (empty to unset)"`
+	SignatureVerify *bool   `arg:"--signature-verify" help:"verify container signatures (=false to disable)"`
+}
+
+func (a imageContainerArgs) isSet() bool {
+	return a.ContainerRef != nil || a.BootcRef != nil || a.SignatureVerify != nil
+}
+
+type imageUpdateContainerCmd struct {
+	ImageName string `arg:"positional,required" placeholder:"NAME"`
+	imageContainerArgs
+}
+
+type imageUpdateKernelArgsCmd struct {
+	ImageName string `arg:"positional,required" placeholder:"NAME"`
+	kernelArgs
 }
 
 type imageShowCmd struct {
 	ImageName string `arg:"positional,required" placeholder:"NAME"`
 }
 
+type imageExtractCmd struct {
+	ImageName string `arg:"positional,required" placeholder:"NAME"`
+}
+
+type imageDeleteCmd struct {
+	ImageName string `arg:"positional,required" placeholder:"NAME"`
+	Force     bool   `arg:"-f" help:"delete even when used by installations, the installations and their history are deleted too"`
+}
+
 type imageListCmd struct {
-	Limit  int64 `arg:"-m" default:"100"`
-	Offset int64 `arg:"-o" default:"0"`
+	Meta   map[string]string `arg:"-f,--filter" help:"list only images with metadata KEY=VALUE (distro, version, arch, variant, build_time, media)"`
+	Limit  int64             `arg:"-m" default:"100"`
+	Offset int64             `arg:"-o" default:"0"`
 }
 
 type imageCmd struct {
-	Upload *imageUploadCmd `arg:"subcommand:upload" help:"upload image"`
-	Show   *imageShowCmd   `arg:"subcommand:show" help:"show image"`
-	List   *imageListCmd   `arg:"subcommand:list" help:"list images"`
+	Upload           *imageUploadCmd           `arg:"subcommand:upload" help:"upload image"`
+	Import           *imageImportCmd           `arg:"subcommand:import" help:"import image from URL on the server side"`
+	Show             *imageShowCmd             `arg:"subcommand:show" help:"show image"`
+	UpdateContainer  *imageUpdateContainerCmd  `arg:"subcommand:update-container" help:"set container and bootc references of image"`
+	UpdateKernelArgs *imageUpdateKernelArgsCmd `arg:"subcommand:update-kernel-args" help:"set kernel arguments of image network boot (boot.iso keeps arguments from upload)"`
+	List             *imageListCmd             `arg:"subcommand:list" help:"list images"`
+	Extract          *imageExtractCmd          `arg:"subcommand:extract" help:"extract failed image again from the uploaded ISO"`
+	Delete           *imageDeleteCmd           `arg:"subcommand:delete" help:"delete image"`
 }
 
 var ErrUploadNot200 = errors.New("upload error")
 
+var ErrUploadChecksum = errors.New("uploaded image checksum mismatch")
+
+var ErrImageExists = errors.New("image already uploaded")
+
+var ErrImageDiffers = errors.New("image of the same name has a different checksum")
+
 func uploadURL(mainURL, newPath string) (string, error) {
 	newURL, err := url.Parse(mainURL)
 	if err != nil {
@@ -44,46 +106,192 @@ func uploadURL(mainURL, newPath string) (string, error) {
 	return newURL.String(), nil
 }
 
-func imageUpload(ctx context.Context, cmdArgs *imageUploadCmd) error {
-	client := ctl.NewImageServiceClient(args.URL, http.DefaultClient)
-	_, uploadPath, err := client.Create(ctx, &ctl.Image{
-		Name: cmdArgs.Name,
-	})
+func fileSha256(file *os.File) (string, error) {
+	h := sha256.New()
+	_, err := io.Copy(h, file)
 	if err != nil {
-		return fmt.Errorf("cannot create image: %w", err)
+		return "", fmt.Errorf("cannot read image: %w", err)
 	}
 
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// uploadOffset returns number of bytes already uploaded and whether the upload is complete.
+func uploadOffset(ctx context.Context, uploadURL string) (int64, bool, error) {
+	r, err := http.NewRequestWithContext(ctx, http.MethodHead, uploadURL, nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("cannot create upload request: %w", err)
+	}
+
+	res, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return 0, false, fmt.Errorf("cannot query upload offset: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return 0, false, fmt.Errorf("server returned %d: %w", res.StatusCode, ErrUploadNot200)
+	}
+
+	offset, err := strconv.ParseInt(res.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("cannot parse upload offset: %w", err)
+	}
+
+	return offset, res.Header.Get("Upload-Complete") == "true", nil
+}
+
+// uploadChunk sends part of the file starting at offset, returns the new offset.
+func uploadChunk(ctx context.Context, uploadURL string, file *os.File, size, offset, chunkSize int64) (int64, error) {
+	end := min(offset+chunkSize, size) - 1
+	body := io.NewSectionReader(file, offset, end-offset+1)
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPut, uploadURL, body)
+	if err != nil {
+		return offset, fmt.Errorf("cannot create upload request: %w", err)
+	}
+	r.ContentLength = end - offset + 1
+	r.Header.Set("Content-Type", "application/octet-stream")
+	r.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, end, size))
+
+	res, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return offset, fmt.Errorf("cannot send data: %w", err)
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusUnprocessableEntity:
+		return 0, ErrUploadChecksum
+	case res.StatusCode < 200 || res.StatusCode > 299:
+		return offset, fmt.Errorf("server returned %d: %w", res.StatusCode, ErrUploadNot200)
+	}
+
+	return end + 1, nil
+}
+
+func imageUpload(ctx context.Context, cmdArgs *imageUploadCmd) error {
 	file, err := os.Open(cmdArgs.ImageFile)
 	if err != nil {
 		return fmt.Errorf("cannot open image: %w", err)
 	}
 	defer file.Close()
 
+	fi, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("cannot stat file: %w", err)
+	}
+	if fi.Size() == 0 {
+		return fmt.Errorf("cannot upload empty image: %w", ErrUploadNot200)
+	}
+
+	sum, err := fileSha256(file)
+	if err != nil {
+		return err
+	}
+
+	// resume upload of an existing image of the same name
+	client := ctl.NewImageServiceClient(args.URL, http.DefaultClient)
+	uploadPath := ""
+	existing, err := client.Find(ctx, cmdArgs.Name)
+	if err == nil {
+		// only the same file can be resumed, the server does not verify a blank checksum
+		if !strings.EqualFold(existing.ExpectedSha256, sum) {
+			return fmt.Errorf("%s: %w, use a different name or delete the image", cmdArgs.Name, ErrImageDiffers)
+		}
+		uploadPath = fmt.Sprintf("/img/%d", existing.ID)
+
+		err = updateImage(ctx, client, cmdArgs.Name, cmdArgs.imageContainerArgs, cmdArgs.kernelArgs)
+		if err != nil {
+			return err
+		}
+	} else {
+		_, uploadPath, err = client.Create(ctx, &ctl.Image{
+			Name:            cmdArgs.Name,
+			ContainerRef:    ptr.From(cmdArgs.ContainerRef),
+			BootcRef:        ptr.From(cmdArgs.BootcRef),
+			SignatureVerify: ptr.From(cmdArgs.SignatureVerify),
+			KernelArgs:      cmdArgs.KernelArgs,
+		}, sum)
+		if err != nil {
+			return fmt.Errorf("cannot create image: %w", err)
+		}
+	}
+
 	uploadURL, err := uploadURL(args.URL, uploadPath)
 	if err != nil {
 		return fmt.Errorf("cannot upload image: %w", err)
 	}
 
-	r, err := http.NewRequest("PUT", uploadURL, file)
+	offset, complete, err := uploadOffset(ctx, uploadURL)
 	if err != nil {
-		return fmt.Errorf("cannot create upload request: %w", err)
+		return err
 	}
-	fi, err := file.Stat()
+	if complete && existing != nil && ctl.ImageIntIsFailed(existing.Status) {
+		// the same ISO was uploaded but processing failed
+		slog.InfoContext(ctx, "image already uploaded but failed, extracting again", "message", existing.StatusMessage)
+		return client.Extract(ctx, cmdArgs.Name)
+	} else if complete {
+		return fmt.Errorf("%s: %w", cmdArgs.Name, ErrImageExists)
+	}
+
+	attempt := 0
+	for offset < fi.Size() {
+		if offset > 0 {
+			slog.InfoContext(ctx, "uploading image", "offset", offset, "size", fi.Size())
+		}
+
+		offset, err = uploadChunk(ctx, uploadURL, file, fi.Size(), offset, cmdArgs.ChunkSize)
+		if errors.Is(err, ErrUploadChecksum) {
+			return err
+		} else if err != nil {
+			attempt++
+			if attempt > cmdArgs.Retries {
+				return err
+			}
+
+			slog.WarnContext(ctx, "upload interrupted, resuming", "err", err, "attempt", attempt)
+			time.Sleep(time.Duration(attempt) * time.Second)
+			// the server may have stored part of the failed chunk
+			if current, _, err := uploadOffset(ctx, uploadURL); err == nil {
+				offset = current
+			}
+		}
+	}
+
+	return nil
+}
+
+// updateImage sets container references and kernel arguments of an existing image when given
+func updateImage(ctx context.Context, client ctl.ImageService, name string, container imageContainerArgs, kernel kernelArgs) error {
+	if container.isSet() {
+		err := client.UpdateContainer(ctx, name, container.ContainerRef, container.BootcRef, container.SignatureVerify)
+		if err != nil {
+			return fmt.Errorf("cannot update image: %w", err)
+		}
+	}
+	if len(kernel.KernelArgs) > 0 {
+		err := client.UpdateKernelArgs(ctx, name, kernel.KernelArgs)
+		if err != nil {
+			return fmt.Errorf("cannot update image: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func imageImport(ctx context.Context, cmdArgs *imageImportCmd) error {
+	client := ctl.NewImageServiceClient(args.URL, http.DefaultClient)
+	id, err := client.Import(ctx, cmdArgs.Name, cmdArgs.URL, cmdArgs.Sha256)
 	if err != nil {
-		return fmt.Errorf("cannot stat file: %w", err)
+		return fmt.Errorf("cannot import image: %w", err)
 	}
-	r.Header.Set("Content-Type", "application/octet-stream")
-	r.Header.Set("Content-Size", strconv.FormatInt(fi.Size(), 10))
-	uploadClient := &http.Client{}
-	res, err := uploadClient.Do(r)
+	err = updateImage(ctx, client, cmdArgs.Name, cmdArgs.imageContainerArgs, cmdArgs.kernelArgs)
 	if err != nil {
-		return fmt.Errorf("cannot send data: %w", err)
+		return err
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode != 200 {
-		return fmt.Errorf("server returned %d: %w", res.StatusCode, ErrUploadNot200)
-	}
+	fmt.Printf("Image %d import started\n", id)
 
 	return nil
 }
@@ -99,24 +307,99 @@ func imageShow(ctx context.Context, cmdArgs *imageShowCmd) error {
 	fmt.Fprintln(w, "Attribute\tValue")
 	fmt.Fprintf(w, "%s\t%d\n", "ID", result.ID)
 	fmt.Fprintf(w, "%s\t%s\n", "Name", result.Name)
+	fmt.Fprintf(w, "%s\t%s\n", "Kind", ctl.ImageIntToKind(result.Kind))
+	fmt.Fprintf(w, "%s\t%s\n", "Arch", result.Arch)
+	fmt.Fprintf(w, "%s\t%s\n", "Status", ctl.ImageIntToStatus(result.Status))
+	fmt.Fprintf(w, "%s\t%d%%\n", "Progress", result.Progress)
+	if result.ContainerRef != "" {
+		fmt.Fprintf(w, "%s\t%s\n", "Container", result.ContainerRef)
+	}
+	if result.BootcRef != "" {
+		fmt.Fprintf(w, "%s\t%s\n", "Bootc", result.BootcRef)
+	}
+	fmt.Fprintf(w, "%s\t%t\n", "Signature verify", result.SignatureVerify)
+	fmt.Fprintf(w, "%s\t%s\n", "Secure Boot", ctl.ImageIntToSecureBoot(result.SecureBoot))
+	if len(result.KernelArgs) > 0 {
+		fmt.Fprintf(w, "%s\t%s\n", "Kernel args", strings.Join(result.KernelArgs, " "))
+	}
+	if result.SecureBootMessage != "" {
+		fmt.Fprintf(w, "%s\t%s\n", "Secure Boot message", result.SecureBootMessage)
+	}
+	if len(result.Meta) > 0 {
+		keys := make([]string, 0, len(result.Meta))
+		for k := range result.Meta {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		fmt.Fprintln(w, "\nMetadata\tValue")
+		for _, k := range keys {
+			fmt.Fprintf(w, "%s\t%s\n", k, result.Meta[k])
+		}
+	}
+	if result.StatusMessage != "" {
+		fmt.Fprintf(w, "%s\t%s\n", "Message", result.StatusMessage)
+	}
 	w.Flush()
 
 	return nil
 }
 
+func imageUpdateContainer(ctx context.Context, cmdArgs *imageUpdateContainerCmd) error {
+	client := ctl.NewImageServiceClient(args.URL, http.DefaultClient)
+	err := client.UpdateContainer(ctx, cmdArgs.ImageName, cmdArgs.ContainerRef, cmdArgs.BootcRef, cmdArgs.SignatureVerify)
+	if err != nil {
+		return fmt.Errorf("cannot update image: %w", err)
+	}
+
+	return nil
+}
+
+func imageUpdateKernelArgs(ctx context.Context, cmdArgs *imageUpdateKernelArgsCmd) error {
+	client := ctl.NewImageServiceClient(args.URL, http.DefaultClient)
+	err := client.UpdateKernelArgs(ctx, cmdArgs.ImageName, cmdArgs.KernelArgs)
+	if err != nil {
+		return fmt.Errorf("cannot update image: %w", err)
+	}
+
+	return nil
+}
+
 func imageList(ctx context.Context, cmdArgs *imageListCmd) error {
 	client := ctl.NewImageServiceClient(args.URL, http.DefaultClient)
-	images, err := client.List(ctx, cmdArgs.Limit, cmdArgs.Offset)
+	images, err := client.List(ctx, cmdArgs.Limit, cmdArgs.Offset, cmdArgs.Meta)
 	if err != nil {
 		return fmt.Errorf("cannot list images: %w", err)
 	}
 
 	w := newTabWriter()
-	fmt.Fprintln(w, "Image ID\tImage Name\tKind")
+	fmt.Fprintln(w, "Image ID\tImage Name\tKind\tArch\tRelease\tStatus\tProgress\tMessage")
 	for _, img := range images {
-		fmt.Fprintf(w, "%d\t%s\t%s\n", img.ID, img.Name, ctl.ImageIntToKind(img.Kind))
+		release := strings.TrimSpace(img.Meta["distro"] + " " + img.Meta["version"])
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%d%%\t%s\n", img.ID, img.Name, ctl.ImageIntToKind(img.Kind), img.Arch,
+			release, ctl.ImageIntToStatus(img.Status), img.Progress, img.StatusMessage)
 	}
 	w.Flush()
 
 	return nil
 }
+
+func imageExtract(ctx context.Context, cmdArgs *imageExtractCmd) error {
+	client := ctl.NewImageServiceClient(args.URL, http.DefaultClient)
+	err := client.Extract(ctx, cmdArgs.ImageName)
+	if err != nil {
+		return fmt.Errorf("cannot extract image: %w", err)
+	}
+
+	return nil
+}
+
+func imageDelete(ctx context.Context, cmdArgs *imageDeleteCmd) error {
+	client := ctl.NewImageServiceClient(args.URL, http.DefaultClient)
+	err := client.Delete(ctx, cmdArgs.ImageName, cmdArgs.Force)
+	if err != nil {
+		return fmt.Errorf("cannot delete image: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"forester/internal/api/ctl"
+)
+
+type jobShowCmd struct {
+	ID int64 `arg:"positional,required" placeholder:"JOB_ID"`
+}
+
+type jobListCmd struct {
+	System string `arg:"-s" placeholder:"MAC_OR_NAME" help:"show only jobs of a system"`
+	Limit  int64  `arg:"-m" default:"100"`
+	Offset int64  `arg:"-o" default:"0"`
+}
+
+type jobCmd struct {
+	Show *jobShowCmd `arg:"subcommand:show" help:"show job"`
+	List *jobListCmd `arg:"subcommand:list" help:"list jobs"`
+}
+
+func jobShow(ctx context.Context, cmdArgs *jobShowCmd) error {
+	client := ctl.NewJobServiceClient(args.URL, http.DefaultClient)
+	result, err := client.Find(ctx, cmdArgs.ID)
+	if err != nil {
+		return fmt.Errorf("cannot find: %w", err)
+	}
+
+	w := newTabWriter()
+	fmt.Fprintln(w, "Attribute\tValue")
+	fmt.Fprintf(w, "%s\t%d\n", "ID", result.ID)
+	fmt.Fprintf(w, "%s\t%s\n", "Kind", ctl.JobKindIntToString(result.Kind))
+	fmt.Fprintf(w, "%s\t%s\n", "State", ctl.JobStateIntToString(result.State))
+	if result.ImageID != 0 {
+		fmt.Fprintf(w, "%s\t%d\n", "Image ID", result.ImageID)
+	} else {
+		fmt.Fprintf(w, "%s\t%d\n", "System ID", result.SystemID)
+	}
+	fmt.Fprintf(w, "%s\t%d/%d\n", "Attempts", result.Attempts, result.MaxAttempts)
+	fmt.Fprintf(w, "%s\t%s\n", "Created", result.CreatedAt.Local().Format(time.DateTime))
+	fmt.Fprintf(w, "%s\t%s\n", "Run At", result.RunAt.Local().Format(time.DateTime))
+	fmt.Fprintf(w, "%s\t%s\n", "Updated", result.UpdatedAt.Local().Format(time.DateTime))
+	if result.LastError != "" {
+		fmt.Fprintf(w, "%s\t%s\n", "Last Error", result.LastError)
+	}
+	w.Flush()
+
+	return nil
+}
+
+func jobList(ctx context.Context, cmdArgs *jobListCmd) error {
+	client := ctl.NewJobServiceClient(args.URL, http.DefaultClient)
+	result, err := client.List(ctx, cmdArgs.System, cmdArgs.Limit, cmdArgs.Offset)
+	if err != nil {
+		return fmt.Errorf("cannot list: %w", err)
+	}
+
+	w := newTabWriter()
+	fmt.Fprintln(w, "ID\tKind\tState\tSystem ID\tAttempts\tRun At\tLast Error")
+	for _, j := range result {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d/%d\t%s\t%s\n",
+			j.ID,
+			ctl.JobKindIntToString(j.Kind),
+			ctl.JobStateIntToString(j.State),
+			j.SystemID,
+			j.Attempts,
+			j.MaxAttempts,
+			j.RunAt.Local().Format(time.DateTime),
+			payloadSummary(j.LastError))
+	}
+	w.Flush()
+
+	return nil
+}
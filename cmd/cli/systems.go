@@ -23,6 +23,7 @@ type systemRegisterCmd struct {
 
 type systemShowCmd struct {
 	Pattern string `arg:"positional,required" placeholder:"MAC_OR_NAME"`
+	Events  int64  `arg:"-e" default:"20" help:"number of installation events to show"`
 }
 
 type systemListCmd struct {
@@ -58,6 +59,28 @@ type systemDeployCmd struct {
 	Kickstart   string   `arg:"-k" placeholder:"KS_OVERRIDE_CONTENTS"`
 	Comment     string   `arg:"-c"`
 	Duration    string   `arg:"-d" default:"3h"`
+	kernelArgs
+}
+
+// kernelArgs are merged in order: defaults, system, image and installation
+type kernelArgs struct {
+	KernelArgs []string `arg:"--kernel-arg,separate" help:"kernel argument, use --kernel-arg=-KEY to remove previous arguments with KEY or --kernel-arg=-KEY=VALUE to remove the exact argument" placeholder:"ARG"`
+}
+
+type systemUpdateKernelArgsCmd struct {
+	Pattern string `arg:"positional,required" placeholder:"MAC_OR_NAME"`
+	kernelArgs
+}
+
+type systemUpdateNetworkCmd struct {
+	Pattern     string   `arg:"positional,required" placeholder:"MAC_OR_NAME"`
+	Address     string   `arg:"-a" help:"static address with prefix length (e.g. 192.168.1.10/24), blank address configures DHCP"`
+	Gateway     string   `arg:"-g" help:"default gateway"`
+	Nameservers []string `arg:"-n,--nameserver,separate" help:"DNS server"`
+	Device      string   `arg:"-e" help:"interface name or bond name, the installer picks an interface when blank"`
+	VLAN        int      `arg:"--vlan" help:"VLAN ID"`
+	BondSlaves  []string `arg:"--bond-slave,separate" help:"bond member interface name"`
+	BondOpts    string   `arg:"--bond-opts" help:"comma-separated bonding options (e.g. mode=active-backup,miimon=100)"`
 }
 
 type systemBootNetworkCmd struct {
@@ -68,21 +91,40 @@ type systemBootLocalCmd struct {
 	Pattern string `arg:"positional,required" placeholder:"MAC_OR_NAME"`
 }
 
+type systemPowerPatternCmd struct {
+	Pattern string `arg:"positional,required" placeholder:"MAC_OR_NAME"`
+}
+
+type systemPowerOffCmd struct {
+	Pattern string `arg:"positional,required" placeholder:"MAC_OR_NAME"`
+	Force   bool   `arg:"-f" help:"hard power off instead of graceful shutdown"`
+}
+
+type systemPowerCmd struct {
+	Status *systemPowerPatternCmd `arg:"subcommand:status" help:"show power state"`
+	On     *systemPowerPatternCmd `arg:"subcommand:on" help:"power on system"`
+	Off    *systemPowerOffCmd     `arg:"subcommand:off" help:"power off system"`
+	Cycle  *systemPowerPatternCmd `arg:"subcommand:cycle" help:"power cycle (hard reboot) system"`
+}
+
 type emptyCmd struct{}
 
 type systemCmd struct {
-	Register    *systemRegisterCmd    `arg:"subcommand:register" help:"register system"`
-	List        *systemListCmd        `arg:"subcommand:list" help:"list systems"`
-	Show        *systemShowCmd        `arg:"subcommand:show" help:"show system"`
-	Rename      *systemRenameCmd      `arg:"subcommand:rename" help:"rename existing system"`
-	Deploy      *systemDeployCmd      `arg:"subcommand:deploy" help:"deploy an image to a system"`
-	Acquire     *emptyCmd             `arg:"subcommand:acquire" help:"acquire system (deprecated)"`
-	Release     *emptyCmd             `arg:"subcommand:release" help:"release system (deprecated)"`
-	Kickstart   *systemKickstartCmd   `arg:"subcommand:kickstart" help:"show system kickstart"`
-	Logs        *systemLogsCmd        `arg:"subcommand:logs" help:"show installation log history"`
-	Ssh         *systemSshCmd         `arg:"subcommand:ssh" help:"ssh to anaconda during installation"`
-	BootNetwork *systemBootNetworkCmd `arg:"subcommand:bootnet" help:"reset (hard reboot) system and boot from network"`
-	BootLocal   *systemBootLocalCmd   `arg:"subcommand:bootlocal" help:"reset (hard reboot) system and boot from local drive"`
+	Register         *systemRegisterCmd         `arg:"subcommand:register" help:"register system"`
+	List             *systemListCmd             `arg:"subcommand:list" help:"list systems"`
+	Show             *systemShowCmd             `arg:"subcommand:show" help:"show system"`
+	Rename           *systemRenameCmd           `arg:"subcommand:rename" help:"rename existing system"`
+	UpdateKernelArgs *systemUpdateKernelArgsCmd `arg:"subcommand:update-kernel-args" help:"set kernel arguments of system network boot"`
+	UpdateNetwork    *systemUpdateNetworkCmd    `arg:"subcommand:update-network" help:"set static network configuration used during installation"`
+	Deploy           *systemDeployCmd           `arg:"subcommand:deploy" help:"deploy an image to a system"`
+	Acquire          *emptyCmd                  `arg:"subcommand:acquire" help:"acquire system (deprecated)"`
+	Release          *emptyCmd                  `arg:"subcommand:release" help:"release system (deprecated)"`
+	Kickstart        *systemKickstartCmd        `arg:"subcommand:kickstart" help:"show system kickstart"`
+	Logs             *systemLogsCmd             `arg:"subcommand:logs" help:"show installation log history"`
+	Ssh              *systemSshCmd              `arg:"subcommand:ssh" help:"ssh to anaconda during installation"`
+	BootNetwork      *systemBootNetworkCmd      `arg:"subcommand:bootnet" help:"reset (hard reboot) system and boot from network"`
+	BootLocal        *systemBootLocalCmd        `arg:"subcommand:bootlocal" help:"reset (hard reboot) system and boot from local drive"`
+	Power            *systemPowerCmd            `arg:"subcommand:power" help:"power management"`
 }
 
 func systemRegister(ctx context.Context, cmdArgs *systemRegisterCmd) error {
@@ -124,6 +166,30 @@ func systemShow(ctx context.Context, cmdArgs *systemShowCmd) error {
 	if result.UID != nil {
 		fmt.Fprintf(w, "%s\t%s\n", "UID", *result.UID)
 	}
+	if len(result.KernelArgs) > 0 {
+		fmt.Fprintf(w, "%s\t%s\n", "Kernel args", strings.Join(result.KernelArgs, " "))
+	}
+	if n := result.Network; n != nil {
+		fmt.Fprintf(w, "%s\t%s\n", "Network address", n.Address)
+		if n.Gateway != "" {
+			fmt.Fprintf(w, "%s\t%s\n", "Network gateway", n.Gateway)
+		}
+		if len(n.Nameservers) > 0 {
+			fmt.Fprintf(w, "%s\t%s\n", "Network DNS", strings.Join(n.Nameservers, ","))
+		}
+		if n.Device != "" {
+			fmt.Fprintf(w, "%s\t%s\n", "Network device", n.Device)
+		}
+		if n.VLAN > 0 {
+			fmt.Fprintf(w, "%s\t%d\n", "Network VLAN", n.VLAN)
+		}
+		if len(n.BondSlaves) > 0 {
+			fmt.Fprintf(w, "%s\t%s\n", "Network bond", strings.Join(n.BondSlaves, ","))
+		}
+		if n.BondOpts != "" {
+			fmt.Fprintf(w, "%s\t%s\n", "Network bond options", n.BondOpts)
+		}
+	}
 	if len(result.Facts) > 0 {
 		keys := make([]string, 0, len(result.Facts))
 
@@ -139,9 +205,35 @@ func systemShow(ctx context.Context, cmdArgs *systemShowCmd) error {
 	}
 	w.Flush()
 
+	instClient := ctl.NewInstallationServiceClient(args.URL, http.DefaultClient)
+	events, err := instClient.Events(ctx, cmdArgs.Pattern, cmdArgs.Events, 0)
+	if err != nil {
+		return fmt.Errorf("cannot fetch events: %w", err)
+	}
+
+	if len(events) > 0 {
+		w = newTabWriter()
+		fmt.Fprintln(w, "\nTime\tInstallation\tEvent\tTrace ID\tPayload")
+		// events are returned newest first
+		for i := len(events) - 1; i >= 0; i-- {
+			e := events[i]
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", e.CreatedAt.Local().Format(time.DateTime), e.InstallationID, ctl.EventIntToKind(e.Kind), e.TraceID, payloadSummary(e.Payload))
+		}
+		w.Flush()
+	}
+
 	return nil
 }
 
+// payloadSummary returns the first line of a payload shortened for tabular output.
+func payloadSummary(payload string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(payload), "\n")
+	if len(line) > 60 {
+		return line[:57] + "..."
+	}
+	return line
+}
+
 func systemList(ctx context.Context, cmdArgs *systemListCmd) error {
 	client := ctl.NewSystemServiceClient(args.URL, http.DefaultClient)
 	result, err := client.List(ctx, cmdArgs.Limit, cmdArgs.Offset)
@@ -254,6 +346,39 @@ func systemRename(ctx context.Context, cmdArgs *systemRenameCmd) error {
 	return nil
 }
 
+func systemUpdateKernelArgs(ctx context.Context, cmdArgs *systemUpdateKernelArgsCmd) error {
+	client := ctl.NewSystemServiceClient(args.URL, http.DefaultClient)
+	err := client.UpdateKernelArgs(ctx, cmdArgs.Pattern, cmdArgs.KernelArgs)
+	if err != nil {
+		return fmt.Errorf("cannot update system: %w", err)
+	}
+
+	return nil
+}
+
+func systemUpdateNetwork(ctx context.Context, cmdArgs *systemUpdateNetworkCmd) error {
+	client := ctl.NewSystemServiceClient(args.URL, http.DefaultClient)
+	var network *ctl.Network
+	if cmdArgs.Address != "" {
+		network = &ctl.Network{
+			Device:      cmdArgs.Device,
+			Address:     cmdArgs.Address,
+			Gateway:     cmdArgs.Gateway,
+			Nameservers: cmdArgs.Nameservers,
+			VLAN:        cmdArgs.VLAN,
+			BondSlaves:  cmdArgs.BondSlaves,
+			BondOpts:    cmdArgs.BondOpts,
+		}
+	}
+
+	err := client.UpdateNetwork(ctx, cmdArgs.Pattern, network)
+	if err != nil {
+		return fmt.Errorf("cannot update system: %w", err)
+	}
+
+	return nil
+}
+
 var ErrAcquireReleaseDeprecated = errors.New("acquire/release was deprecated, use 'forester-cli deploy' instead")
 
 func systemAcquire(ctx context.Context, cmdArgs *emptyCmd) error {
@@ -271,30 +396,77 @@ func systemDeploy(ctx context.Context, cmdArgs *systemDeployCmd) error {
 	}
 
 	client := ctl.NewSystemServiceClient(args.URL, http.DefaultClient)
-	err = client.Deploy(ctx, cmdArgs.Pattern, cmdArgs.Image, cmdArgs.Snippets, cmdArgs.TextSnippet, cmdArgs.Kickstart, cmdArgs.Comment, time.Now().Add(dur))
+	jobID, err := client.Deploy(ctx, cmdArgs.Pattern, cmdArgs.Image, cmdArgs.Snippets, cmdArgs.TextSnippet, cmdArgs.Kickstart, cmdArgs.Comment, cmdArgs.KernelArgs, time.Now().Add(dur))
 	if err != nil {
 		return fmt.Errorf("cannot deploy system: %w", err)
 	}
+	fmt.Printf("Power job %d enqueued\n", jobID)
 
 	return nil
 }
 
 func systemBootNetwork(ctx context.Context, cmdArgs *systemBootNetworkCmd) error {
 	client := ctl.NewSystemServiceClient(args.URL, http.DefaultClient)
-	err := client.BootNetwork(ctx, cmdArgs.Pattern)
+	jobID, err := client.BootNetwork(ctx, cmdArgs.Pattern)
 	if err != nil {
 		return fmt.Errorf("cannot reset system: %w", err)
 	}
+	fmt.Printf("Power job %d enqueued\n", jobID)
 
 	return nil
 }
 
 func systemBootLocal(ctx context.Context, cmdArgs *systemBootLocalCmd) error {
 	client := ctl.NewSystemServiceClient(args.URL, http.DefaultClient)
-	err := client.BootLocal(ctx, cmdArgs.Pattern)
+	jobID, err := client.BootLocal(ctx, cmdArgs.Pattern)
 	if err != nil {
 		return fmt.Errorf("cannot reset system: %w", err)
 	}
+	fmt.Printf("Power job %d enqueued\n", jobID)
+
+	return nil
+}
+
+func systemPowerStatus(ctx context.Context, cmdArgs *systemPowerPatternCmd) error {
+	client := ctl.NewSystemServiceClient(args.URL, http.DefaultClient)
+	state, err := client.PowerState(ctx, cmdArgs.Pattern)
+	if err != nil {
+		return fmt.Errorf("cannot get power state: %w", err)
+	}
+
+	fmt.Println(state)
+	return nil
+}
+
+func systemPowerOn(ctx context.Context, cmdArgs *systemPowerPatternCmd) error {
+	client := ctl.NewSystemServiceClient(args.URL, http.DefaultClient)
+	jobID, err := client.PowerOn(ctx, cmdArgs.Pattern)
+	if err != nil {
+		return fmt.Errorf("cannot power on system: %w", err)
+	}
+	fmt.Printf("Power job %d enqueued\n", jobID)
+
+	return nil
+}
+
+func systemPowerOff(ctx context.Context, cmdArgs *systemPowerOffCmd) error {
+	client := ctl.NewSystemServiceClient(args.URL, http.DefaultClient)
+	jobID, err := client.PowerOff(ctx, cmdArgs.Pattern, cmdArgs.Force)
+	if err != nil {
+		return fmt.Errorf("cannot power off system: %w", err)
+	}
+	fmt.Printf("Power job %d enqueued\n", jobID)
+
+	return nil
+}
+
+func systemPowerCycle(ctx context.Context, cmdArgs *systemPowerPatternCmd) error {
+	client := ctl.NewSystemServiceClient(args.URL, http.DefaultClient)
+	jobID, err := client.PowerCycle(ctx, cmdArgs.Pattern)
+	if err != nil {
+		return fmt.Errorf("cannot power cycle system: %w", err)
+	}
+	fmt.Printf("Power job %d enqueued\n", jobID)
 
 	return nil
 }
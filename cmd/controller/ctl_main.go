@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,13 +13,17 @@ import (
 	"time"
 
 	chi "github.com/go-chi/chi/v5"
+	pgx "github.com/jackc/pgx/v5"
 
 	"forester/internal/api/ctl"
 	"forester/internal/config"
 	"forester/internal/db"
+	"forester/internal/dhcp"
 	"forester/internal/img"
+	"forester/internal/jobs"
 	"forester/internal/logging"
 	"forester/internal/logstore"
+	"forester/internal/metal"
 	"forester/internal/mux"
 	"forester/internal/tftp"
 )
@@ -64,6 +69,44 @@ func main() {
 		return
 	}
 
+	if config.Dhcp.Enabled {
+		proxy, err := dhcp.Start(ctx,
+			fmt.Sprintf(":%d", config.Dhcp.Port),
+			fmt.Sprintf(":%d", config.Dhcp.PXEPort),
+			net.ParseIP(config.Dhcp.ServerIP),
+			config.BaseURL(),
+			knownSystem)
+		if err != nil {
+			slog.ErrorContext(ctx, "error when starting ProxyDHCP service", "err", err)
+			os.Exit(1)
+		}
+		defer proxy.Shutdown()
+	}
+
+	count, err := db.GetApplianceDao(ctx).EncryptPlainCredentials(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "plaintext appliance credentials were not encrypted", "err", err)
+	} else if count > 0 {
+		slog.InfoContext(ctx, "encrypted plaintext appliance credentials", "count", count)
+	}
+
+	count, err = db.GetImageDao(ctx).FailInterrupted(ctx, "processing interrupted by controller restart")
+	if err != nil {
+		slog.ErrorContext(ctx, "cannot update interrupted images", "err", err)
+	} else if count > 0 {
+		slog.WarnContext(ctx, "image processing was interrupted", "count", count)
+	}
+
+	sessions := metal.StartSessionKeeper(ctx)
+	defer sessions.Shutdown()
+
+	queue, err := jobs.Start(ctx, mux.ImportImage)
+	if err != nil {
+		slog.ErrorContext(ctx, "error when starting job workers", "err", err)
+		os.Exit(1)
+	}
+	defer queue.Shutdown()
+
 	rootRouter := chi.NewRouter()
 	bootstrapRouter := chi.NewRouter()
 	bootRouter := chi.NewRouter()
@@ -129,3 +172,16 @@ func main() {
 
 	slog.DebugContext(ctx, "shutdown complete")
 }
+
+// knownSystem returns true for registered systems or for all systems in discovery mode
+func knownSystem(ctx context.Context, mac net.HardwareAddr) bool {
+	if config.Dhcp.Discovery {
+		return true
+	}
+
+	_, err := db.GetSystemDao(ctx).FindByMac(ctx, mac)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		slog.ErrorContext(ctx, "cannot find system for DHCP request", "mac", mac, "err", err)
+	}
+	return err == nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"strings"
 
 	"forester/internal/db"
@@ -18,12 +19,42 @@ type ApplianceServiceImpl struct{}
 
 var ErrUnknownApplianceKind = errors.New("unknown appliance kind")
 
-func (i ApplianceServiceImpl) Create(ctx context.Context, name string, kind int16, uri string) error {
+// maskURI hides password embedded in appliance URI so it never leaves the controller.
+func maskURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+
+	return u.Redacted()
+}
+
+// splitURICredentials removes user info from appliance URI so credentials embedded in it are
+// stored encrypted, explicitly given username and password take precedence.
+func splitURICredentials(uri, username, password string) (string, string, string) {
+	u, err := url.Parse(uri)
+	if err != nil || u.User == nil {
+		return uri, username, password
+	}
+
+	if username == "" && password == "" {
+		username = u.User.Username()
+		password, _ = u.User.Password()
+	}
+	u.User = nil
+
+	return u.String(), username, password
+}
+
+func (i ApplianceServiceImpl) Create(ctx context.Context, name string, kind int16, uri string, username string, password string) error {
 	dao := db.GetApplianceDao(ctx)
+	uri, username, password = splitURICredentials(uri, username, password)
 	record := model.Appliance{
-		Kind: model.ParseKind(kind),
-		Name: name,
-		URI:  uri,
+		Kind:     model.ParseKind(kind),
+		Name:     name,
+		URI:      uri,
+		Username: username,
+		Password: password,
 	}
 	if record.Kind < 0 {
 		return fmt.Errorf("%w: %d", ErrUnknownApplianceKind, kind)
@@ -45,10 +76,11 @@ func (i ApplianceServiceImpl) Find(ctx context.Context, name string) (*Appliance
 	}
 
 	return &Appliance{
-		ID:   result.ID,
-		Name: result.Name,
-		Kind: int16(result.Kind),
-		URI:  result.URI,
+		ID:       result.ID,
+		Name:     result.Name,
+		Kind:     int16(result.Kind),
+		URI:      maskURI(result.URI),
+		Username: result.Username,
 	}, nil
 }
 
@@ -63,16 +95,32 @@ func (i ApplianceServiceImpl) List(ctx context.Context, limit int64, offset int6
 	result := make([]*Appliance, len(list))
 	for i, item := range list {
 		result[i] = &Appliance{
-			ID:   item.ID,
-			Name: item.Name,
-			Kind: int16(item.Kind),
-			URI:  item.URI,
+			ID:       item.ID,
+			Name:     item.Name,
+			Kind:     int16(item.Kind),
+			URI:      maskURI(item.URI),
+			Username: item.Username,
 		}
 	}
 
 	return result, nil
 }
 
+func (i ApplianceServiceImpl) UpdateCredentials(ctx context.Context, name string, username string, password string) error {
+	dao := db.GetApplianceDao(ctx)
+	app, err := dao.Find(ctx, name)
+	if err != nil {
+		return fmt.Errorf("unknown appliance '%s': %w", name, err)
+	}
+
+	err = dao.UpdateCredentials(ctx, app.ID, username, password)
+	if err != nil {
+		return fmt.Errorf("cannot update credentials: %w", err)
+	}
+
+	return nil
+}
+
 func (i ApplianceServiceImpl) Enlist(ctx context.Context, name string, namePattern string) error {
 	dao := db.GetApplianceDao(ctx)
 	app, err := dao.Find(ctx, name)
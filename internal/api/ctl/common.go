@@ -43,6 +43,26 @@ func ImageIntToKind(kind int16) string {
 	}
 }
 
+func ImageIntToStatus(status int16) string {
+	s := model.ParseImageStatus(status).String()
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+func ImageIntIsFailed(status int16) bool {
+	return model.ParseImageStatus(status) == model.FailedImageStatus
+}
+
+func ImageIntToSecureBoot(status int16) string {
+	s := model.ParseSecureBootStatus(status).String()
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
 func ApplianceKindToInt(kind string) int16 {
 	switch strings.ToLower(kind) {
 	case "noop":
@@ -53,6 +73,10 @@ func ApplianceKindToInt(kind string) int16 {
 		return int16(model.RedfishApplianceKind)
 	case "redfish_manual":
 		return int16(model.RedfishManualApplianceKind)
+	case "ipmi":
+		return int16(model.IpmiApplianceKind)
+	case "redfish_vmedia":
+		return int16(model.RedfishVMediaApplianceKind)
 	default:
 		panic(fmt.Sprintf("unknown kind: %s", kind))
 	}
@@ -68,6 +92,10 @@ func ApplianceIntToKind(kind int16) string {
 		return "redfish"
 	case model.RedfishManualApplianceKind:
 		return "redfish_manual"
+	case model.IpmiApplianceKind:
+		return "ipmi"
+	case model.RedfishVMediaApplianceKind:
+		return "redfish_vmedia"
 	default:
 		panic(fmt.Sprintf("unknown kind: %d", kind))
 	}
@@ -122,3 +150,19 @@ func SnippetIntToKind(kind int16) string {
 		panic(fmt.Sprintf("unknown kind: %d", kind))
 	}
 }
+
+func InstallStateIntToString(state int16) string {
+	return model.InstallState(state).String()
+}
+
+func EventIntToKind(kind int16) string {
+	return model.EventKind(kind).String()
+}
+
+func JobKindIntToString(kind int16) string {
+	return model.JobKind(kind).String()
+}
+
+func JobStateIntToString(state int16) string {
+	return model.JobState(state).String()
+}
@@ -3,15 +3,19 @@ package ctl
 import chi "github.com/go-chi/chi/v5"
 
 var Service = struct {
-	Image     ImageService
-	Appliance ApplianceService
-	System    SystemService
-	Snippet   SnippetService
+	Image        ImageService
+	Appliance    ApplianceService
+	System       SystemService
+	Snippet      SnippetService
+	Installation InstallationService
+	Job          JobService
 }{
 	ImageServiceImpl{},
 	ApplianceServiceImpl{},
 	SystemServiceImpl{},
 	SnippetServiceImpl{},
+	InstallationServiceImpl{},
+	JobServiceImpl{},
 }
 
 func MountServices(r chi.Router) {
@@ -23,4 +27,8 @@ func MountServices(r chi.Router) {
 	r.Handle("/rpc/SystemService/*", systemSrvHandler)
 	snippetSrvHandler := NewSnippetServiceServer(Service.Snippet)
 	r.Handle("/rpc/SnippetService/*", snippetSrvHandler)
+	installationSrvHandler := NewInstallationServiceServer(Service.Installation)
+	r.Handle("/rpc/InstallationService/*", installationSrvHandler)
+	jobSrvHandler := NewJobServiceServer(Service.Job)
+	r.Handle("/rpc/JobService/*", jobSrvHandler)
 }
@@ -2,23 +2,86 @@ package ctl
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"net/url"
+	"strings"
 
 	"forester/internal/db"
+	"forester/internal/jobs"
 	"forester/internal/model"
+	"forester/internal/mux"
 )
 
 var _ ImageService = ImageServiceImpl{}
 
 type ImageServiceImpl struct{}
 
-func (i ImageServiceImpl) Create(ctx context.Context, image *Image) (int64, string, error) {
+var ErrInvalidSha256 = errors.New("invalid SHA256 checksum")
+
+var ErrInvalidImportURL = errors.New("invalid import URL, only http and https are supported")
+
+var ErrImageNotReady = errors.New("image is not ready")
+
+var ErrImageInUse = errors.New("image is used by installations, use force to delete them too")
+
+var ErrArchMismatch = errors.New("image architecture does not match system")
+
+var ErrSecureBootFailed = errors.New("image failed Secure Boot verification")
+
+var ErrInvalidContainerRef = errors.New("invalid container reference")
+
+// parseContainerRef validates container registry reference, blank reference is allowed.
+func parseContainerRef(ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if strings.ContainsAny(ref, " \t\r\n\"'") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidContainerRef, ref)
+	}
+
+	return ref, nil
+}
+
+// parseSha256 validates hex encoded checksum, blank checksum is allowed.
+func parseSha256(sum string) (string, error) {
+	sum = strings.ToLower(sum)
+	if b, err := hex.DecodeString(sum); err != nil || (len(b) != 0 && len(b) != sha256.Size) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidSha256, sum)
+	}
+
+	return sum, nil
+}
+
+func (i ImageServiceImpl) Create(ctx context.Context, image *Image, isoSha256 string) (int64, string, error) {
 	dao := db.GetImageDao(ctx)
+	expected, err := parseSha256(isoSha256)
+	if err != nil {
+		return 0, "", err
+	}
+	containerRef, err := parseContainerRef(image.ContainerRef)
+	if err != nil {
+		return 0, "", err
+	}
+	bootcRef, err := parseContainerRef(image.BootcRef)
+	if err != nil {
+		return 0, "", err
+	}
+	kernelArgs, err := parseKernelArgs(image.KernelArgs)
+	if err != nil {
+		return 0, "", err
+	}
 	dbImage := model.Image{
-		Name: image.Name,
+		Name:            image.Name,
+		ExpectedSha256:  expected,
+		Status:          model.UploadingImageStatus,
+		ContainerRef:    containerRef,
+		BootcRef:        bootcRef,
+		SignatureVerify: image.SignatureVerify,
+		KernelArgs:      kernelArgs,
 	}
 
-	err := dao.Create(ctx, &dbImage)
+	err = dao.Create(ctx, &dbImage)
 	if err != nil {
 		return 0, "", fmt.Errorf("cannot create: %w", err)
 	}
@@ -26,6 +89,36 @@ func (i ImageServiceImpl) Create(ctx context.Context, image *Image) (int64, stri
 	return dbImage.ID, fmt.Sprintf("/img/%d", dbImage.ID), nil
 }
 
+func (i ImageServiceImpl) Import(ctx context.Context, name string, importURL string, isoSha256 string) (int64, error) {
+	dao := db.GetImageDao(ctx)
+	expected, err := parseSha256(isoSha256)
+	if err != nil {
+		return 0, err
+	}
+
+	u, err := url.Parse(importURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidImportURL, importURL)
+	}
+
+	dbImage := model.Image{
+		Name:           name,
+		ExpectedSha256: expected,
+		Status:         model.UploadingImageStatus,
+	}
+	err = dao.Create(ctx, &dbImage)
+	if err != nil {
+		return 0, fmt.Errorf("cannot create: %w", err)
+	}
+
+	_, err = jobs.EnqueueImport(ctx, dbImage.ID, importURL)
+	if err != nil {
+		return 0, fmt.Errorf("cannot import: %w", err)
+	}
+
+	return dbImage.ID, nil
+}
+
 func (i ImageServiceImpl) GetByID(ctx context.Context, imageID int64) (*Image, error) {
 	dao := db.GetImageDao(ctx)
 	result, err := dao.FindByID(ctx, imageID)
@@ -34,8 +127,21 @@ func (i ImageServiceImpl) GetByID(ctx context.Context, imageID int64) (*Image, e
 	}
 
 	return &Image{
-		ID:   result.ID,
-		Name: result.Name,
+		ID:                result.ID,
+		Name:              result.Name,
+		Kind:              int16(result.Kind),
+		Status:            int16(result.Status),
+		StatusMessage:     result.StatusMessage,
+		Progress:          result.Progress,
+		Arch:              result.Arch,
+		ContainerRef:      result.ContainerRef,
+		BootcRef:          result.BootcRef,
+		SignatureVerify:   result.SignatureVerify,
+		Meta:              result.Meta,
+		SecureBoot:        int16(result.SecureBoot),
+		SecureBootMessage: result.SecureBootMessage,
+		KernelArgs:        result.KernelArgs,
+		ExpectedSha256:    result.ExpectedSha256,
 	}, nil
 }
 
@@ -47,29 +153,141 @@ func (i ImageServiceImpl) Find(ctx context.Context, pattern string) (*Image, err
 	}
 
 	return &Image{
-		ID:   result.ID,
-		Name: result.Name,
+		ID:                result.ID,
+		Name:              result.Name,
+		Kind:              int16(result.Kind),
+		Status:            int16(result.Status),
+		StatusMessage:     result.StatusMessage,
+		Progress:          result.Progress,
+		Arch:              result.Arch,
+		ContainerRef:      result.ContainerRef,
+		BootcRef:          result.BootcRef,
+		SignatureVerify:   result.SignatureVerify,
+		Meta:              result.Meta,
+		SecureBoot:        int16(result.SecureBoot),
+		SecureBootMessage: result.SecureBootMessage,
+		KernelArgs:        result.KernelArgs,
+		ExpectedSha256:    result.ExpectedSha256,
 	}, nil
 }
 
-func (i ImageServiceImpl) List(ctx context.Context, limit int64, offset int64) ([]*Image, error) {
+func (i ImageServiceImpl) List(ctx context.Context, limit int64, offset int64, meta map[string]string) ([]*Image, error) {
 	dao := db.GetImageDao(ctx)
 	ensureLimitNonzero(&limit)
-	images, err := dao.List(ctx, limit, offset)
+	images, err := dao.List(ctx, limit, offset, meta)
 	if err != nil {
 		return nil, fmt.Errorf("cannot list: %w", err)
 	}
 	result := make([]*Image, len(images))
 	for i, img := range images {
 		result[i] = &Image{
-			ID:   img.ID,
-			Name: img.Name,
-			Kind: int16(img.Kind),
+			ID:                img.ID,
+			Name:              img.Name,
+			Kind:              int16(img.Kind),
+			Status:            int16(img.Status),
+			StatusMessage:     img.StatusMessage,
+			Progress:          img.Progress,
+			Arch:              img.Arch,
+			ContainerRef:      img.ContainerRef,
+			BootcRef:          img.BootcRef,
+			SignatureVerify:   img.SignatureVerify,
+			Meta:              img.Meta,
+			SecureBoot:        int16(img.SecureBoot),
+			SecureBootMessage: img.SecureBootMessage,
+			KernelArgs:        img.KernelArgs,
+			ExpectedSha256:    img.ExpectedSha256,
 		}
 	}
 	return result, nil
 }
 
-func (i ImageServiceImpl) Delete(ctx context.Context, name string) error {
-	panic("implement me")
+func (i ImageServiceImpl) UpdateContainer(ctx context.Context, name string, containerRef *string, bootcRef *string, signatureVerify *bool) error {
+	dao := db.GetImageDao(ctx)
+	image, err := dao.Find(ctx, name)
+	if err != nil {
+		return fmt.Errorf("cannot find: %w", err)
+	}
+
+	if containerRef != nil {
+		ref, err := parseContainerRef(*containerRef)
+		if err != nil {
+			return err
+		}
+		containerRef = &ref
+	}
+	if bootcRef != nil {
+		ref, err := parseContainerRef(*bootcRef)
+		if err != nil {
+			return err
+		}
+		bootcRef = &ref
+	}
+
+	err = dao.UpdateContainer(ctx, image.ID, containerRef, bootcRef, signatureVerify)
+	if err != nil {
+		return fmt.Errorf("cannot update: %w", err)
+	}
+
+	return nil
+}
+
+func (i ImageServiceImpl) UpdateKernelArgs(ctx context.Context, name string, kernelArgs []string) error {
+	dao := db.GetImageDao(ctx)
+	image, err := dao.Find(ctx, name)
+	if err != nil {
+		return fmt.Errorf("cannot find: %w", err)
+	}
+
+	kernelArgs, err = parseKernelArgs(kernelArgs)
+	if err != nil {
+		return err
+	}
+
+	err = dao.UpdateKernelArgs(ctx, image.ID, kernelArgs)
+	if err != nil {
+		return fmt.Errorf("cannot update: %w", err)
+	}
+
+	return nil
+}
+
+func (i ImageServiceImpl) Extract(ctx context.Context, name string) error {
+	dao := db.GetImageDao(ctx)
+	image, err := dao.Find(ctx, name)
+	if err != nil {
+		return fmt.Errorf("cannot find: %w", err)
+	}
+
+	err = mux.ExtractImage(ctx, image)
+	if err != nil {
+		return fmt.Errorf("cannot extract: %w", err)
+	}
+
+	return nil
+}
+
+func (i ImageServiceImpl) Delete(ctx context.Context, name string, force bool) error {
+	dao := db.GetImageDao(ctx)
+	image, err := dao.Find(ctx, name)
+	if err != nil {
+		return fmt.Errorf("cannot find: %w", err)
+	}
+
+	// installations including their history are deleted together with the image
+	if !force {
+		count, err := db.GetInstallationDao(ctx).CountByImage(ctx, image.ID)
+		if err != nil {
+			return fmt.Errorf("cannot check installations: %w", err)
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: %d installation(s)", ErrImageInUse, count)
+		}
+	}
+
+	err = mux.DeleteImage(ctx, image)
+	if err != nil {
+		return fmt.Errorf("cannot delete: %w", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,87 @@
+package ctl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"forester/internal/config"
+	"forester/internal/db"
+	"forester/internal/model"
+)
+
+// imageDaoMock holds a single image
+type imageDaoMock struct {
+	db.ImageDao
+	image   *model.Image
+	deleted bool
+}
+
+func (dao *imageDaoMock) Find(ctx context.Context, pattern string) (*model.Image, error) {
+	return dao.image, nil
+}
+
+func (dao *imageDaoMock) FindByID(ctx context.Context, id int64) (*model.Image, error) {
+	return dao.image, nil
+}
+
+func (dao *imageDaoMock) Delete(ctx context.Context, id int64) error {
+	dao.deleted = true
+	return nil
+}
+
+// installationDaoMock returns fixed number of installations of an image
+type installationDaoMock struct {
+	db.InstallationDao
+	count int64
+}
+
+func (dao *installationDaoMock) CountByImage(ctx context.Context, imageID int64) (int64, error) {
+	return dao.count, nil
+}
+
+func TestImageDelete(t *testing.T) {
+	tests := map[string]struct {
+		installations int64
+		force         bool
+		err           error
+	}{
+		"unused":            {},
+		"used":              {installations: 2, err: ErrImageInUse},
+		"used with force":   {installations: 2, force: true},
+		"unused with force": {force: true},
+	}
+
+	origImage, origInst := db.GetImageDao, db.GetInstallationDao
+	t.Cleanup(func() { db.GetImageDao, db.GetInstallationDao = origImage, origInst })
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			config.Images.Directory = t.TempDir()
+			dir := filepath.Join(config.Images.Directory, "1")
+			require.NoError(t, os.MkdirAll(dir, 0744))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "image.iso"), []byte("iso"), 0644))
+
+			iDao := &imageDaoMock{image: &model.Image{ID: 1, Name: "test", Status: model.ReadyImageStatus}}
+			db.GetImageDao = func(ctx context.Context) db.ImageDao { return iDao }
+			db.GetInstallationDao = func(ctx context.Context) db.InstallationDao {
+				return &installationDaoMock{count: tc.installations}
+			}
+
+			err := ImageServiceImpl{}.Delete(context.Background(), "test", tc.force)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				require.False(t, iDao.deleted)
+				require.FileExists(t, filepath.Join(dir, "image.iso"))
+				return
+			}
+
+			require.NoError(t, err)
+			require.True(t, iDao.deleted)
+			require.NoDirExists(t, dir)
+		})
+	}
+}
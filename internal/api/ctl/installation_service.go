@@ -0,0 +1,69 @@
+package ctl
+
+import (
+	"context"
+	"fmt"
+
+	"forester/internal/db"
+)
+
+var _ InstallationService = InstallationServiceImpl{}
+
+type InstallationServiceImpl struct{}
+
+func (i InstallationServiceImpl) List(ctx context.Context, systemPattern string, limit int64, offset int64) ([]*Installation, error) {
+	system, err := db.GetSystemDao(ctx).Find(ctx, systemPattern)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find: %w", err)
+	}
+
+	ensureLimitNonzero(&limit)
+	list, err := db.GetInstallationDao(ctx).ListBySystem(ctx, system.ID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list: %w", err)
+	}
+
+	result := make([]*Installation, len(list))
+	for i, item := range list {
+		result[i] = &Installation{
+			ID:         item.ID,
+			UUID:       item.UUID.String(),
+			State:      int16(item.State),
+			SystemID:   item.SystemID,
+			ImageID:    item.ImageID,
+			QueuedAt:   item.QueuedAt,
+			ValidUntil: item.ValidUntil,
+			Comment:    item.Comment,
+			KernelArgs: item.KernelArgs,
+		}
+	}
+
+	return result, nil
+}
+
+func (i InstallationServiceImpl) Events(ctx context.Context, systemPattern string, limit int64, offset int64) ([]*InstallationEvent, error) {
+	system, err := db.GetSystemDao(ctx).Find(ctx, systemPattern)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find: %w", err)
+	}
+
+	ensureLimitNonzero(&limit)
+	list, err := db.GetInstallationEventDao(ctx).ListBySystem(ctx, system.ID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list: %w", err)
+	}
+
+	result := make([]*InstallationEvent, len(list))
+	for i, item := range list {
+		result[i] = &InstallationEvent{
+			ID:             item.ID,
+			InstallationID: item.InstallationID,
+			Kind:           int16(item.Kind),
+			CreatedAt:      item.CreatedAt,
+			TraceID:        item.TraceID,
+			Payload:        item.Payload,
+		}
+	}
+
+	return result, nil
+}
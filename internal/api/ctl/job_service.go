@@ -0,0 +1,66 @@
+package ctl
+
+import (
+	"context"
+	"fmt"
+
+	"forester/internal/db"
+	"forester/internal/model"
+	"forester/internal/ptr"
+)
+
+var _ JobService = JobServiceImpl{}
+
+type JobServiceImpl struct{}
+
+func jobToPayload(j *model.Job) *Job {
+	return &Job{
+		ID:          j.ID,
+		Kind:        int16(j.Kind),
+		State:       int16(j.State),
+		SystemID:    ptr.From(j.SystemID),
+		ImageID:     ptr.From(j.ImageID),
+		Attempts:    j.Attempts,
+		MaxAttempts: j.MaxAttempts,
+		RunAt:       j.RunAt,
+		CreatedAt:   j.CreatedAt,
+		UpdatedAt:   j.UpdatedAt,
+		LastError:   j.LastError,
+	}
+}
+
+func (i JobServiceImpl) Find(ctx context.Context, jobID int64) (*Job, error) {
+	job, err := db.GetJobDao(ctx).FindByID(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find: %w", err)
+	}
+
+	return jobToPayload(job), nil
+}
+
+func (i JobServiceImpl) List(ctx context.Context, systemPattern string, limit int64, offset int64) ([]*Job, error) {
+	var list []*model.Job
+	var err error
+
+	dao := db.GetJobDao(ctx)
+	ensureLimitNonzero(&limit)
+	if systemPattern == "" {
+		list, err = dao.List(ctx, limit, offset)
+	} else {
+		system, findErr := db.GetSystemDao(ctx).Find(ctx, systemPattern)
+		if findErr != nil {
+			return nil, fmt.Errorf("cannot find: %w", findErr)
+		}
+		list, err = dao.ListBySystem(ctx, system.ID, limit, offset)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot list: %w", err)
+	}
+
+	result := make([]*Job, len(list))
+	for i, item := range list {
+		result[i] = jobToPayload(item)
+	}
+
+	return result, nil
+}
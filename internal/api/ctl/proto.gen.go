@@ -1,4 +1,4 @@
-// forester-controller v0.0.1 d26a5eee3495db4a3089d289a86d28d292a83e50
+// forester-controller v0.0.1 0de1b1cb3f2a723b7747744c277fc945d1fd5e3d
 // --
 // Code generated by webrpc-gen@v0.14.0-dev with golang generator. DO NOT EDIT.
 //
@@ -30,7 +30,7 @@ func WebRPCSchemaVersion() string {
 
 // Schema hash generated from your RIDL schema
 func WebRPCSchemaHash() string {
-	return "d26a5eee3495db4a3089d289a86d28d292a83e50"
+	return "0de1b1cb3f2a723b7747744c277fc945d1fd5e3d"
 }
 
 //
@@ -38,16 +38,29 @@ func WebRPCSchemaHash() string {
 //
 
 type Image struct {
-	ID   int64  `json:"ID"`
-	Name string `json:"Name"`
-	Kind int16  `json:"Kind"`
+	ID                int64             `json:"ID"`
+	Name              string            `json:"Name"`
+	Kind              int16             `json:"Kind"`
+	Status            int16             `json:"Status"`
+	StatusMessage     string            `json:"StatusMessage"`
+	Progress          int16             `json:"Progress"`
+	Arch              string            `json:"Arch"`
+	ContainerRef      string            `json:"ContainerRef"`
+	BootcRef          string            `json:"BootcRef"`
+	SignatureVerify   bool              `json:"SignatureVerify"`
+	Meta              map[string]string `json:"Meta"`
+	SecureBoot        int16             `json:"SecureBoot"`
+	SecureBootMessage string            `json:"SecureBootMessage"`
+	KernelArgs        []string          `json:"KernelArgs"`
+	ExpectedSha256    string            `json:"ExpectedSha256"`
 }
 
 type Appliance struct {
-	ID   int64  `json:"ID"`
-	Name string `json:"Name"`
-	Kind int16  `json:"Kind"`
-	URI  string `json:"URI"`
+	ID       int64  `json:"ID"`
+	Name     string `json:"Name"`
+	Kind     int16  `json:"Kind"`
+	URI      string `json:"URI"`
+	Username string `json:"Username"`
 }
 
 type NewSystem struct {
@@ -58,12 +71,24 @@ type NewSystem struct {
 	UID           *string           `json:"UID"`
 }
 
+type Network struct {
+	Device      string   `json:"Device"`
+	Address     string   `json:"Address"`
+	Gateway     string   `json:"Gateway"`
+	Nameservers []string `json:"Nameservers"`
+	VLAN        int      `json:"VLAN"`
+	BondSlaves  []string `json:"BondSlaves"`
+	BondOpts    string   `json:"BondOpts"`
+}
+
 type System struct {
 	ID          int64             `json:"ID"`
 	Name        string            `json:"Name"`
 	HwAddrs     []string          `json:"HwAddrs"`
 	Facts       map[string]string `json:"Facts"`
 	Comment     string            `json:"Comment"`
+	KernelArgs  []string          `json:"KernelArgs"`
+	Network     *Network          `json:"Network"`
 	ApplianceID *int64            `json:"ApplianceID"`
 	Appliance   *Appliance        `json:"Appliance"`
 	UID         *string           `json:"UID"`
@@ -76,6 +101,41 @@ type LogEntry struct {
 	ModifiedAt time.Time `json:"ModifiedAt"`
 }
 
+type Installation struct {
+	ID         int64     `json:"ID"`
+	UUID       string    `json:"UUID"`
+	State      int16     `json:"State"`
+	SystemID   int64     `json:"SystemID"`
+	ImageID    int64     `json:"ImageID"`
+	QueuedAt   time.Time `json:"QueuedAt"`
+	ValidUntil time.Time `json:"ValidUntil"`
+	Comment    string    `json:"Comment"`
+	KernelArgs []string  `json:"KernelArgs"`
+}
+
+type InstallationEvent struct {
+	ID             int64     `json:"ID"`
+	InstallationID int64     `json:"InstallationID"`
+	Kind           int16     `json:"Kind"`
+	CreatedAt      time.Time `json:"CreatedAt"`
+	TraceID        string    `json:"TraceID"`
+	Payload        string    `json:"Payload"`
+}
+
+type Job struct {
+	ID          int64     `json:"ID"`
+	Kind        int16     `json:"Kind"`
+	State       int16     `json:"State"`
+	SystemID    int64     `json:"SystemID"`
+	ImageID     int64     `json:"ImageID"`
+	Attempts    int16     `json:"Attempts"`
+	MaxAttempts int16     `json:"MaxAttempts"`
+	RunAt       time.Time `json:"RunAt"`
+	CreatedAt   time.Time `json:"CreatedAt"`
+	UpdatedAt   time.Time `json:"UpdatedAt"`
+	LastError   string    `json:"LastError"`
+}
+
 type Snippet struct {
 	ID   int64  `json:"ID"`
 	Name string `json:"Name"`
@@ -84,17 +144,22 @@ type Snippet struct {
 }
 
 type ImageService interface {
-	Create(ctx context.Context, image *Image) (int64, string, error)
+	Create(ctx context.Context, image *Image, isoSha256 string) (int64, string, error)
+	Import(ctx context.Context, name string, url string, isoSha256 string) (int64, error)
 	GetByID(ctx context.Context, imageID int64) (*Image, error)
 	Find(ctx context.Context, pattern string) (*Image, error)
-	List(ctx context.Context, limit int64, offset int64) ([]*Image, error)
-	Delete(ctx context.Context, name string) error
+	List(ctx context.Context, limit int64, offset int64, meta map[string]string) ([]*Image, error)
+	UpdateContainer(ctx context.Context, name string, containerRef *string, bootcRef *string, signatureVerify *bool) error
+	UpdateKernelArgs(ctx context.Context, name string, kernelArgs []string) error
+	Extract(ctx context.Context, name string) error
+	Delete(ctx context.Context, name string, force bool) error
 }
 
 type ApplianceService interface {
-	Create(ctx context.Context, name string, kind int16, uri string) error
+	Create(ctx context.Context, name string, kind int16, uri string, username string, password string) error
 	Find(ctx context.Context, name string) (*Appliance, error)
 	List(ctx context.Context, limit int64, offset int64) ([]*Appliance, error)
+	UpdateCredentials(ctx context.Context, name string, username string, password string) error
 	Enlist(ctx context.Context, name string, namePattern string) error
 	Delete(ctx context.Context, name string) error
 }
@@ -103,14 +168,30 @@ type SystemService interface {
 	Register(ctx context.Context, system *NewSystem) error
 	Find(ctx context.Context, pattern string) (*System, error)
 	Rename(ctx context.Context, pattern string, newName string) error
-	Deploy(ctx context.Context, systemPattern string, imagePattern string, snippets []string, customSnippet string, ksOverride string, comment string, duration time.Time) error
+	UpdateKernelArgs(ctx context.Context, systemPattern string, kernelArgs []string) error
+	UpdateNetwork(ctx context.Context, systemPattern string, network *Network) error
+	Deploy(ctx context.Context, systemPattern string, imagePattern string, snippets []string, customSnippet string, ksOverride string, comment string, kernelArgs []string, duration time.Time) (int64, error)
 	List(ctx context.Context, limit int64, offset int64) ([]*System, error)
-	BootNetwork(ctx context.Context, systemPattern string) error
-	BootLocal(ctx context.Context, systemPattern string) error
+	BootNetwork(ctx context.Context, systemPattern string) (int64, error)
+	BootLocal(ctx context.Context, systemPattern string) (int64, error)
+	PowerState(ctx context.Context, systemPattern string) (string, error)
+	PowerOn(ctx context.Context, systemPattern string) (int64, error)
+	PowerOff(ctx context.Context, systemPattern string, force bool) (int64, error)
+	PowerCycle(ctx context.Context, systemPattern string) (int64, error)
 	Kickstart(ctx context.Context, systemPattern string) (string, error)
 	Logs(ctx context.Context, systemPattern string) ([]*LogEntry, error)
 }
 
+type InstallationService interface {
+	List(ctx context.Context, systemPattern string, limit int64, offset int64) ([]*Installation, error)
+	Events(ctx context.Context, systemPattern string, limit int64, offset int64) ([]*InstallationEvent, error)
+}
+
+type JobService interface {
+	Find(ctx context.Context, jobID int64) (*Job, error)
+	List(ctx context.Context, systemPattern string, limit int64, offset int64) ([]*Job, error)
+}
+
 type SnippetService interface {
 	Create(ctx context.Context, name string, kind int16, body string) error
 	Find(ctx context.Context, name string) (*Snippet, error)
@@ -122,15 +203,20 @@ type SnippetService interface {
 var WebRPCServices = map[string][]string{
 	"ImageService": {
 		"Create",
+		"Import",
 		"GetByID",
 		"Find",
 		"List",
+		"UpdateContainer",
+		"UpdateKernelArgs",
+		"Extract",
 		"Delete",
 	},
 	"ApplianceService": {
 		"Create",
 		"Find",
 		"List",
+		"UpdateCredentials",
 		"Enlist",
 		"Delete",
 	},
@@ -138,13 +224,27 @@ var WebRPCServices = map[string][]string{
 		"Register",
 		"Find",
 		"Rename",
+		"UpdateKernelArgs",
+		"UpdateNetwork",
 		"Deploy",
 		"List",
 		"BootNetwork",
 		"BootLocal",
+		"PowerState",
+		"PowerOn",
+		"PowerOff",
+		"PowerCycle",
 		"Kickstart",
 		"Logs",
 	},
+	"InstallationService": {
+		"List",
+		"Events",
+	},
+	"JobService": {
+		"Find",
+		"List",
+	},
 	"SnippetService": {
 		"Create",
 		"Find",
@@ -191,12 +291,20 @@ func (s *imageServiceServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	switch r.URL.Path {
 	case "/rpc/ImageService/Create":
 		handler = s.serveCreateJSON
+	case "/rpc/ImageService/Import":
+		handler = s.serveImportJSON
 	case "/rpc/ImageService/GetByID":
 		handler = s.serveGetByIDJSON
 	case "/rpc/ImageService/Find":
 		handler = s.serveFindJSON
 	case "/rpc/ImageService/List":
 		handler = s.serveListJSON
+	case "/rpc/ImageService/UpdateContainer":
+		handler = s.serveUpdateContainerJSON
+	case "/rpc/ImageService/UpdateKernelArgs":
+		handler = s.serveUpdateKernelArgsJSON
+	case "/rpc/ImageService/Extract":
+		handler = s.serveExtractJSON
 	case "/rpc/ImageService/Delete":
 		handler = s.serveDeleteJSON
 	default:
@@ -239,6 +347,7 @@ func (s *imageServiceServer) serveCreateJSON(ctx context.Context, w http.Respons
 
 	reqPayload := struct {
 		Arg0 *Image `json:"image"`
+		Arg1 string `json:"isoSha256"`
 	}{}
 	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
 		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
@@ -246,7 +355,7 @@ func (s *imageServiceServer) serveCreateJSON(ctx context.Context, w http.Respons
 	}
 
 	// Call service method implementation.
-	ret0, ret1, err := s.ImageService.Create(ctx, reqPayload.Arg0)
+	ret0, ret1, err := s.ImageService.Create(ctx, reqPayload.Arg0, reqPayload.Arg1)
 	if err != nil {
 		rpcErr, ok := err.(WebRPCError)
 		if !ok {
@@ -271,6 +380,51 @@ func (s *imageServiceServer) serveCreateJSON(ctx context.Context, w http.Respons
 	w.Write(respBody)
 }
 
+func (s *imageServiceServer) serveImportJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "Import")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string `json:"name"`
+		Arg1 string `json:"url"`
+		Arg2 string `json:"isoSha256"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	ret0, err := s.ImageService.Import(ctx, reqPayload.Arg0, reqPayload.Arg1, reqPayload.Arg2)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	respPayload := struct {
+		Ret0 int64 `json:"id"`
+	}{ret0}
+	respBody, err := json.Marshal(respPayload)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respBody)
+}
+
 func (s *imageServiceServer) serveGetByIDJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	ctx = context.WithValue(ctx, MethodNameCtxKey, "GetByID")
 
@@ -368,8 +522,9 @@ func (s *imageServiceServer) serveListJSON(ctx context.Context, w http.ResponseW
 	defer r.Body.Close()
 
 	reqPayload := struct {
-		Arg0 int64 `json:"limit"`
-		Arg1 int64 `json:"offset"`
+		Arg0 int64             `json:"limit"`
+		Arg1 int64             `json:"offset"`
+		Arg2 map[string]string `json:"meta"`
 	}{}
 	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
 		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
@@ -377,7 +532,7 @@ func (s *imageServiceServer) serveListJSON(ctx context.Context, w http.ResponseW
 	}
 
 	// Call service method implementation.
-	ret0, err := s.ImageService.List(ctx, reqPayload.Arg0, reqPayload.Arg1)
+	ret0, err := s.ImageService.List(ctx, reqPayload.Arg0, reqPayload.Arg1, reqPayload.Arg2)
 	if err != nil {
 		rpcErr, ok := err.(WebRPCError)
 		if !ok {
@@ -401,6 +556,112 @@ func (s *imageServiceServer) serveListJSON(ctx context.Context, w http.ResponseW
 	w.Write(respBody)
 }
 
+func (s *imageServiceServer) serveUpdateContainerJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "UpdateContainer")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string  `json:"name"`
+		Arg1 *string `json:"containerRef"`
+		Arg2 *string `json:"bootcRef"`
+		Arg3 *bool   `json:"signatureVerify"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	err = s.ImageService.UpdateContainer(ctx, reqPayload.Arg0, reqPayload.Arg1, reqPayload.Arg2, reqPayload.Arg3)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("{}"))
+}
+
+func (s *imageServiceServer) serveUpdateKernelArgsJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "UpdateKernelArgs")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string   `json:"name"`
+		Arg1 []string `json:"kernelArgs"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	err = s.ImageService.UpdateKernelArgs(ctx, reqPayload.Arg0, reqPayload.Arg1)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("{}"))
+}
+
+func (s *imageServiceServer) serveExtractJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "Extract")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string `json:"name"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	err = s.ImageService.Extract(ctx, reqPayload.Arg0)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("{}"))
+}
+
 func (s *imageServiceServer) serveDeleteJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	ctx = context.WithValue(ctx, MethodNameCtxKey, "Delete")
 
@@ -413,6 +674,7 @@ func (s *imageServiceServer) serveDeleteJSON(ctx context.Context, w http.Respons
 
 	reqPayload := struct {
 		Arg0 string `json:"name"`
+		Arg1 bool   `json:"force"`
 	}{}
 	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
 		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
@@ -420,7 +682,7 @@ func (s *imageServiceServer) serveDeleteJSON(ctx context.Context, w http.Respons
 	}
 
 	// Call service method implementation.
-	err = s.ImageService.Delete(ctx, reqPayload.Arg0)
+	err = s.ImageService.Delete(ctx, reqPayload.Arg0, reqPayload.Arg1)
 	if err != nil {
 		rpcErr, ok := err.(WebRPCError)
 		if !ok {
@@ -480,6 +742,8 @@ func (s *applianceServiceServer) ServeHTTP(w http.ResponseWriter, r *http.Reques
 		handler = s.serveFindJSON
 	case "/rpc/ApplianceService/List":
 		handler = s.serveListJSON
+	case "/rpc/ApplianceService/UpdateCredentials":
+		handler = s.serveUpdateCredentialsJSON
 	case "/rpc/ApplianceService/Enlist":
 		handler = s.serveEnlistJSON
 	case "/rpc/ApplianceService/Delete":
@@ -526,6 +790,8 @@ func (s *applianceServiceServer) serveCreateJSON(ctx context.Context, w http.Res
 		Arg0 string `json:"name"`
 		Arg1 int16  `json:"kind"`
 		Arg2 string `json:"uri"`
+		Arg3 string `json:"username"`
+		Arg4 string `json:"password"`
 	}{}
 	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
 		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
@@ -533,7 +799,7 @@ func (s *applianceServiceServer) serveCreateJSON(ctx context.Context, w http.Res
 	}
 
 	// Call service method implementation.
-	err = s.ApplianceService.Create(ctx, reqPayload.Arg0, reqPayload.Arg1, reqPayload.Arg2)
+	err = s.ApplianceService.Create(ctx, reqPayload.Arg0, reqPayload.Arg1, reqPayload.Arg2, reqPayload.Arg3, reqPayload.Arg4)
 	if err != nil {
 		rpcErr, ok := err.(WebRPCError)
 		if !ok {
@@ -635,6 +901,42 @@ func (s *applianceServiceServer) serveListJSON(ctx context.Context, w http.Respo
 	w.Write(respBody)
 }
 
+func (s *applianceServiceServer) serveUpdateCredentialsJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "UpdateCredentials")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string `json:"name"`
+		Arg1 string `json:"username"`
+		Arg2 string `json:"password"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	err = s.ApplianceService.UpdateCredentials(ctx, reqPayload.Arg0, reqPayload.Arg1, reqPayload.Arg2)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("{}"))
+}
+
 func (s *applianceServiceServer) serveEnlistJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	ctx = context.WithValue(ctx, MethodNameCtxKey, "Enlist")
 
@@ -749,6 +1051,10 @@ func (s *systemServiceServer) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		handler = s.serveFindJSON
 	case "/rpc/SystemService/Rename":
 		handler = s.serveRenameJSON
+	case "/rpc/SystemService/UpdateKernelArgs":
+		handler = s.serveUpdateKernelArgsJSON
+	case "/rpc/SystemService/UpdateNetwork":
+		handler = s.serveUpdateNetworkJSON
 	case "/rpc/SystemService/Deploy":
 		handler = s.serveDeployJSON
 	case "/rpc/SystemService/List":
@@ -757,6 +1063,14 @@ func (s *systemServiceServer) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		handler = s.serveBootNetworkJSON
 	case "/rpc/SystemService/BootLocal":
 		handler = s.serveBootLocalJSON
+	case "/rpc/SystemService/PowerState":
+		handler = s.servePowerStateJSON
+	case "/rpc/SystemService/PowerOn":
+		handler = s.servePowerOnJSON
+	case "/rpc/SystemService/PowerOff":
+		handler = s.servePowerOffJSON
+	case "/rpc/SystemService/PowerCycle":
+		handler = s.servePowerCycleJSON
 	case "/rpc/SystemService/Kickstart":
 		handler = s.serveKickstartJSON
 	case "/rpc/SystemService/Logs":
@@ -901,8 +1215,8 @@ func (s *systemServiceServer) serveRenameJSON(ctx context.Context, w http.Respon
 	w.Write([]byte("{}"))
 }
 
-func (s *systemServiceServer) serveDeployJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
-	ctx = context.WithValue(ctx, MethodNameCtxKey, "Deploy")
+func (s *systemServiceServer) serveUpdateKernelArgsJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "UpdateKernelArgs")
 
 	reqBody, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -912,13 +1226,8 @@ func (s *systemServiceServer) serveDeployJSON(ctx context.Context, w http.Respon
 	defer r.Body.Close()
 
 	reqPayload := struct {
-		Arg0 string    `json:"systemPattern"`
-		Arg1 string    `json:"imagePattern"`
-		Arg2 []string  `json:"snippets"`
-		Arg3 string    `json:"customSnippet"`
-		Arg4 string    `json:"ksOverride"`
-		Arg5 string    `json:"comment"`
-		Arg6 time.Time `json:"duration"`
+		Arg0 string   `json:"systemPattern"`
+		Arg1 []string `json:"kernelArgs"`
 	}{}
 	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
 		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
@@ -926,7 +1235,7 @@ func (s *systemServiceServer) serveDeployJSON(ctx context.Context, w http.Respon
 	}
 
 	// Call service method implementation.
-	err = s.SystemService.Deploy(ctx, reqPayload.Arg0, reqPayload.Arg1, reqPayload.Arg2, reqPayload.Arg3, reqPayload.Arg4, reqPayload.Arg5, reqPayload.Arg6)
+	err = s.SystemService.UpdateKernelArgs(ctx, reqPayload.Arg0, reqPayload.Arg1)
 	if err != nil {
 		rpcErr, ok := err.(WebRPCError)
 		if !ok {
@@ -941,8 +1250,8 @@ func (s *systemServiceServer) serveDeployJSON(ctx context.Context, w http.Respon
 	w.Write([]byte("{}"))
 }
 
-func (s *systemServiceServer) serveListJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
-	ctx = context.WithValue(ctx, MethodNameCtxKey, "List")
+func (s *systemServiceServer) serveUpdateNetworkJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "UpdateNetwork")
 
 	reqBody, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -952,8 +1261,8 @@ func (s *systemServiceServer) serveListJSON(ctx context.Context, w http.Response
 	defer r.Body.Close()
 
 	reqPayload := struct {
-		Arg0 int64 `json:"limit"`
-		Arg1 int64 `json:"offset"`
+		Arg0 string   `json:"systemPattern"`
+		Arg1 *Network `json:"network"`
 	}{}
 	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
 		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
@@ -961,7 +1270,7 @@ func (s *systemServiceServer) serveListJSON(ctx context.Context, w http.Response
 	}
 
 	// Call service method implementation.
-	ret0, err := s.SystemService.List(ctx, reqPayload.Arg0, reqPayload.Arg1)
+	err = s.SystemService.UpdateNetwork(ctx, reqPayload.Arg0, reqPayload.Arg1)
 	if err != nil {
 		rpcErr, ok := err.(WebRPCError)
 		if !ok {
@@ -971,22 +1280,13 @@ func (s *systemServiceServer) serveListJSON(ctx context.Context, w http.Response
 		return
 	}
 
-	respPayload := struct {
-		Ret0 []*System `json:"systems"`
-	}{ret0}
-	respBody, err := json.Marshal(respPayload)
-	if err != nil {
-		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
-		return
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	w.Write(respBody)
+	w.Write([]byte("{}"))
 }
 
-func (s *systemServiceServer) serveBootNetworkJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
-	ctx = context.WithValue(ctx, MethodNameCtxKey, "BootNetwork")
+func (s *systemServiceServer) serveDeployJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "Deploy")
 
 	reqBody, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -996,7 +1296,14 @@ func (s *systemServiceServer) serveBootNetworkJSON(ctx context.Context, w http.R
 	defer r.Body.Close()
 
 	reqPayload := struct {
-		Arg0 string `json:"systemPattern"`
+		Arg0 string    `json:"systemPattern"`
+		Arg1 string    `json:"imagePattern"`
+		Arg2 []string  `json:"snippets"`
+		Arg3 string    `json:"customSnippet"`
+		Arg4 string    `json:"ksOverride"`
+		Arg5 string    `json:"comment"`
+		Arg6 []string  `json:"kernelArgs"`
+		Arg7 time.Time `json:"duration"`
 	}{}
 	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
 		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
@@ -1004,7 +1311,7 @@ func (s *systemServiceServer) serveBootNetworkJSON(ctx context.Context, w http.R
 	}
 
 	// Call service method implementation.
-	err = s.SystemService.BootNetwork(ctx, reqPayload.Arg0)
+	ret0, err := s.SystemService.Deploy(ctx, reqPayload.Arg0, reqPayload.Arg1, reqPayload.Arg2, reqPayload.Arg3, reqPayload.Arg4, reqPayload.Arg5, reqPayload.Arg6, reqPayload.Arg7)
 	if err != nil {
 		rpcErr, ok := err.(WebRPCError)
 		if !ok {
@@ -1014,13 +1321,22 @@ func (s *systemServiceServer) serveBootNetworkJSON(ctx context.Context, w http.R
 		return
 	}
 
+	respPayload := struct {
+		Ret0 int64 `json:"jobID"`
+	}{ret0}
+	respBody, err := json.Marshal(respPayload)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("{}"))
+	w.Write(respBody)
 }
 
-func (s *systemServiceServer) serveBootLocalJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
-	ctx = context.WithValue(ctx, MethodNameCtxKey, "BootLocal")
+func (s *systemServiceServer) serveListJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "List")
 
 	reqBody, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -1030,7 +1346,8 @@ func (s *systemServiceServer) serveBootLocalJSON(ctx context.Context, w http.Res
 	defer r.Body.Close()
 
 	reqPayload := struct {
-		Arg0 string `json:"systemPattern"`
+		Arg0 int64 `json:"limit"`
+		Arg1 int64 `json:"offset"`
 	}{}
 	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
 		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
@@ -1038,7 +1355,7 @@ func (s *systemServiceServer) serveBootLocalJSON(ctx context.Context, w http.Res
 	}
 
 	// Call service method implementation.
-	err = s.SystemService.BootLocal(ctx, reqPayload.Arg0)
+	ret0, err := s.SystemService.List(ctx, reqPayload.Arg0, reqPayload.Arg1)
 	if err != nil {
 		rpcErr, ok := err.(WebRPCError)
 		if !ok {
@@ -1048,13 +1365,22 @@ func (s *systemServiceServer) serveBootLocalJSON(ctx context.Context, w http.Res
 		return
 	}
 
+	respPayload := struct {
+		Ret0 []*System `json:"systems"`
+	}{ret0}
+	respBody, err := json.Marshal(respPayload)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("{}"))
+	w.Write(respBody)
 }
 
-func (s *systemServiceServer) serveKickstartJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
-	ctx = context.WithValue(ctx, MethodNameCtxKey, "Kickstart")
+func (s *systemServiceServer) serveBootNetworkJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "BootNetwork")
 
 	reqBody, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -1072,7 +1398,7 @@ func (s *systemServiceServer) serveKickstartJSON(ctx context.Context, w http.Res
 	}
 
 	// Call service method implementation.
-	ret0, err := s.SystemService.Kickstart(ctx, reqPayload.Arg0)
+	ret0, err := s.SystemService.BootNetwork(ctx, reqPayload.Arg0)
 	if err != nil {
 		rpcErr, ok := err.(WebRPCError)
 		if !ok {
@@ -1083,7 +1409,7 @@ func (s *systemServiceServer) serveKickstartJSON(ctx context.Context, w http.Res
 	}
 
 	respPayload := struct {
-		Ret0 string `json:"contents"`
+		Ret0 int64 `json:"jobID"`
 	}{ret0}
 	respBody, err := json.Marshal(respPayload)
 	if err != nil {
@@ -1096,9 +1422,586 @@ func (s *systemServiceServer) serveKickstartJSON(ctx context.Context, w http.Res
 	w.Write(respBody)
 }
 
-func (s *systemServiceServer) serveLogsJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
-	ctx = context.WithValue(ctx, MethodNameCtxKey, "Logs")
-
+func (s *systemServiceServer) serveBootLocalJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "BootLocal")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string `json:"systemPattern"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	ret0, err := s.SystemService.BootLocal(ctx, reqPayload.Arg0)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	respPayload := struct {
+		Ret0 int64 `json:"jobID"`
+	}{ret0}
+	respBody, err := json.Marshal(respPayload)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respBody)
+}
+
+func (s *systemServiceServer) servePowerStateJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "PowerState")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string `json:"systemPattern"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	ret0, err := s.SystemService.PowerState(ctx, reqPayload.Arg0)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	respPayload := struct {
+		Ret0 string `json:"state"`
+	}{ret0}
+	respBody, err := json.Marshal(respPayload)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respBody)
+}
+
+func (s *systemServiceServer) servePowerOnJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "PowerOn")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string `json:"systemPattern"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	ret0, err := s.SystemService.PowerOn(ctx, reqPayload.Arg0)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	respPayload := struct {
+		Ret0 int64 `json:"jobID"`
+	}{ret0}
+	respBody, err := json.Marshal(respPayload)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respBody)
+}
+
+func (s *systemServiceServer) servePowerOffJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "PowerOff")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string `json:"systemPattern"`
+		Arg1 bool   `json:"force"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	ret0, err := s.SystemService.PowerOff(ctx, reqPayload.Arg0, reqPayload.Arg1)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	respPayload := struct {
+		Ret0 int64 `json:"jobID"`
+	}{ret0}
+	respBody, err := json.Marshal(respPayload)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respBody)
+}
+
+func (s *systemServiceServer) servePowerCycleJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "PowerCycle")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string `json:"systemPattern"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	ret0, err := s.SystemService.PowerCycle(ctx, reqPayload.Arg0)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	respPayload := struct {
+		Ret0 int64 `json:"jobID"`
+	}{ret0}
+	respBody, err := json.Marshal(respPayload)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respBody)
+}
+
+func (s *systemServiceServer) serveKickstartJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "Kickstart")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string `json:"systemPattern"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	ret0, err := s.SystemService.Kickstart(ctx, reqPayload.Arg0)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	respPayload := struct {
+		Ret0 string `json:"contents"`
+	}{ret0}
+	respBody, err := json.Marshal(respPayload)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respBody)
+}
+
+func (s *systemServiceServer) serveLogsJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "Logs")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string `json:"systemPattern"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	ret0, err := s.SystemService.Logs(ctx, reqPayload.Arg0)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	respPayload := struct {
+		Ret0 []*LogEntry `json:"logs"`
+	}{ret0}
+	respBody, err := json.Marshal(respPayload)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respBody)
+}
+
+func (s *systemServiceServer) sendErrorJSON(w http.ResponseWriter, r *http.Request, rpcErr WebRPCError) {
+	if s.OnError != nil {
+		s.OnError(r, &rpcErr)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(rpcErr.HTTPStatus)
+
+	respBody, _ := json.Marshal(rpcErr)
+	w.Write(respBody)
+}
+
+type installationServiceServer struct {
+	InstallationService
+	OnError func(r *http.Request, rpcErr *WebRPCError)
+}
+
+func NewInstallationServiceServer(svc InstallationService) *installationServiceServer {
+	return &installationServiceServer{
+		InstallationService: svc,
+	}
+}
+
+func (s *installationServiceServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		// In case of a panic, serve a HTTP 500 error and then panic.
+		if rr := recover(); rr != nil {
+			s.sendErrorJSON(w, r, ErrWebrpcServerPanic.WithCause(fmt.Errorf("%v", rr)))
+			panic(rr)
+		}
+	}()
+
+	ctx := r.Context()
+	ctx = context.WithValue(ctx, HTTPResponseWriterCtxKey, w)
+	ctx = context.WithValue(ctx, HTTPRequestCtxKey, r)
+	ctx = context.WithValue(ctx, ServiceNameCtxKey, "InstallationService")
+
+	var handler func(ctx context.Context, w http.ResponseWriter, r *http.Request)
+	switch r.URL.Path {
+	case "/rpc/InstallationService/List":
+		handler = s.serveListJSON
+	case "/rpc/InstallationService/Events":
+		handler = s.serveEventsJSON
+	default:
+		err := ErrWebrpcBadRoute.WithCause(fmt.Errorf("no handler for path %q", r.URL.Path))
+		s.sendErrorJSON(w, r, err)
+		return
+	}
+
+	if r.Method != "POST" {
+		w.Header().Add("Allow", "POST") // RFC 9110.
+		err := ErrWebrpcBadMethod.WithCause(fmt.Errorf("unsupported method %q (only POST is allowed)", r.Method))
+		s.sendErrorJSON(w, r, err)
+		return
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	contentType = strings.TrimSpace(strings.ToLower(contentType))
+
+	switch contentType {
+	case "application/json":
+		handler(ctx, w, r)
+	default:
+		err := ErrWebrpcBadRequest.WithCause(fmt.Errorf("unexpected Content-Type: %q", r.Header.Get("Content-Type")))
+		s.sendErrorJSON(w, r, err)
+	}
+}
+
+func (s *installationServiceServer) serveListJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "List")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string `json:"systemPattern"`
+		Arg1 int64  `json:"limit"`
+		Arg2 int64  `json:"offset"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	ret0, err := s.InstallationService.List(ctx, reqPayload.Arg0, reqPayload.Arg1, reqPayload.Arg2)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	respPayload := struct {
+		Ret0 []*Installation `json:"installations"`
+	}{ret0}
+	respBody, err := json.Marshal(respPayload)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respBody)
+}
+
+func (s *installationServiceServer) serveEventsJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "Events")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 string `json:"systemPattern"`
+		Arg1 int64  `json:"limit"`
+		Arg2 int64  `json:"offset"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	ret0, err := s.InstallationService.Events(ctx, reqPayload.Arg0, reqPayload.Arg1, reqPayload.Arg2)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	respPayload := struct {
+		Ret0 []*InstallationEvent `json:"events"`
+	}{ret0}
+	respBody, err := json.Marshal(respPayload)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respBody)
+}
+
+func (s *installationServiceServer) sendErrorJSON(w http.ResponseWriter, r *http.Request, rpcErr WebRPCError) {
+	if s.OnError != nil {
+		s.OnError(r, &rpcErr)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(rpcErr.HTTPStatus)
+
+	respBody, _ := json.Marshal(rpcErr)
+	w.Write(respBody)
+}
+
+type jobServiceServer struct {
+	JobService
+	OnError func(r *http.Request, rpcErr *WebRPCError)
+}
+
+func NewJobServiceServer(svc JobService) *jobServiceServer {
+	return &jobServiceServer{
+		JobService: svc,
+	}
+}
+
+func (s *jobServiceServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		// In case of a panic, serve a HTTP 500 error and then panic.
+		if rr := recover(); rr != nil {
+			s.sendErrorJSON(w, r, ErrWebrpcServerPanic.WithCause(fmt.Errorf("%v", rr)))
+			panic(rr)
+		}
+	}()
+
+	ctx := r.Context()
+	ctx = context.WithValue(ctx, HTTPResponseWriterCtxKey, w)
+	ctx = context.WithValue(ctx, HTTPRequestCtxKey, r)
+	ctx = context.WithValue(ctx, ServiceNameCtxKey, "JobService")
+
+	var handler func(ctx context.Context, w http.ResponseWriter, r *http.Request)
+	switch r.URL.Path {
+	case "/rpc/JobService/Find":
+		handler = s.serveFindJSON
+	case "/rpc/JobService/List":
+		handler = s.serveListJSON
+	default:
+		err := ErrWebrpcBadRoute.WithCause(fmt.Errorf("no handler for path %q", r.URL.Path))
+		s.sendErrorJSON(w, r, err)
+		return
+	}
+
+	if r.Method != "POST" {
+		w.Header().Add("Allow", "POST") // RFC 9110.
+		err := ErrWebrpcBadMethod.WithCause(fmt.Errorf("unsupported method %q (only POST is allowed)", r.Method))
+		s.sendErrorJSON(w, r, err)
+		return
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	contentType = strings.TrimSpace(strings.ToLower(contentType))
+
+	switch contentType {
+	case "application/json":
+		handler(ctx, w, r)
+	default:
+		err := ErrWebrpcBadRequest.WithCause(fmt.Errorf("unexpected Content-Type: %q", r.Header.Get("Content-Type")))
+		s.sendErrorJSON(w, r, err)
+	}
+}
+
+func (s *jobServiceServer) serveFindJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "Find")
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
+	reqPayload := struct {
+		Arg0 int64 `json:"jobID"`
+	}{}
+	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
+		return
+	}
+
+	// Call service method implementation.
+	ret0, err := s.JobService.Find(ctx, reqPayload.Arg0)
+	if err != nil {
+		rpcErr, ok := err.(WebRPCError)
+		if !ok {
+			rpcErr = ErrWebrpcEndpoint.WithCause(err)
+		}
+		s.sendErrorJSON(w, r, rpcErr)
+		return
+	}
+
+	respPayload := struct {
+		Ret0 *Job `json:"job"`
+	}{ret0}
+	respBody, err := json.Marshal(respPayload)
+	if err != nil {
+		s.sendErrorJSON(w, r, ErrWebrpcBadResponse.WithCause(fmt.Errorf("failed to marshal json response: %w", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respBody)
+}
+
+func (s *jobServiceServer) serveListJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx = context.WithValue(ctx, MethodNameCtxKey, "List")
+
 	reqBody, err := io.ReadAll(r.Body)
 	if err != nil {
 		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to read request data: %w", err)))
@@ -1108,6 +2011,8 @@ func (s *systemServiceServer) serveLogsJSON(ctx context.Context, w http.Response
 
 	reqPayload := struct {
 		Arg0 string `json:"systemPattern"`
+		Arg1 int64  `json:"limit"`
+		Arg2 int64  `json:"offset"`
 	}{}
 	if err := json.Unmarshal(reqBody, &reqPayload); err != nil {
 		s.sendErrorJSON(w, r, ErrWebrpcBadRequest.WithCause(fmt.Errorf("failed to unmarshal request data: %w", err)))
@@ -1115,7 +2020,7 @@ func (s *systemServiceServer) serveLogsJSON(ctx context.Context, w http.Response
 	}
 
 	// Call service method implementation.
-	ret0, err := s.SystemService.Logs(ctx, reqPayload.Arg0)
+	ret0, err := s.JobService.List(ctx, reqPayload.Arg0, reqPayload.Arg1, reqPayload.Arg2)
 	if err != nil {
 		rpcErr, ok := err.(WebRPCError)
 		if !ok {
@@ -1126,7 +2031,7 @@ func (s *systemServiceServer) serveLogsJSON(ctx context.Context, w http.Response
 	}
 
 	respPayload := struct {
-		Ret0 []*LogEntry `json:"logs"`
+		Ret0 []*Job `json:"jobs"`
 	}{ret0}
 	respBody, err := json.Marshal(respPayload)
 	if err != nil {
@@ -1139,7 +2044,7 @@ func (s *systemServiceServer) serveLogsJSON(ctx context.Context, w http.Response
 	w.Write(respBody)
 }
 
-func (s *systemServiceServer) sendErrorJSON(w http.ResponseWriter, r *http.Request, rpcErr WebRPCError) {
+func (s *jobServiceServer) sendErrorJSON(w http.ResponseWriter, r *http.Request, rpcErr WebRPCError) {
 	if s.OnError != nil {
 		s.OnError(r, &rpcErr)
 	}
@@ -1439,20 +2344,26 @@ func RespondWithError(w http.ResponseWriter, err error) {
 const ImageServicePathPrefix = "/rpc/ImageService/"
 const ApplianceServicePathPrefix = "/rpc/ApplianceService/"
 const SystemServicePathPrefix = "/rpc/SystemService/"
+const InstallationServicePathPrefix = "/rpc/InstallationService/"
+const JobServicePathPrefix = "/rpc/JobService/"
 const SnippetServicePathPrefix = "/rpc/SnippetService/"
 
 type imageServiceClient struct {
 	client HTTPClient
-	urls   [5]string
+	urls   [9]string
 }
 
 func NewImageServiceClient(addr string, client HTTPClient) ImageService {
 	prefix := urlBase(addr) + ImageServicePathPrefix
-	urls := [5]string{
+	urls := [9]string{
 		prefix + "Create",
+		prefix + "Import",
 		prefix + "GetByID",
 		prefix + "Find",
 		prefix + "List",
+		prefix + "UpdateContainer",
+		prefix + "UpdateKernelArgs",
+		prefix + "Extract",
 		prefix + "Delete",
 	}
 	return &imageServiceClient{
@@ -1461,10 +2372,11 @@ func NewImageServiceClient(addr string, client HTTPClient) ImageService {
 	}
 }
 
-func (c *imageServiceClient) Create(ctx context.Context, image *Image) (int64, string, error) {
+func (c *imageServiceClient) Create(ctx context.Context, image *Image, isoSha256 string) (int64, string, error) {
 	in := struct {
 		Arg0 *Image `json:"image"`
-	}{image}
+		Arg1 string `json:"isoSha256"`
+	}{image, isoSha256}
 	out := struct {
 		Ret0 int64  `json:"id"`
 		Ret1 string `json:"uploadPath"`
@@ -1474,6 +2386,20 @@ func (c *imageServiceClient) Create(ctx context.Context, image *Image) (int64, s
 	return out.Ret0, out.Ret1, err
 }
 
+func (c *imageServiceClient) Import(ctx context.Context, name string, url string, isoSha256 string) (int64, error) {
+	in := struct {
+		Arg0 string `json:"name"`
+		Arg1 string `json:"url"`
+		Arg2 string `json:"isoSha256"`
+	}{name, url, isoSha256}
+	out := struct {
+		Ret0 int64 `json:"id"`
+	}{}
+
+	err := doJSONRequest(ctx, c.client, c.urls[1], in, &out)
+	return out.Ret0, err
+}
+
 func (c *imageServiceClient) GetByID(ctx context.Context, imageID int64) (*Image, error) {
 	in := struct {
 		Arg0 int64 `json:"imageID"`
@@ -1482,7 +2408,7 @@ func (c *imageServiceClient) GetByID(ctx context.Context, imageID int64) (*Image
 		Ret0 *Image `json:"image"`
 	}{}
 
-	err := doJSONRequest(ctx, c.client, c.urls[1], in, &out)
+	err := doJSONRequest(ctx, c.client, c.urls[2], in, &out)
 	return out.Ret0, err
 }
 
@@ -1494,42 +2420,73 @@ func (c *imageServiceClient) Find(ctx context.Context, pattern string) (*Image,
 		Ret0 *Image `json:"image"`
 	}{}
 
-	err := doJSONRequest(ctx, c.client, c.urls[2], in, &out)
+	err := doJSONRequest(ctx, c.client, c.urls[3], in, &out)
 	return out.Ret0, err
 }
 
-func (c *imageServiceClient) List(ctx context.Context, limit int64, offset int64) ([]*Image, error) {
+func (c *imageServiceClient) List(ctx context.Context, limit int64, offset int64, meta map[string]string) ([]*Image, error) {
 	in := struct {
-		Arg0 int64 `json:"limit"`
-		Arg1 int64 `json:"offset"`
-	}{limit, offset}
+		Arg0 int64             `json:"limit"`
+		Arg1 int64             `json:"offset"`
+		Arg2 map[string]string `json:"meta"`
+	}{limit, offset, meta}
 	out := struct {
 		Ret0 []*Image `json:"images"`
 	}{}
 
-	err := doJSONRequest(ctx, c.client, c.urls[3], in, &out)
+	err := doJSONRequest(ctx, c.client, c.urls[4], in, &out)
 	return out.Ret0, err
 }
 
-func (c *imageServiceClient) Delete(ctx context.Context, name string) error {
+func (c *imageServiceClient) UpdateContainer(ctx context.Context, name string, containerRef *string, bootcRef *string, signatureVerify *bool) error {
+	in := struct {
+		Arg0 string  `json:"name"`
+		Arg1 *string `json:"containerRef"`
+		Arg2 *string `json:"bootcRef"`
+		Arg3 *bool   `json:"signatureVerify"`
+	}{name, containerRef, bootcRef, signatureVerify}
+	err := doJSONRequest(ctx, c.client, c.urls[5], in, nil)
+	return err
+}
+
+func (c *imageServiceClient) UpdateKernelArgs(ctx context.Context, name string, kernelArgs []string) error {
+	in := struct {
+		Arg0 string   `json:"name"`
+		Arg1 []string `json:"kernelArgs"`
+	}{name, kernelArgs}
+	err := doJSONRequest(ctx, c.client, c.urls[6], in, nil)
+	return err
+}
+
+func (c *imageServiceClient) Extract(ctx context.Context, name string) error {
 	in := struct {
 		Arg0 string `json:"name"`
 	}{name}
-	err := doJSONRequest(ctx, c.client, c.urls[4], in, nil)
+	err := doJSONRequest(ctx, c.client, c.urls[7], in, nil)
+	return err
+}
+
+func (c *imageServiceClient) Delete(ctx context.Context, name string, force bool) error {
+	in := struct {
+		Arg0 string `json:"name"`
+		Arg1 bool   `json:"force"`
+	}{name, force}
+	err := doJSONRequest(ctx, c.client, c.urls[8], in, nil)
 	return err
 }
 
 type applianceServiceClient struct {
 	client HTTPClient
-	urls   [5]string
+	urls   [6]string
 }
 
 func NewApplianceServiceClient(addr string, client HTTPClient) ApplianceService {
 	prefix := urlBase(addr) + ApplianceServicePathPrefix
-	urls := [5]string{
+	urls := [6]string{
 		prefix + "Create",
 		prefix + "Find",
 		prefix + "List",
+		prefix + "UpdateCredentials",
 		prefix + "Enlist",
 		prefix + "Delete",
 	}
@@ -1539,12 +2496,14 @@ func NewApplianceServiceClient(addr string, client HTTPClient) ApplianceService
 	}
 }
 
-func (c *applianceServiceClient) Create(ctx context.Context, name string, kind int16, uri string) error {
+func (c *applianceServiceClient) Create(ctx context.Context, name string, kind int16, uri string, username string, password string) error {
 	in := struct {
 		Arg0 string `json:"name"`
 		Arg1 int16  `json:"kind"`
 		Arg2 string `json:"uri"`
-	}{name, kind, uri}
+		Arg3 string `json:"username"`
+		Arg4 string `json:"password"`
+	}{name, kind, uri, username, password}
 	err := doJSONRequest(ctx, c.client, c.urls[0], in, nil)
 	return err
 }
@@ -1574,12 +2533,22 @@ func (c *applianceServiceClient) List(ctx context.Context, limit int64, offset i
 	return out.Ret0, err
 }
 
+func (c *applianceServiceClient) UpdateCredentials(ctx context.Context, name string, username string, password string) error {
+	in := struct {
+		Arg0 string `json:"name"`
+		Arg1 string `json:"username"`
+		Arg2 string `json:"password"`
+	}{name, username, password}
+	err := doJSONRequest(ctx, c.client, c.urls[3], in, nil)
+	return err
+}
+
 func (c *applianceServiceClient) Enlist(ctx context.Context, name string, namePattern string) error {
 	in := struct {
 		Arg0 string `json:"name"`
 		Arg1 string `json:"namePattern"`
 	}{name, namePattern}
-	err := doJSONRequest(ctx, c.client, c.urls[3], in, nil)
+	err := doJSONRequest(ctx, c.client, c.urls[4], in, nil)
 	return err
 }
 
@@ -1587,25 +2556,31 @@ func (c *applianceServiceClient) Delete(ctx context.Context, name string) error
 	in := struct {
 		Arg0 string `json:"name"`
 	}{name}
-	err := doJSONRequest(ctx, c.client, c.urls[4], in, nil)
+	err := doJSONRequest(ctx, c.client, c.urls[5], in, nil)
 	return err
 }
 
 type systemServiceClient struct {
 	client HTTPClient
-	urls   [9]string
+	urls   [15]string
 }
 
 func NewSystemServiceClient(addr string, client HTTPClient) SystemService {
 	prefix := urlBase(addr) + SystemServicePathPrefix
-	urls := [9]string{
+	urls := [15]string{
 		prefix + "Register",
 		prefix + "Find",
 		prefix + "Rename",
+		prefix + "UpdateKernelArgs",
+		prefix + "UpdateNetwork",
 		prefix + "Deploy",
 		prefix + "List",
 		prefix + "BootNetwork",
 		prefix + "BootLocal",
+		prefix + "PowerState",
+		prefix + "PowerOn",
+		prefix + "PowerOff",
+		prefix + "PowerCycle",
 		prefix + "Kickstart",
 		prefix + "Logs",
 	}
@@ -1644,7 +2619,25 @@ func (c *systemServiceClient) Rename(ctx context.Context, pattern string, newNam
 	return err
 }
 
-func (c *systemServiceClient) Deploy(ctx context.Context, systemPattern string, imagePattern string, snippets []string, customSnippet string, ksOverride string, comment string, duration time.Time) error {
+func (c *systemServiceClient) UpdateKernelArgs(ctx context.Context, systemPattern string, kernelArgs []string) error {
+	in := struct {
+		Arg0 string   `json:"systemPattern"`
+		Arg1 []string `json:"kernelArgs"`
+	}{systemPattern, kernelArgs}
+	err := doJSONRequest(ctx, c.client, c.urls[3], in, nil)
+	return err
+}
+
+func (c *systemServiceClient) UpdateNetwork(ctx context.Context, systemPattern string, network *Network) error {
+	in := struct {
+		Arg0 string   `json:"systemPattern"`
+		Arg1 *Network `json:"network"`
+	}{systemPattern, network}
+	err := doJSONRequest(ctx, c.client, c.urls[4], in, nil)
+	return err
+}
+
+func (c *systemServiceClient) Deploy(ctx context.Context, systemPattern string, imagePattern string, snippets []string, customSnippet string, ksOverride string, comment string, kernelArgs []string, duration time.Time) (int64, error) {
 	in := struct {
 		Arg0 string    `json:"systemPattern"`
 		Arg1 string    `json:"imagePattern"`
@@ -1652,10 +2645,15 @@ func (c *systemServiceClient) Deploy(ctx context.Context, systemPattern string,
 		Arg3 string    `json:"customSnippet"`
 		Arg4 string    `json:"ksOverride"`
 		Arg5 string    `json:"comment"`
-		Arg6 time.Time `json:"duration"`
-	}{systemPattern, imagePattern, snippets, customSnippet, ksOverride, comment, duration}
-	err := doJSONRequest(ctx, c.client, c.urls[3], in, nil)
-	return err
+		Arg6 []string  `json:"kernelArgs"`
+		Arg7 time.Time `json:"duration"`
+	}{systemPattern, imagePattern, snippets, customSnippet, ksOverride, comment, kernelArgs, duration}
+	out := struct {
+		Ret0 int64 `json:"jobID"`
+	}{}
+
+	err := doJSONRequest(ctx, c.client, c.urls[5], in, &out)
+	return out.Ret0, err
 }
 
 func (c *systemServiceClient) List(ctx context.Context, limit int64, offset int64) ([]*System, error) {
@@ -1667,24 +2665,81 @@ func (c *systemServiceClient) List(ctx context.Context, limit int64, offset int6
 		Ret0 []*System `json:"systems"`
 	}{}
 
-	err := doJSONRequest(ctx, c.client, c.urls[4], in, &out)
+	err := doJSONRequest(ctx, c.client, c.urls[6], in, &out)
 	return out.Ret0, err
 }
 
-func (c *systemServiceClient) BootNetwork(ctx context.Context, systemPattern string) error {
+func (c *systemServiceClient) BootNetwork(ctx context.Context, systemPattern string) (int64, error) {
 	in := struct {
 		Arg0 string `json:"systemPattern"`
 	}{systemPattern}
-	err := doJSONRequest(ctx, c.client, c.urls[5], in, nil)
-	return err
+	out := struct {
+		Ret0 int64 `json:"jobID"`
+	}{}
+
+	err := doJSONRequest(ctx, c.client, c.urls[7], in, &out)
+	return out.Ret0, err
 }
 
-func (c *systemServiceClient) BootLocal(ctx context.Context, systemPattern string) error {
+func (c *systemServiceClient) BootLocal(ctx context.Context, systemPattern string) (int64, error) {
 	in := struct {
 		Arg0 string `json:"systemPattern"`
 	}{systemPattern}
-	err := doJSONRequest(ctx, c.client, c.urls[6], in, nil)
-	return err
+	out := struct {
+		Ret0 int64 `json:"jobID"`
+	}{}
+
+	err := doJSONRequest(ctx, c.client, c.urls[8], in, &out)
+	return out.Ret0, err
+}
+
+func (c *systemServiceClient) PowerState(ctx context.Context, systemPattern string) (string, error) {
+	in := struct {
+		Arg0 string `json:"systemPattern"`
+	}{systemPattern}
+	out := struct {
+		Ret0 string `json:"state"`
+	}{}
+
+	err := doJSONRequest(ctx, c.client, c.urls[9], in, &out)
+	return out.Ret0, err
+}
+
+func (c *systemServiceClient) PowerOn(ctx context.Context, systemPattern string) (int64, error) {
+	in := struct {
+		Arg0 string `json:"systemPattern"`
+	}{systemPattern}
+	out := struct {
+		Ret0 int64 `json:"jobID"`
+	}{}
+
+	err := doJSONRequest(ctx, c.client, c.urls[10], in, &out)
+	return out.Ret0, err
+}
+
+func (c *systemServiceClient) PowerOff(ctx context.Context, systemPattern string, force bool) (int64, error) {
+	in := struct {
+		Arg0 string `json:"systemPattern"`
+		Arg1 bool   `json:"force"`
+	}{systemPattern, force}
+	out := struct {
+		Ret0 int64 `json:"jobID"`
+	}{}
+
+	err := doJSONRequest(ctx, c.client, c.urls[11], in, &out)
+	return out.Ret0, err
+}
+
+func (c *systemServiceClient) PowerCycle(ctx context.Context, systemPattern string) (int64, error) {
+	in := struct {
+		Arg0 string `json:"systemPattern"`
+	}{systemPattern}
+	out := struct {
+		Ret0 int64 `json:"jobID"`
+	}{}
+
+	err := doJSONRequest(ctx, c.client, c.urls[12], in, &out)
+	return out.Ret0, err
 }
 
 func (c *systemServiceClient) Kickstart(ctx context.Context, systemPattern string) (string, error) {
@@ -1695,7 +2750,7 @@ func (c *systemServiceClient) Kickstart(ctx context.Context, systemPattern strin
 		Ret0 string `json:"contents"`
 	}{}
 
-	err := doJSONRequest(ctx, c.client, c.urls[7], in, &out)
+	err := doJSONRequest(ctx, c.client, c.urls[13], in, &out)
 	return out.Ret0, err
 }
 
@@ -1707,7 +2762,95 @@ func (c *systemServiceClient) Logs(ctx context.Context, systemPattern string) ([
 		Ret0 []*LogEntry `json:"logs"`
 	}{}
 
-	err := doJSONRequest(ctx, c.client, c.urls[8], in, &out)
+	err := doJSONRequest(ctx, c.client, c.urls[14], in, &out)
+	return out.Ret0, err
+}
+
+type installationServiceClient struct {
+	client HTTPClient
+	urls   [2]string
+}
+
+func NewInstallationServiceClient(addr string, client HTTPClient) InstallationService {
+	prefix := urlBase(addr) + InstallationServicePathPrefix
+	urls := [2]string{
+		prefix + "List",
+		prefix + "Events",
+	}
+	return &installationServiceClient{
+		client: client,
+		urls:   urls,
+	}
+}
+
+func (c *installationServiceClient) List(ctx context.Context, systemPattern string, limit int64, offset int64) ([]*Installation, error) {
+	in := struct {
+		Arg0 string `json:"systemPattern"`
+		Arg1 int64  `json:"limit"`
+		Arg2 int64  `json:"offset"`
+	}{systemPattern, limit, offset}
+	out := struct {
+		Ret0 []*Installation `json:"installations"`
+	}{}
+
+	err := doJSONRequest(ctx, c.client, c.urls[0], in, &out)
+	return out.Ret0, err
+}
+
+func (c *installationServiceClient) Events(ctx context.Context, systemPattern string, limit int64, offset int64) ([]*InstallationEvent, error) {
+	in := struct {
+		Arg0 string `json:"systemPattern"`
+		Arg1 int64  `json:"limit"`
+		Arg2 int64  `json:"offset"`
+	}{systemPattern, limit, offset}
+	out := struct {
+		Ret0 []*InstallationEvent `json:"events"`
+	}{}
+
+	err := doJSONRequest(ctx, c.client, c.urls[1], in, &out)
+	return out.Ret0, err
+}
+
+type jobServiceClient struct {
+	client HTTPClient
+	urls   [2]string
+}
+
+func NewJobServiceClient(addr string, client HTTPClient) JobService {
+	prefix := urlBase(addr) + JobServicePathPrefix
+	urls := [2]string{
+		prefix + "Find",
+		prefix + "List",
+	}
+	return &jobServiceClient{
+		client: client,
+		urls:   urls,
+	}
+}
+
+func (c *jobServiceClient) Find(ctx context.Context, jobID int64) (*Job, error) {
+	in := struct {
+		Arg0 int64 `json:"jobID"`
+	}{jobID}
+	out := struct {
+		Ret0 *Job `json:"job"`
+	}{}
+
+	err := doJSONRequest(ctx, c.client, c.urls[0], in, &out)
+	return out.Ret0, err
+}
+
+func (c *jobServiceClient) List(ctx context.Context, systemPattern string, limit int64, offset int64) ([]*Job, error) {
+	in := struct {
+		Arg0 string `json:"systemPattern"`
+		Arg1 int64  `json:"limit"`
+		Arg2 int64  `json:"offset"`
+	}{systemPattern, limit, offset}
+	out := struct {
+		Ret0 []*Job `json:"jobs"`
+	}{}
+
+	err := doJSONRequest(ctx, c.client, c.urls[1], in, &out)
 	return out.Ret0, err
 }
 
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"forester/internal/db"
+	"forester/internal/jobs"
 	"forester/internal/logstore"
 	"forester/internal/metal"
 	"forester/internal/model"
@@ -19,6 +20,43 @@ import (
 
 var _ SystemService = SystemServiceImpl{}
 
+var ErrInvalidKernelArg = errors.New("invalid kernel argument")
+
+// parseKernelArgs validates kernel arguments, characters interpreted by grub or iPXE
+// scripts are not allowed. Blank arguments are skipped.
+func parseKernelArgs(args []string) ([]string, error) {
+	result := make([]string, 0, len(args))
+	for _, arg := range args {
+		arg = strings.TrimSpace(arg)
+		if arg == "" {
+			continue
+		}
+		if strings.ContainsAny(arg, " \t\r\n\"';\\${}") {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidKernelArg, arg)
+		}
+		result = append(result, arg)
+	}
+
+	return result, nil
+}
+
+// networkToPayload converts static network configuration, nil is returned for DHCP
+func networkToPayload(n *model.Network) *Network {
+	if n == nil {
+		return nil
+	}
+
+	return &Network{
+		Device:      n.Device,
+		Address:     n.Address,
+		Gateway:     n.Gateway,
+		Nameservers: n.Nameservers,
+		VLAN:        n.VLAN,
+		BondSlaves:  n.BondSlaves,
+		BondOpts:    n.BondOpts,
+	}
+}
+
 type SystemServiceImpl struct{}
 
 func (i SystemServiceImpl) Register(ctx context.Context, system *NewSystem) error {
@@ -101,19 +139,22 @@ func (i SystemServiceImpl) Find(ctx context.Context, pattern string) (*System, e
 	}
 
 	payload := &System{
-		ID:      result.System.ID,
-		Name:    result.System.Name,
-		HwAddrs: hwa,
-		Facts:   result.System.Facts.FactsMap(),
-		Comment: result.System.Comment,
-		UID:     result.System.UID,
+		ID:         result.System.ID,
+		Name:       result.System.Name,
+		HwAddrs:    hwa,
+		Facts:      result.System.Facts.FactsMap(),
+		Comment:    result.System.Comment,
+		KernelArgs: result.System.KernelArgs,
+		Network:    networkToPayload(result.System.Network),
+		UID:        result.System.UID,
 	}
 
 	payload.Appliance = &Appliance{
-		ID:   result.Appliance.ID,
-		Name: result.Appliance.Name,
-		Kind: int16(result.Appliance.Kind),
-		URI:  result.Appliance.URI,
+		ID:       result.Appliance.ID,
+		Name:     result.Appliance.Name,
+		Kind:     int16(result.Appliance.Kind),
+		URI:      maskURI(result.Appliance.URI),
+		Username: result.Appliance.Username,
 	}
 
 	return payload, nil
@@ -135,6 +176,60 @@ func (i SystemServiceImpl) Rename(ctx context.Context, pattern, newName string)
 	return nil
 }
 
+func (i SystemServiceImpl) UpdateKernelArgs(ctx context.Context, systemPattern string, kernelArgs []string) error {
+	dao := db.GetSystemDao(ctx)
+	sys, err := dao.Find(ctx, systemPattern)
+	if err != nil {
+		return fmt.Errorf("cannot find system %s: %w", systemPattern, err)
+	}
+
+	kernelArgs, err = parseKernelArgs(kernelArgs)
+	if err != nil {
+		return err
+	}
+
+	err = dao.UpdateKernelArgs(ctx, sys.ID, kernelArgs)
+	if err != nil {
+		return fmt.Errorf("cannot update: %w", err)
+	}
+
+	return nil
+}
+
+func (i SystemServiceImpl) UpdateNetwork(ctx context.Context, systemPattern string, network *Network) error {
+	dao := db.GetSystemDao(ctx)
+	sys, err := dao.Find(ctx, systemPattern)
+	if err != nil {
+		return fmt.Errorf("cannot find system %s: %w", systemPattern, err)
+	}
+
+	var n *model.Network
+	if network != nil {
+		n = &model.Network{
+			Device:      strings.TrimSpace(network.Device),
+			Address:     strings.TrimSpace(network.Address),
+			Gateway:     strings.TrimSpace(network.Gateway),
+			Nameservers: network.Nameservers,
+			VLAN:        network.VLAN,
+			BondSlaves:  network.BondSlaves,
+			BondOpts:    strings.TrimSpace(network.BondOpts),
+		}
+		if n.IsZero() {
+			// blank configuration switches back to DHCP
+			n = nil
+		} else if err = n.Validate(); err != nil {
+			return err
+		}
+	}
+
+	err = dao.UpdateNetwork(ctx, sys.ID, n)
+	if err != nil {
+		return fmt.Errorf("cannot update: %w", err)
+	}
+
+	return nil
+}
+
 func (i SystemServiceImpl) List(ctx context.Context, limit int64, offset int64) ([]*System, error) {
 	dao := db.GetSystemDao(ctx)
 	ensureLimitNonzero(&limit)
@@ -146,29 +241,45 @@ func (i SystemServiceImpl) List(ctx context.Context, limit int64, offset int64)
 	result := make([]*System, len(list))
 	for i, item := range list {
 		result[i] = &System{
-			ID:      item.ID,
-			Name:    item.Name,
-			HwAddrs: item.HwAddrStrings(),
-			Facts:   item.Facts.FactsMap(),
-			Comment: item.Comment,
+			ID:         item.ID,
+			Name:       item.Name,
+			HwAddrs:    item.HwAddrStrings(),
+			Facts:      item.Facts.FactsMap(),
+			Comment:    item.Comment,
+			KernelArgs: item.KernelArgs,
+			Network:    networkToPayload(item.Network),
 		}
 	}
 
 	return result, nil
 }
 
-func (i SystemServiceImpl) Deploy(ctx context.Context, systemPattern string, imagePattern string, snippets []string, customSnippet string, ksOverride string, comment string, validUntil time.Time) error {
+func (i SystemServiceImpl) Deploy(ctx context.Context, systemPattern string, imagePattern string, snippets []string, customSnippet string, ksOverride string, comment string, kernelArgs []string, validUntil time.Time) (int64, error) {
 	daoSystem := db.GetSystemDao(ctx)
 	daoImage := db.GetImageDao(ctx)
 	daoSnip := db.GetSnippetDao(ctx)
 
 	image, err := daoImage.Find(ctx, imagePattern)
 	if err != nil {
-		return fmt.Errorf("cannot find: %w", err)
+		return 0, fmt.Errorf("cannot find: %w", err)
 	}
-	system, err := daoSystem.Find(ctx, systemPattern)
+	if !image.Deployable() {
+		return 0, fmt.Errorf("%w: %s is %s", ErrImageNotReady, image.Name, image.Status.String())
+	}
+	system, err := daoSystem.FindRelated(ctx, systemPattern)
+	if err != nil {
+		return 0, fmt.Errorf("cannot find: %w", err)
+	}
+	if arch := system.System.Facts.FactsMap()[model.ArchFact]; arch != "" && image.Arch != "" && arch != image.Arch {
+		return 0, fmt.Errorf("%w: %s is %s, %s is %s", ErrArchMismatch, image.Name, image.Arch, system.System.Name, arch)
+	}
+	if system.System.SecureBoot() && image.SecureBoot == model.FailedSecureBootStatus {
+		return 0, fmt.Errorf("%w: %s has Secure Boot enabled: %s", ErrSecureBootFailed, system.System.Name, image.SecureBootMessage)
+	}
+
+	kernelArgs, err = parseKernelArgs(kernelArgs)
 	if err != nil {
-		return fmt.Errorf("cannot find: %w", err)
+		return 0, err
 	}
 
 	snippetIDs := make([]int64, len(snippets))
@@ -176,43 +287,76 @@ func (i SystemServiceImpl) Deploy(ctx context.Context, systemPattern string, ima
 		slog.DebugContext(ctx, "checking snippet", "name", snippet)
 		s, err := daoSnip.Find(ctx, snippet)
 		if err != nil {
-			return fmt.Errorf("cannot find snippet named %s: %w", snippet, err)
+			return 0, fmt.Errorf("cannot find snippet named %s: %w", snippet, err)
 		}
 		snippetIDs[i] = s.ID
 	}
 
-	err = daoSystem.Deploy(ctx, system.ID, image.ID, snippetIDs, customSnippet, ksOverride, comment, validUntil)
+	instID, err := daoSystem.Deploy(ctx, system.System.ID, image.ID, snippetIDs, customSnippet, ksOverride, comment, kernelArgs, validUntil)
 	if err != nil {
-		return fmt.Errorf("cannot deploy: %w", err)
+		return 0, fmt.Errorf("cannot deploy: %w", err)
 	}
+	db.RecordEvent(ctx, instID, model.DeployEventKind, fmt.Sprintf("image=%s snippets=%s", image.Name, strings.Join(snippets, ",")))
 
-	// TODO this should be done in the background via notification
-	err = i.BootNetwork(ctx, systemPattern)
+	job, err := jobs.Enqueue(ctx, model.BootNetworkJobKind, system, &instID, 0)
 	if err != nil {
-		return fmt.Errorf("cannot reset after deploy: %w", err)
+		return 0, fmt.Errorf("cannot reset after deploy: %w", err)
 	}
 
-	return nil
+	return job.ID, nil
 }
 
-func (i SystemServiceImpl) BootNetwork(ctx context.Context, systemPattern string) error {
+func (i SystemServiceImpl) BootNetwork(ctx context.Context, systemPattern string) (int64, error) {
+	return i.enqueuePowerJob(ctx, systemPattern, model.BootNetworkJobKind)
+}
+
+func (i SystemServiceImpl) BootLocal(ctx context.Context, systemPattern string) (int64, error) {
+	return i.enqueuePowerJob(ctx, systemPattern, model.BootLocalJobKind)
+}
+
+func (i SystemServiceImpl) PowerState(ctx context.Context, systemPattern string) (string, error) {
 	dao := db.GetSystemDao(ctx)
 	system, err := dao.FindRelated(ctx, systemPattern)
 	if err != nil {
-		return fmt.Errorf("cannot find: %w", err)
+		return "", fmt.Errorf("cannot find: %w", err)
+	}
+
+	state, err := metal.GetPowerState(ctx, system)
+	if err != nil {
+		return "", fmt.Errorf("cannot get power state: %w", err)
 	}
 
-	return metal.BootNetwork(ctx, system)
+	return state.String(), nil
 }
 
-func (i SystemServiceImpl) BootLocal(ctx context.Context, systemPattern string) error {
+func (i SystemServiceImpl) enqueuePowerJob(ctx context.Context, systemPattern string, kind model.JobKind) (int64, error) {
 	dao := db.GetSystemDao(ctx)
 	system, err := dao.FindRelated(ctx, systemPattern)
 	if err != nil {
-		return fmt.Errorf("cannot find: %w", err)
+		return 0, fmt.Errorf("cannot find: %w", err)
+	}
+
+	job, err := jobs.Enqueue(ctx, kind, system, nil, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return job.ID, nil
+}
+
+func (i SystemServiceImpl) PowerOn(ctx context.Context, systemPattern string) (int64, error) {
+	return i.enqueuePowerJob(ctx, systemPattern, model.PowerOnJobKind)
+}
+
+func (i SystemServiceImpl) PowerOff(ctx context.Context, systemPattern string, force bool) (int64, error) {
+	if force {
+		return i.enqueuePowerJob(ctx, systemPattern, model.ForceOffJobKind)
 	}
+	return i.enqueuePowerJob(ctx, systemPattern, model.PowerOffJobKind)
+}
 
-	return metal.BootLocal(ctx, system)
+func (i SystemServiceImpl) PowerCycle(ctx context.Context, systemPattern string) (int64, error) {
+	return i.enqueuePowerJob(ctx, systemPattern, model.PowerCycleJobKind)
 }
 
 func (i SystemServiceImpl) Kickstart(ctx context.Context, pattern string) (string, error) {
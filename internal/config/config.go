@@ -1,8 +1,10 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"os"
 	"path"
 	"path/filepath"
@@ -16,9 +18,10 @@ import (
 
 var config struct {
 	App struct {
-		Port       int    `env:"PORT" env-default:"8000" env-description:"HTTP port of the API service"`
-		SyslogPort int    `env:"SYSLOG_PORT" env-default:"8514" env-description:"syslog TCP and UDP port"`
-		Hostname   string `env:"HOSTNAME" env-default:"" env-description:"hostname of the service exposed through templates"`
+		Port           int    `env:"PORT" env-default:"8000" env-description:"HTTP port of the API service"`
+		SyslogPort     int    `env:"SYSLOG_PORT" env-default:"8514" env-description:"syslog TCP and UDP port"`
+		Hostname       string `env:"HOSTNAME" env-default:"" env-description:"hostname of the service exposed through templates"`
+		CredentialsKey string `env:"CREDENTIALS_KEY" env-default:"" env-description:"passphrase used to encrypt appliance credentials in the database"`
 	} `env-prefix:"APP_"`
 	Database struct {
 		Host        string        `env:"HOST" env-default:"localhost" env-description:"main database hostname"`
@@ -35,23 +38,56 @@ var config struct {
 	Tftp struct {
 		Port int `env:"PORT" env-default:"6969" env-description:"TFTP UDP port (69 requires root)"`
 	} `env-prefix:"APP_"`
+	Dhcp struct {
+		Enabled   bool   `env:"ENABLED" env-default:"false" env-description:"start built-in ProxyDHCP server for PXE and HTTP Boot next to the main DHCP server"`
+		Port      int    `env:"PORT" env-default:"67" env-description:"ProxyDHCP UDP port (67 requires root)"`
+		PXEPort   int    `env:"PXE_PORT" env-default:"4011" env-description:"PXE boot server UDP port"`
+		ServerIP  string `env:"SERVER_IP" env-default:"" env-description:"IP address of TFTP server announced to clients, detected from the receiving interface when blank"`
+		Discovery bool   `env:"DISCOVERY" env-default:"false" env-description:"answer to unknown MAC addresses too (discovery mode)"`
+	} `env-prefix:"DHCP_"`
 	Logging struct {
 		Level     string `env:"LEVEL" env-default:"debug" env-description:"logger level (debug, info, warn, error)"`
 		Syslog    bool   `env:"SYSLOG" env-default:"false" env-description:"write Anaconda syslog data into application log"`
 		SyslogDir string `env:"SYSLOG_DIR" env-default:"logs" env-description:"absolute path to directory with syslog files"`
 	} `env-prefix:"LOGGING_"`
 	Images struct {
-		Directory string `env:"DIR" env-default:"images" env-description:"absolute path to directory with images"`
+		Directory      string        `env:"DIR" env-default:"images" env-description:"absolute path to directory with images"`
+		ImportTimeout  time.Duration `env:"IMPORT_TIMEOUT" env-default:"2h" env-description:"timeout of a single attempt of image download from URL (time interval syntax)"`
+		ImportAttempts int16         `env:"IMPORT_ATTEMPTS" env-default:"5" env-description:"number of image download attempts until an import is marked as failed"`
+		ImportBackoff  time.Duration `env:"IMPORT_BACKOFF" env-default:"30s" env-description:"delay before the first retry of image download, doubled for each next retry (time interval syntax)"`
+		ImportWorkers  int           `env:"IMPORT_WORKERS" env-default:"1" env-description:"number of background workers downloading images from URL, power operations have their own workers"`
+		BootISOScript  bool          `env:"BOOT_ISO_SCRIPT" env-default:"false" env-description:"generate boot.iso with shell script using xorrisofs and mtools instead of built-in writer"`
+		RPMMirror      string        `env:"RPM_MIRROR" env-default:"" env-description:"installation tree URL for netboot images without packages (e.g. https://dl.fedoraproject.org/pub/fedora/linux/releases/40/Everything/x86_64/os/)"`
+		RPMRepos       []string      `env:"RPM_REPOS" env-default:"" env-separator:" " env-description:"additional repositories of netboot images in name=URL format separated by space"`
+		SecureBootCA   string        `env:"SECURE_BOOT_CA" env-default:"" env-description:"PEM file with CA certificates (e.g. Microsoft UEFI CA and distribution Secure Boot CA) shim and grub of images are verified against, verification is skipped when blank"`
 	} `env-prefix:"IMAGES_"`
+	Jobs struct {
+		Workers        int           `env:"WORKERS" env-default:"4" env-description:"number of background workers performing power operations"`
+		PollInterval   time.Duration `env:"POLL_INTERVAL" env-default:"5s" env-description:"how often workers look for due jobs (time interval syntax)"`
+		ApplianceLimit int           `env:"APPLIANCE_LIMIT" env-default:"2" env-description:"maximum number of concurrently running jobs per appliance"`
+		MaxAttempts    int16         `env:"MAX_ATTEMPTS" env-default:"5" env-description:"number of attempts until a job is marked as failed"`
+		RetryBackoff   time.Duration `env:"RETRY_BACKOFF" env-default:"10s" env-description:"delay before the first retry, doubled for each next retry (time interval syntax)"`
+		Timeout        time.Duration `env:"TIMEOUT" env-default:"2m" env-description:"timeout of a single job attempt (time interval syntax)"`
+		BootLocalDelay time.Duration `env:"BOOT_LOCAL_DELAY" env-default:"6s" env-description:"delay of local boot after installation is done (time interval syntax)"`
+	} `env-prefix:"JOBS_"`
+	Redfish struct {
+		SessionIdle time.Duration `env:"SESSION_IDLE" env-default:"10m" env-description:"idle time after which a cached BMC session is logged out (time interval syntax)"`
+		Keepalive   time.Duration `env:"KEEPALIVE" env-default:"1m" env-description:"how often cached BMC sessions are refreshed (time interval syntax)"`
+	} `env-prefix:"REDFISH_"`
 }
 
+var ErrInvalidIP = errors.New("invalid IPv4 address")
+
 // Config shortcuts
 var (
 	Application = &config.App
 	Database    = &config.Database
 	Tftp        = &config.Tftp
+	Dhcp        = &config.Dhcp
 	Logging     = &config.Logging
 	Images      = &config.Images
+	Jobs        = &config.Jobs
+	Redfish     = &config.Redfish
 )
 
 // Initialize loads configuration from provided .env files, the first existing file wins.
@@ -86,6 +122,9 @@ func Initialize(configFiles ...string) error {
 	if err != nil {
 		return fmt.Errorf("syslog directory config error: %w", err)
 	}
+	if config.Dhcp.ServerIP != "" && net.ParseIP(config.Dhcp.ServerIP).To4() == nil {
+		return fmt.Errorf("dhcp server IP config error: %w: %s", ErrInvalidIP, config.Dhcp.ServerIP)
+	}
 
 	// print key configuration values
 	pwd, _ := os.Getwd()
@@ -98,9 +137,36 @@ func Initialize(configFiles ...string) error {
 		"hostname", config.App.Hostname,
 		"port", config.App.Port,
 		"syslog_port", config.App.SyslogPort,
+		"credentials_key", config.App.CredentialsKey != "",
 	)
 	slog.Debug("images configuration",
 		"dir", config.Images.Directory,
+		"import_timeout", config.Images.ImportTimeout,
+		"import_attempts", config.Images.ImportAttempts,
+		"import_backoff", config.Images.ImportBackoff,
+		"import_workers", config.Images.ImportWorkers,
+		"boot_iso_script", config.Images.BootISOScript,
+		"rpm_mirror", config.Images.RPMMirror,
+		"rpm_repos", config.Images.RPMRepos,
+		"secure_boot_ca", config.Images.SecureBootCA,
+	)
+	slog.Debug("dhcp configuration",
+		"enabled", config.Dhcp.Enabled,
+		"port", config.Dhcp.Port,
+		"pxe_port", config.Dhcp.PXEPort,
+		"server_ip", config.Dhcp.ServerIP,
+		"discovery", config.Dhcp.Discovery,
+	)
+	slog.Debug("jobs configuration",
+		"workers", config.Jobs.Workers,
+		"poll_interval", config.Jobs.PollInterval,
+		"appliance_limit", config.Jobs.ApplianceLimit,
+		"max_attempts", config.Jobs.MaxAttempts,
+		"retry_backoff", config.Jobs.RetryBackoff,
+	)
+	slog.Debug("redfish configuration",
+		"session_idle", config.Redfish.SessionIdle,
+		"keepalive", config.Redfish.Keepalive,
 	)
 	slog.Debug("logging configuration",
 		"level", config.Logging.Level,
@@ -2,11 +2,14 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log/slog"
 
 	"github.com/georgysavva/scany/v2/pgxscan"
 
 	"forester/internal/model"
+	"forester/internal/secret"
 )
 
 func init() {
@@ -19,10 +22,66 @@ func getApplianceDao(ctx context.Context) ApplianceDao {
 	return &applianceDao{}
 }
 
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// encryptCredentials returns encrypted username and password or nil when both are blank.
+func encryptCredentials(username, password string) ([]byte, error) {
+	if username == "" && password == "" {
+		return nil, nil
+	}
+
+	plain, err := json.Marshal(credentials{Username: username, Password: password})
+	if err != nil {
+		return nil, fmt.Errorf("marshal error: %w", err)
+	}
+
+	data, err := secret.Encrypt(plain)
+	if err != nil {
+		return nil, fmt.Errorf("credentials error: %w", err)
+	}
+
+	return data, nil
+}
+
+// decryptCredentials sets username and password of an appliance from its encrypted credentials.
+// Errors are only logged so a misconfigured key does not prevent other operations, appliance
+// operations will fail to authenticate then.
+func decryptCredentials(ctx context.Context, a *model.Appliance) {
+	if len(a.Credentials) == 0 {
+		// not encrypted yet (no key configured)
+		a.Username, a.Password = a.PlainUsername, a.PlainPassword
+		return
+	}
+
+	plain, err := secret.Decrypt(a.Credentials)
+	if err != nil {
+		slog.WarnContext(ctx, "cannot decrypt appliance credentials", "appliance", a.Name, "err", err)
+		return
+	}
+
+	var c credentials
+	err = json.Unmarshal(plain, &c)
+	if err != nil {
+		slog.WarnContext(ctx, "cannot unmarshal appliance credentials", "appliance", a.Name, "err", err)
+		return
+	}
+
+	a.Username, a.Password = c.Username, c.Password
+}
+
 func (dao applianceDao) Create(ctx context.Context, a *model.Appliance) error {
-	query := `INSERT INTO appliances (name, kind, uri) VALUES ($1, $2, $3) RETURNING id`
+	query := `INSERT INTO appliances (name, kind, uri, credentials) VALUES ($1, $2, $3, $4) RETURNING id`
+
+	var err error
+	a.Credentials, err = encryptCredentials(a.Username, a.Password)
+	if err != nil {
+		return err
+	}
 
-	err := Pool.QueryRow(ctx, query, a.Name, a.Kind, a.URI).Scan(&a.ID)
+	err = Pool.QueryRow(ctx, query, a.Name, a.Kind, a.URI, a.Credentials).Scan(&a.ID)
 	if err != nil {
 		return fmt.Errorf("insert error: %w", err)
 	}
@@ -38,6 +97,7 @@ func (dao applianceDao) Find(ctx context.Context, name string) (*model.Appliance
 	if err != nil {
 		return nil, fmt.Errorf("select error: %w", err)
 	}
+	decryptCredentials(ctx, result)
 
 	return result, nil
 }
@@ -50,6 +110,7 @@ func (dao applianceDao) FindByID(ctx context.Context, id int64) (*model.Applianc
 	if err != nil {
 		return nil, fmt.Errorf("select error: %w", err)
 	}
+	decryptCredentials(ctx, result)
 
 	return result, nil
 }
@@ -67,10 +128,54 @@ func (dao applianceDao) List(ctx context.Context, limit, offset int64) ([]*model
 	if err != nil {
 		return nil, fmt.Errorf("select error: %w", err)
 	}
+	for _, a := range result {
+		decryptCredentials(ctx, a)
+	}
 
 	return result, nil
 }
 
+func (dao applianceDao) UpdateCredentials(ctx context.Context, id int64, username, password string) error {
+	query := `UPDATE appliances SET credentials = $2, username = '', password = '' WHERE id = $1`
+
+	data, err := encryptCredentials(username, password)
+	if err != nil {
+		return err
+	}
+
+	tag, err := Pool.Exec(ctx, query, id, data)
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+
+	if tag.RowsAffected() != 1 {
+		return fmt.Errorf("expected 1 row: %w", ErrAffectedMismatch)
+	}
+
+	return nil
+}
+
+func (dao applianceDao) EncryptPlainCredentials(ctx context.Context) (int64, error) {
+	query := `SELECT * FROM appliances WHERE credentials IS NULL AND (username <> '' OR password <> '')`
+
+	var list []*model.Appliance
+	err := pgxscan.Select(ctx, Pool, &list, query)
+	if err != nil {
+		return 0, fmt.Errorf("select error: %w", err)
+	}
+
+	var count int64
+	for _, a := range list {
+		err = dao.UpdateCredentials(ctx, a.ID, a.PlainUsername, a.PlainPassword)
+		if err != nil {
+			return count, fmt.Errorf("cannot encrypt credentials of appliance %s: %w", a.Name, err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
 func (dao applianceDao) Delete(ctx context.Context, id int64) error {
 	query := `DELETE FROM appliances WHERE id = $1`
 
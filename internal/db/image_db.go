@@ -20,9 +20,19 @@ func getImageDao(ctx context.Context) ImageDao {
 }
 
 func (dao imageDao) Create(ctx context.Context, image *model.Image) error {
-	query := `INSERT INTO images (name, kind, iso_sha256, liveimg_sha256) VALUES ($1, $2, $3, $4) RETURNING id`
+	query := `INSERT INTO images (name, kind, iso_sha256, liveimg_sha256, expected_sha256, status,
+		container_ref, bootc_ref, signature_verify, meta, kernel_args)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`
 
-	err := Pool.QueryRow(ctx, query, image.Name, image.Kind, image.IsoSha256, image.LiveimgSha256).Scan(&image.ID)
+	if image.Meta == nil {
+		image.Meta = map[string]string{}
+	}
+	if image.KernelArgs == nil {
+		image.KernelArgs = []string{}
+	}
+
+	err := Pool.QueryRow(ctx, query, image.Name, image.Kind, image.IsoSha256, image.LiveimgSha256, image.ExpectedSha256, image.Status,
+		image.ContainerRef, image.BootcRef, image.SignatureVerify, image.Meta, image.KernelArgs).Scan(&image.ID)
 	if err != nil {
 		return fmt.Errorf("db error: %w", err)
 	}
@@ -54,11 +64,17 @@ func (dao imageDao) Find(ctx context.Context, pattern string) (*model.Image, err
 	return result, nil
 }
 
-func (dao imageDao) List(ctx context.Context, limit, offset int64) ([]*model.Image, error) {
-	query := `SELECT * FROM images ORDER BY id LIMIT $1 OFFSET $2`
+// List returns images containing all the provided metadata key/value pairs, nil or empty map
+// returns all images.
+func (dao imageDao) List(ctx context.Context, limit, offset int64, meta map[string]string) ([]*model.Image, error) {
+	query := `SELECT * FROM images WHERE meta @> $3 ORDER BY id LIMIT $1 OFFSET $2`
+
+	if meta == nil {
+		meta = map[string]string{}
+	}
 
 	var result []*model.Image
-	rows, err := Pool.Query(ctx, query, limit, offset)
+	rows, err := Pool.Query(ctx, query, limit, offset, meta)
 	if err != nil {
 		return nil, fmt.Errorf("select error: %w", err)
 	}
@@ -72,9 +88,13 @@ func (dao imageDao) List(ctx context.Context, limit, offset int64) ([]*model.Ima
 }
 
 func (dao imageDao) Update(ctx context.Context, image *model.Image) error {
-	query := `UPDATE images SET name = $2, kind = $3, iso_sha256 = $4, liveimg_sha256 = $5 WHERE id = $1`
+	query := `UPDATE images SET name = $2, kind = $3, iso_sha256 = $4, liveimg_sha256 = $5, expected_sha256 = $6,
+		status = $7, status_message = $8, progress = $9, arch = $10, local_repo = $11, meta = $12,
+		secure_boot = $13, secure_boot_message = $14 WHERE id = $1`
 
-	tag, err := Pool.Exec(ctx, query, image.ID, image.Name, image.Kind, image.IsoSha256, image.LiveimgSha256)
+	tag, err := Pool.Exec(ctx, query, image.ID, image.Name, image.Kind, image.IsoSha256, image.LiveimgSha256, image.ExpectedSha256,
+		image.Status, image.StatusMessage, image.Progress, image.Arch, image.LocalRepo, image.Meta,
+		image.SecureBoot, image.SecureBootMessage)
 	if err != nil {
 		return fmt.Errorf("update error: %w", err)
 	}
@@ -86,6 +106,71 @@ func (dao imageDao) Update(ctx context.Context, image *model.Image) error {
 	return nil
 }
 
+func (dao imageDao) UpdateStatus(ctx context.Context, id int64, status model.ImageStatus, progress int16, message string) error {
+	query := `UPDATE images SET status = $2, progress = $3, status_message = $4 WHERE id = $1`
+
+	tag, err := Pool.Exec(ctx, query, id, status, progress, message)
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+
+	if tag.RowsAffected() != 1 {
+		return fmt.Errorf("expected 1 row: %w", ErrAffectedMismatch)
+	}
+
+	return nil
+}
+
+// UpdateContainer sets container and bootc references and signature verification of an
+// image, nil values are left unchanged.
+func (dao imageDao) UpdateContainer(ctx context.Context, id int64, containerRef, bootcRef *string, signatureVerify *bool) error {
+	query := `UPDATE images SET container_ref = COALESCE($2, container_ref), bootc_ref = COALESCE($3, bootc_ref),
+		signature_verify = COALESCE($4, signature_verify) WHERE id = $1`
+
+	tag, err := Pool.Exec(ctx, query, id, containerRef, bootcRef, signatureVerify)
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+
+	if tag.RowsAffected() != 1 {
+		return fmt.Errorf("expected 1 row: %w", ErrAffectedMismatch)
+	}
+
+	return nil
+}
+
+func (dao imageDao) UpdateKernelArgs(ctx context.Context, id int64, kernelArgs []string) error {
+	query := `UPDATE images SET kernel_args = $2 WHERE id = $1`
+
+	if kernelArgs == nil {
+		kernelArgs = []string{}
+	}
+
+	tag, err := Pool.Exec(ctx, query, id, kernelArgs)
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+
+	if tag.RowsAffected() != 1 {
+		return fmt.Errorf("expected 1 row: %w", ErrAffectedMismatch)
+	}
+
+	return nil
+}
+
+// FailInterrupted marks images which were being processed as failed, processing does not
+// survive controller restart.
+func (dao imageDao) FailInterrupted(ctx context.Context, message string) (int64, error) {
+	query := `UPDATE images SET status = $1, status_message = $2 WHERE status IN ($3, $4)`
+
+	tag, err := Pool.Exec(ctx, query, model.FailedImageStatus, message, model.ExtractingImageStatus, model.GeneratingImageStatus)
+	if err != nil {
+		return 0, fmt.Errorf("update error: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
 func (dao imageDao) Delete(ctx context.Context, id int64) error {
 	query := `DELETE FROM images WHERE id = $1`
 
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"forester/internal/model"
+)
+
+func TestImageDeleteWithInstallations(t *testing.T) {
+	ctx := initTestDatabase(t)
+
+	iDao := GetImageDao(ctx)
+	image := &model.Image{Name: "test"}
+	require.NoError(t, iDao.Create(ctx, image))
+	other := &model.Image{Name: "other"}
+	require.NoError(t, iDao.Create(ctx, other))
+
+	var systemID int64
+	err := Pool.QueryRow(ctx, `INSERT INTO systems (hwaddrs, facts) VALUES ('{00:00:00:00:00:01}', '{}') RETURNING id`).
+		Scan(&systemID)
+	require.NoError(t, err)
+	for _, state := range []model.InstallState{model.InstallingInstallState, model.FinishedInstallState} {
+		_, err = Pool.Exec(ctx, `INSERT INTO installations (system_id, image_id, state) VALUES ($1, $2, $3)`, systemID, image.ID, state)
+		require.NoError(t, err)
+	}
+
+	instDao := GetInstallationDao(ctx)
+	count, err := instDao.CountByImage(ctx, image.ID)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, count)
+	count, err = instDao.CountByImage(ctx, other.ID)
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	// installations are deleted together with the image
+	require.NoError(t, iDao.Delete(ctx, image.ID))
+	count, err = instDao.CountByImage(ctx, image.ID)
+	require.NoError(t, err)
+	require.Zero(t, count)
+	_, err = iDao.FindByID(ctx, image.ID)
+	require.ErrorIs(t, err, ErrNoRows)
+
+	require.ErrorIs(t, iDao.Delete(ctx, image.ID), ErrAffectedMismatch)
+}
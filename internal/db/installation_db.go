@@ -71,6 +71,60 @@ func (dao instDao) FindAnyByState(ctx context.Context, state model.InstallState)
 	return result, nil
 }
 
+func (dao instDao) ListBySystem(ctx context.Context, systemId int64, limit, offset int64) ([]*model.Installation, error) {
+	query := `SELECT * FROM installations WHERE system_id = $1 ORDER BY id DESC LIMIT $2 OFFSET $3`
+
+	var result []*model.Installation
+	rows, err := Pool.Query(ctx, query, systemId, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("select error: %w", err)
+	}
+
+	err = pgxscan.ScanAll(&result, rows)
+	if err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	return result, nil
+}
+
+// ErrInvalidTransition is returned when an installation cannot be moved into a new state,
+// typically because it is already in that state (or further), or it is no longer valid.
+var ErrInvalidTransition = errors.New("invalid installation state transition")
+
+// CountByImage returns number of all installations of an image including finished and
+// expired ones, they are deleted together with the image.
+func (dao instDao) CountByImage(ctx context.Context, imageID int64) (int64, error) {
+	query := `SELECT count(*) FROM installations WHERE image_id = $1`
+
+	var count int64
+	err := Pool.QueryRow(ctx, query, imageID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("select error: %w", err)
+	}
+
+	return count, nil
+}
+
+// Transition atomically moves an installation forward into the given state. The update is
+// guarded so it only succeeds when the current state is lower than the new state, concurrent
+// callers cannot move an installation backwards or apply the same transition twice.
+func (dao instDao) Transition(ctx context.Context, id int64, to model.InstallState) error {
+	query := `UPDATE installations SET state = $2 WHERE id = $1 AND state < $2 AND valid_until > current_timestamp`
+
+	tag, err := Pool.Exec(ctx, query, id, to)
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+
+	if tag.RowsAffected() != 1 {
+		return fmt.Errorf("%w: installation %d to %s", ErrInvalidTransition, id, to.String())
+	}
+
+	invalidateInstallationCache(id)
+	return nil
+}
+
 var ErrUnknownSystem = errors.New("unknown system")
 
 var NullMAC net.HardwareAddr
@@ -87,6 +141,26 @@ func init() {
 	installationCache = expirable.NewLRU[string, installationCacheEntry](512, nil, 30*time.Second)
 }
 
+// invalidateInstallationCache removes all cache entries of the installation, so
+// its new state is immediately visible to FindInstallationForMAC.
+func invalidateInstallationCache(id int64) {
+	for _, key := range installationCache.Keys() {
+		if value, ok := installationCache.Peek(key); ok && value.inst.ID == id {
+			installationCache.Remove(key)
+		}
+	}
+}
+
+// invalidateSystemCache removes all cache entries of the system, so its new
+// configuration is immediately visible to FindInstallationForMAC.
+func invalidateSystemCache(id int64) {
+	for _, key := range installationCache.Keys() {
+		if value, ok := installationCache.Peek(key); ok && value.sys.ID == id {
+			installationCache.Remove(key)
+		}
+	}
+}
+
 func (dao instDao) FindInstallationForMAC(ctx context.Context, givenMAC net.HardwareAddr) (*model.Installation, *model.System, error) {
 	// lookup in cache
 	if value, ok := installationCache.Get(givenMAC.String()); ok {
@@ -118,7 +192,8 @@ func (dao instDao) FindInstallationForMAC(ctx context.Context, givenMAC net.Hard
 		}
 
 		if r == 0 {
-			state = model.BootingInstallState
+			// finished installations must not boot into installer again
+			state = model.InstallingInstallState
 		} else {
 			state = model.AnyInstallState
 		}
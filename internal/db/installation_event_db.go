@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+
+	"forester/internal/logging"
+	"forester/internal/model"
+)
+
+func init() {
+	GetInstallationEventDao = getInstallationEventDao
+}
+
+type instEventDao struct{}
+
+func getInstallationEventDao(_ context.Context) InstallationEventDao {
+	return &instEventDao{}
+}
+
+func (dao instEventDao) Create(ctx context.Context, e *model.InstallationEvent) error {
+	query := `INSERT INTO installation_events (installation_id, kind, trace_id, payload) VALUES ($1, $2, $3, $4) RETURNING id, created_at`
+
+	err := Pool.QueryRow(ctx, query, e.InstallationID, e.Kind, e.TraceID, e.Payload).Scan(&e.ID, &e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert error: %w", err)
+	}
+
+	return nil
+}
+
+func (dao instEventDao) ListByInstallation(ctx context.Context, instID int64) ([]*model.InstallationEvent, error) {
+	query := `SELECT * FROM installation_events WHERE installation_id = $1 ORDER BY id`
+
+	var result []*model.InstallationEvent
+	rows, err := Pool.Query(ctx, query, instID)
+	if err != nil {
+		return nil, fmt.Errorf("select error: %w", err)
+	}
+
+	err = pgxscan.ScanAll(&result, rows)
+	if err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	return result, nil
+}
+
+func (dao instEventDao) ListBySystem(ctx context.Context, systemID int64, limit, offset int64) ([]*model.InstallationEvent, error) {
+	query := `SELECT installation_events.* FROM installation_events, installations
+	WHERE installation_events.installation_id = installations.id AND installations.system_id = $1
+	ORDER BY installation_events.id DESC LIMIT $2 OFFSET $3`
+
+	var result []*model.InstallationEvent
+	rows, err := Pool.Query(ctx, query, systemID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("select error: %w", err)
+	}
+
+	err = pgxscan.ScanAll(&result, rows)
+	if err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	return result, nil
+}
+
+// RecordEvent stores an installation milestone. Events are informative, errors are
+// only logged so they never interrupt the provisioning workflow.
+func RecordEvent(ctx context.Context, instID int64, kind model.EventKind, payload string) {
+	e := model.InstallationEvent{
+		InstallationID: instID,
+		Kind:           kind,
+		TraceID:        logging.TraceId(ctx),
+		Payload:        payload,
+	}
+
+	err := GetInstallationEventDao(ctx).Create(ctx, &e)
+	if err != nil {
+		slog.WarnContext(ctx, "cannot record installation event", "inst_id", instID, "kind", kind.String(), "err", err)
+	}
+}
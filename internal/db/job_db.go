@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	pgx "github.com/jackc/pgx/v5"
+
+	"forester/internal/model"
+)
+
+func init() {
+	GetJobDao = getJobDao
+}
+
+type jobDao struct{}
+
+func getJobDao(_ context.Context) JobDao {
+	return &jobDao{}
+}
+
+func (dao jobDao) Enqueue(ctx context.Context, j *model.Job) error {
+	query := `INSERT INTO jobs (kind, system_id, appliance_id, image_id, url, installation_id, trace_id, max_attempts, run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, state, created_at, updated_at`
+
+	err := Pool.QueryRow(ctx, query, j.Kind, j.SystemID, j.ApplianceID, j.ImageID, j.URL, j.InstallationID, j.TraceID, j.MaxAttempts, j.RunAt).
+		Scan(&j.ID, &j.State, &j.CreatedAt, &j.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("insert error: %w", err)
+	}
+
+	return nil
+}
+
+// acquireLockKey is the advisory lock key serializing job acquisition
+const acquireLockKey = 0x6a6f6273
+
+// Acquire atomically picks the next pending job of the given kinds which is due and marks
+// it as running. Jobs of appliances which already run applianceLimit jobs are not picked. Acquisition is
+// serialized by a transaction-level advisory lock, otherwise concurrent workers would count
+// running jobs of an appliance before each other's updates are committed. Returns ErrNoRows
+// when there is nothing to do.
+func (dao jobDao) Acquire(ctx context.Context, kinds []model.JobKind, applianceLimit int) (*model.Job, error) {
+	query := `UPDATE jobs SET state = $1, attempts = attempts + 1, updated_at = current_timestamp
+		WHERE id = (
+			SELECT j.id FROM jobs j
+			WHERE j.state = $2 AND j.kind = ANY($4) AND j.run_at <= current_timestamp AND (j.appliance_id IS NULL OR
+				(SELECT count(*) FROM jobs r WHERE r.state = $1 AND r.appliance_id = j.appliance_id) < $3)
+			ORDER BY j.run_at, j.id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		) RETURNING *`
+
+	kindValues := make([]int16, len(kinds))
+	for i, k := range kinds {
+		kindValues[i] = int16(k)
+	}
+
+	result := &model.Job{}
+	err := pgx.BeginFunc(ctx, Pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, acquireLockKey)
+		if err != nil {
+			return err
+		}
+
+		return pgxscan.Get(ctx, tx, result, query, model.RunningJobState, model.PendingJobState, applianceLimit, kindValues)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+
+	return result, nil
+}
+
+func (dao jobDao) Succeed(ctx context.Context, id int64) error {
+	query := `UPDATE jobs SET state = $2, last_error = '', updated_at = current_timestamp WHERE id = $1`
+
+	tag, err := Pool.Exec(ctx, query, id, model.SucceededJobState)
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+
+	if tag.RowsAffected() != 1 {
+		return fmt.Errorf("expected 1 row: %w", ErrAffectedMismatch)
+	}
+
+	return nil
+}
+
+func (dao jobDao) Retry(ctx context.Context, id int64, message string, runAt time.Time) error {
+	query := `UPDATE jobs SET state = $2, last_error = $3, run_at = $4, updated_at = current_timestamp WHERE id = $1`
+
+	tag, err := Pool.Exec(ctx, query, id, model.PendingJobState, message, runAt)
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+
+	if tag.RowsAffected() != 1 {
+		return fmt.Errorf("expected 1 row: %w", ErrAffectedMismatch)
+	}
+
+	return nil
+}
+
+func (dao jobDao) Fail(ctx context.Context, id int64, message string) error {
+	query := `UPDATE jobs SET state = $2, last_error = $3, updated_at = current_timestamp WHERE id = $1`
+
+	tag, err := Pool.Exec(ctx, query, id, model.FailedJobState, message)
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+
+	if tag.RowsAffected() != 1 {
+		return fmt.Errorf("expected 1 row: %w", ErrAffectedMismatch)
+	}
+
+	return nil
+}
+
+// Requeue moves all running jobs back to pending state, it must be only called before
+// workers are started to pick jobs which were interrupted by controller shutdown.
+func (dao jobDao) Requeue(ctx context.Context) (int64, error) {
+	query := `UPDATE jobs SET state = $2, updated_at = current_timestamp WHERE state = $1`
+
+	tag, err := Pool.Exec(ctx, query, model.RunningJobState, model.PendingJobState)
+	if err != nil {
+		return 0, fmt.Errorf("update error: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func (dao jobDao) FindByID(ctx context.Context, id int64) (*model.Job, error) {
+	query := `SELECT * FROM jobs WHERE id = $1 LIMIT 1`
+
+	result := &model.Job{}
+	err := pgxscan.Get(ctx, Pool, result, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("select error: %w", err)
+	}
+
+	return result, nil
+}
+
+func (dao jobDao) List(ctx context.Context, limit, offset int64) ([]*model.Job, error) {
+	query := `SELECT * FROM jobs ORDER BY id DESC LIMIT $1 OFFSET $2`
+
+	var result []*model.Job
+	rows, err := Pool.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("select error: %w", err)
+	}
+
+	err = pgxscan.ScanAll(&result, rows)
+	if err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	return result, nil
+}
+
+func (dao jobDao) ListBySystem(ctx context.Context, systemID int64, limit, offset int64) ([]*model.Job, error) {
+	query := `SELECT * FROM jobs WHERE system_id = $1 ORDER BY id DESC LIMIT $2 OFFSET $3`
+
+	var result []*model.Job
+	rows, err := Pool.Query(ctx, query, systemID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("select error: %w", err)
+	}
+
+	err = pgxscan.ScanAll(&result, rows)
+	if err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"forester/internal/config"
+	"forester/internal/model"
+)
+
+const testSchema = "forester_test"
+
+// initTestDatabase migrates an empty schema of the configured database, the test is skipped
+// when the database is not available
+func initTestDatabase(t *testing.T) context.Context {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("database tests are skipped in short mode")
+	}
+
+	ctx := context.Background()
+	require.NoError(t, config.Initialize())
+	err := Initialize(ctx, testSchema)
+	if err != nil {
+		t.Skipf("database not available: %s", err)
+	}
+	t.Cleanup(Close)
+
+	_, err = Pool.Exec(ctx, "DROP SCHEMA IF EXISTS "+testSchema+" CASCADE")
+	require.NoError(t, err)
+	_, err = Pool.Exec(ctx, "CREATE SCHEMA "+testSchema)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = Pool.Exec(ctx, "DROP SCHEMA IF EXISTS "+testSchema+" CASCADE")
+	})
+	require.NoError(t, Migrate(ctx, testSchema))
+
+	return ctx
+}
+
+func TestJobAcquireConcurrent(t *testing.T) {
+	ctx := initTestDatabase(t)
+
+	var applianceID, systemID int64
+	err := Pool.QueryRow(ctx, `INSERT INTO appliances (name, kind, uri) VALUES ('test', 1, 'qemu:///system') RETURNING id`).
+		Scan(&applianceID)
+	require.NoError(t, err)
+	err = Pool.QueryRow(ctx, `INSERT INTO systems (appliance_id, hwaddrs, facts) VALUES ($1, '{00:00:00:00:00:01}', '{}') RETURNING id`, applianceID).
+		Scan(&systemID)
+	require.NoError(t, err)
+
+	dao := GetJobDao(ctx)
+	for i := 0; i < 20; i++ {
+		job := model.Job{
+			Kind:        model.PowerOnJobKind,
+			SystemID:    &systemID,
+			ApplianceID: &applianceID,
+			MaxAttempts: 1,
+			RunAt:       time.Now().Add(-time.Minute),
+		}
+		require.NoError(t, dao.Enqueue(ctx, &job))
+	}
+
+	const limit = 2
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var acquired []int64
+	var errs []error
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job, err := dao.Acquire(ctx, []model.JobKind{model.PowerOnJobKind}, limit)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				acquired = append(acquired, job.ID)
+			} else if !errors.Is(err, ErrNoRows) {
+				errs = append(errs, err)
+			}
+		}()
+	}
+	wg.Wait()
+	require.Empty(t, errs)
+	require.Len(t, acquired, limit)
+
+	// a finished job makes room for another one
+	require.NoError(t, dao.Succeed(ctx, acquired[0]))
+	_, err = dao.Acquire(ctx, []model.JobKind{model.PowerOnJobKind}, limit)
+	require.NoError(t, err)
+	_, err = dao.Acquire(ctx, []model.JobKind{model.PowerOnJobKind}, limit)
+	require.ErrorIs(t, err, ErrNoRows)
+}
@@ -0,0 +1,11 @@
+CREATE TABLE installation_events
+(
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  installation_id BIGINT NOT NULL REFERENCES installations(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  kind SMALLINT NOT NULL CHECK (kind != 0),
+  created_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  trace_id TEXT NOT NULL DEFAULT '',
+  payload TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_installation_events_installation_id ON installation_events(installation_id);
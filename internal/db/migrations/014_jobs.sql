@@ -0,0 +1,18 @@
+CREATE TABLE jobs
+(
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  kind SMALLINT NOT NULL CHECK (kind != 0),
+  state SMALLINT NOT NULL CHECK (state != 0) DEFAULT 1,
+  system_id BIGINT NOT NULL REFERENCES systems(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  appliance_id BIGINT REFERENCES appliances(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  trace_id TEXT NOT NULL DEFAULT '',
+  attempts SMALLINT NOT NULL DEFAULT 0,
+  max_attempts SMALLINT NOT NULL CHECK (max_attempts > 0) DEFAULT 1,
+  run_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  created_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  updated_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  last_error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_jobs_pending ON jobs(run_at) WHERE state = 1;
+CREATE INDEX idx_jobs_system_id ON jobs(system_id);
@@ -0,0 +1,3 @@
+ALTER TABLE appliances
+ADD COLUMN username TEXT NOT NULL DEFAULT '',
+ADD COLUMN password TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+-- credentials are now encrypted by the controller, plaintext values
+-- must be set again via "forester-cli appliance update-credentials"
+ALTER TABLE appliances
+DROP COLUMN username,
+DROP COLUMN password,
+ADD COLUMN credentials BYTEA;
@@ -0,0 +1,2 @@
+ALTER TABLE images
+  ADD COLUMN expected_sha256 TEXT NOT NULL DEFAULT '';
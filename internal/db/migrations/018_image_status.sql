@@ -0,0 +1,7 @@
+ALTER TABLE images
+  ADD COLUMN status SMALLINT NOT NULL CHECK (status != 0) DEFAULT 1,
+  ADD COLUMN status_message TEXT NOT NULL DEFAULT '',
+  ADD COLUMN progress SMALLINT NOT NULL DEFAULT 0;
+
+-- images uploaded before status tracking are considered ready
+UPDATE images SET status = 4, progress = 100 WHERE iso_sha256 != '';
@@ -0,0 +1,5 @@
+ALTER TABLE images
+  ADD COLUMN arch TEXT NOT NULL DEFAULT '';
+
+-- only x86_64 images were supported before architecture detection
+UPDATE images SET arch = 'x86_64' WHERE iso_sha256 != '';
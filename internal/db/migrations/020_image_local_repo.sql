@@ -0,0 +1,2 @@
+ALTER TABLE images
+  ADD COLUMN local_repo BOOLEAN NOT NULL DEFAULT false;
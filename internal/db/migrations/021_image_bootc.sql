@@ -0,0 +1,4 @@
+ALTER TABLE images
+  ADD COLUMN container_ref TEXT NOT NULL DEFAULT '',
+  ADD COLUMN bootc_ref TEXT NOT NULL DEFAULT '',
+  ADD COLUMN signature_verify BOOLEAN NOT NULL DEFAULT false;
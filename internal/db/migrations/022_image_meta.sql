@@ -0,0 +1,2 @@
+ALTER TABLE images
+  ADD COLUMN meta JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,2 @@
+ALTER TABLE systems
+  ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT current_timestamp;
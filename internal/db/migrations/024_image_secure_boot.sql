@@ -0,0 +1,3 @@
+ALTER TABLE images
+  ADD COLUMN secure_boot SMALLINT NOT NULL DEFAULT 0,
+  ADD COLUMN secure_boot_message TEXT NOT NULL DEFAULT '';
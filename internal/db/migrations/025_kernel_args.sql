@@ -0,0 +1,8 @@
+ALTER TABLE systems
+  ADD COLUMN kernel_args TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE images
+  ADD COLUMN kernel_args TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE installations
+  ADD COLUMN kernel_args TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,6 @@
+-- image import jobs have no system
+ALTER TABLE jobs
+  ALTER COLUMN system_id DROP NOT NULL,
+  ADD COLUMN image_id BIGINT REFERENCES images(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  ADD COLUMN url TEXT NOT NULL DEFAULT '',
+  ADD CONSTRAINT jobs_system_or_image CHECK (system_id IS NOT NULL OR image_id IS NOT NULL);
@@ -0,0 +1,27 @@
+-- MAC addresses removed from systems (or deleted with systems) for delta DHCP configuration
+CREATE TABLE removed_hwaddrs
+(
+  hwaddr MACADDR NOT NULL,
+  removed_at TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX idx_removed_hwaddrs_removed_at ON removed_hwaddrs(removed_at);
+
+CREATE OR REPLACE FUNCTION record_removed_hwaddrs()
+  RETURNS TRIGGER AS
+$$
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    INSERT INTO removed_hwaddrs (hwaddr) SELECT DISTINCT unnest(old.hwaddrs);
+  ELSE
+    INSERT INTO removed_hwaddrs (hwaddr) SELECT unnest(old.hwaddrs) EXCEPT SELECT unnest(new.hwaddrs);
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE 'plpgsql';
+
+CREATE TRIGGER record_removed_hwaddrs
+  AFTER UPDATE OF hwaddrs OR DELETE
+  ON systems
+  FOR EACH ROW
+EXECUTE PROCEDURE record_removed_hwaddrs();
@@ -0,0 +1,6 @@
+-- delta DHCP configuration compares times across session time zones
+ALTER TABLE systems
+  ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING updated_at AT TIME ZONE current_setting('TimeZone');
+
+ALTER TABLE removed_hwaddrs
+  ALTER COLUMN removed_at TYPE TIMESTAMPTZ USING removed_at AT TIME ZONE current_setting('TimeZone');
@@ -0,0 +1,6 @@
+-- plaintext username and password are kept until the controller encrypts them into
+-- credentials on start, databases which dropped them get empty columns back (the columns
+-- will be dropped by a later migration)
+ALTER TABLE appliances
+ADD COLUMN IF NOT EXISTS username TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS password TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- boot jobs of an installation record their events for it
+ALTER TABLE jobs
+  ADD COLUMN installation_id BIGINT REFERENCES installations(id) ON DELETE SET NULL ON UPDATE CASCADE;
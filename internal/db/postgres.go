@@ -29,8 +29,12 @@ type ImageDao interface {
 	Create(ctx context.Context, image *model.Image) error
 	FindByID(ctx context.Context, id int64) (*model.Image, error)
 	Find(ctx context.Context, pattern string) (*model.Image, error)
-	List(ctx context.Context, limit, offset int64) ([]*model.Image, error)
+	List(ctx context.Context, limit, offset int64, meta map[string]string) ([]*model.Image, error)
 	Update(ctx context.Context, image *model.Image) error
+	UpdateStatus(ctx context.Context, id int64, status model.ImageStatus, progress int16, message string) error
+	UpdateContainer(ctx context.Context, id int64, containerRef, bootcRef *string, signatureVerify *bool) error
+	UpdateKernelArgs(ctx context.Context, id int64, kernelArgs []string) error
+	FailInterrupted(ctx context.Context, message string) (int64, error)
 	Delete(ctx context.Context, id int64) error
 }
 
@@ -40,8 +44,20 @@ type SystemDao interface {
 	Register(ctx context.Context, sys *model.System) error
 	RegisterExisting(ctx context.Context, id int64, sys *model.System) error
 	List(ctx context.Context, limit, offset int64) ([]*model.System, error)
+	ListUpdatedSince(ctx context.Context, since time.Time) ([]*model.System, error)
+	// ListRemovedSince returns MAC addresses removed from systems since the given time which
+	// are not used by any system anymore.
+	ListRemovedSince(ctx context.Context, since time.Time) ([]net.HardwareAddr, error)
+	// DeleteRemovedBefore prunes MAC addresses removed before the given time and returns
+	// number of deleted records.
+	DeleteRemovedBefore(ctx context.Context, before time.Time) (int64, error)
+	// Now returns current database time, use it as the "since" cursor so it is not affected
+	// by clock skew of the controller.
+	Now(ctx context.Context) (time.Time, error)
 	Rename(ctx context.Context, systemId int64, newName string) error
-	Deploy(ctx context.Context, systemId, imageId int64, snippets []int64, snippetText, ksOverride, comment string, validUntil time.Time) error
+	UpdateKernelArgs(ctx context.Context, systemId int64, kernelArgs []string) error
+	UpdateNetwork(ctx context.Context, systemId int64, network *model.Network) error
+	Deploy(ctx context.Context, systemId, imageId int64, snippets []int64, snippetText, ksOverride, comment string, kernelArgs []string, validUntil time.Time) (int64, error)
 	Find(ctx context.Context, pattern string) (*model.System, error)
 	FindByID(ctx context.Context, id int64) (*model.System, error)
 	FindByMac(ctx context.Context, mac net.HardwareAddr) (*model.System, error)
@@ -57,6 +73,17 @@ type InstallationDao interface {
 	FindValidByState(ctx context.Context, systemId int64, state model.InstallState) ([]*model.Installation, error)
 	FindAnyByState(ctx context.Context, state model.InstallState) ([]*model.Installation, error)
 	FindInstallationForMAC(ctx context.Context, givenMAC net.HardwareAddr) (*model.Installation, *model.System, error)
+	ListBySystem(ctx context.Context, systemId int64, limit, offset int64) ([]*model.Installation, error)
+	CountByImage(ctx context.Context, imageID int64) (int64, error)
+	Transition(ctx context.Context, id int64, to model.InstallState) error
+}
+
+var GetInstallationEventDao func(ctx context.Context) InstallationEventDao
+
+type InstallationEventDao interface {
+	Create(ctx context.Context, e *model.InstallationEvent) error
+	ListByInstallation(ctx context.Context, instID int64) ([]*model.InstallationEvent, error)
+	ListBySystem(ctx context.Context, systemID int64, limit, offset int64) ([]*model.InstallationEvent, error)
 }
 
 var GetApplianceDao func(ctx context.Context) ApplianceDao
@@ -66,6 +93,11 @@ type ApplianceDao interface {
 	Find(ctx context.Context, name string) (*model.Appliance, error)
 	FindByID(ctx context.Context, id int64) (*model.Appliance, error)
 	List(ctx context.Context, limit, offset int64) ([]*model.Appliance, error)
+	UpdateCredentials(ctx context.Context, id int64, username, password string) error
+
+	// EncryptPlainCredentials encrypts plaintext credentials stored by older versions and
+	// returns number of updated appliances.
+	EncryptPlainCredentials(ctx context.Context) (int64, error)
 	Delete(ctx context.Context, id int64) error
 }
 
@@ -80,3 +112,17 @@ type SnippetDao interface {
 	EditByName(ctx context.Context, name, body string) error
 	DeleteByName(ctx context.Context, name string) error
 }
+
+var GetJobDao func(ctx context.Context) JobDao
+
+type JobDao interface {
+	Enqueue(ctx context.Context, j *model.Job) error
+	Acquire(ctx context.Context, kinds []model.JobKind, applianceLimit int) (*model.Job, error)
+	Succeed(ctx context.Context, id int64) error
+	Retry(ctx context.Context, id int64, message string, runAt time.Time) error
+	Fail(ctx context.Context, id int64, message string) error
+	Requeue(ctx context.Context) (int64, error)
+	FindByID(ctx context.Context, id int64) (*model.Job, error)
+	List(ctx context.Context, limit, offset int64) ([]*model.Job, error)
+	ListBySystem(ctx context.Context, systemID int64, limit, offset int64) ([]*model.Job, error)
+}
@@ -45,7 +45,7 @@ func (dao systemDao) Register(ctx context.Context, sys *model.System) error {
 }
 
 func (dao systemDao) RegisterExisting(ctx context.Context, id int64, sys *model.System) error {
-	query := `UPDATE systems SET hwaddrs = $2, facts = $3 WHERE id = $1 RETURNING id`
+	query := `UPDATE systems SET hwaddrs = $2, facts = $3, updated_at = current_timestamp WHERE id = $1 RETURNING id`
 
 	err := Pool.QueryRow(ctx, query, id, sys.HwAddrs, sys.Facts).Scan(&sys.ID)
 	if err != nil {
@@ -72,13 +72,73 @@ func (dao systemDao) List(ctx context.Context, limit, offset int64) ([]*model.Sy
 	return result, nil
 }
 
-func (dao systemDao) Deploy(ctx context.Context, systemId, imageId int64, snippets []int64, snippetText, ksOverride, comment string, validUntil time.Time) error {
+func (dao systemDao) ListUpdatedSince(ctx context.Context, since time.Time) ([]*model.System, error) {
+	query := `SELECT * FROM systems WHERE updated_at >= $1 ORDER BY id`
+
+	var result []*model.System
+	rows, err := Pool.Query(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("select error: %w", err)
+	}
+
+	err = pgxscan.ScanAll(&result, rows)
+	if err != nil {
+		return nil, fmt.Errorf("select error: %w", err)
+	}
+
+	return result, nil
+}
+
+func (dao systemDao) ListRemovedSince(ctx context.Context, since time.Time) ([]net.HardwareAddr, error) {
+	query := `SELECT DISTINCT r.hwaddr FROM removed_hwaddrs r
+		WHERE r.removed_at >= $1 AND NOT EXISTS (SELECT 1 FROM systems s WHERE r.hwaddr = ANY(s.hwaddrs))
+		ORDER BY r.hwaddr`
+
+	rows, err := Pool.Query(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("select error: %w", err)
+	}
+
+	result, err := pgx.CollectRows(rows, pgx.RowTo[net.HardwareAddr])
+	if err != nil {
+		return nil, fmt.Errorf("select error: %w", err)
+	}
+
+	return result, nil
+}
+
+func (dao systemDao) DeleteRemovedBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM removed_hwaddrs WHERE removed_at < $1`
+
+	tag, err := Pool.Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("delete error: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func (dao systemDao) Now(ctx context.Context) (time.Time, error) {
+	var result time.Time
+	err := Pool.QueryRow(ctx, `SELECT clock_timestamp()`).Scan(&result)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("select error: %w", err)
+	}
+
+	return result, nil
+}
+
+func (dao systemDao) Deploy(ctx context.Context, systemId, imageId int64, snippets []int64, snippetText, ksOverride, comment string, kernelArgs []string, validUntil time.Time) (int64, error) {
+	if kernelArgs == nil {
+		kernelArgs = []string{}
+	}
+
+	var instID int64
 	txErr := WithTransaction(ctx, func(tx pgx.Tx) error {
-		insertQuery := `INSERT INTO installations (system_id, image_id, snippet_text, kickstart_override, comment, valid_until) VALUES
-			($1, $2, $3, $4, $5, $6) RETURNING id`
-		var instID int64
+		insertQuery := `INSERT INTO installations (system_id, image_id, snippet_text, kickstart_override, comment, kernel_args, valid_until) VALUES
+			($1, $2, $3, $4, $5, $6, $7) RETURNING id`
 
-		err := Pool.QueryRow(ctx, insertQuery, systemId, imageId, snippetText, ksOverride, comment, validUntil).Scan(&instID)
+		err := Pool.QueryRow(ctx, insertQuery, systemId, imageId, snippetText, ksOverride, comment, kernelArgs, validUntil).Scan(&instID)
 		if err != nil {
 			return fmt.Errorf("installation insert error: %w", err)
 		}
@@ -112,12 +172,48 @@ func (dao systemDao) Deploy(ctx context.Context, systemId, imageId int64, snippe
 		return nil
 	})
 
-	return txErr
+	return instID, txErr
+}
+
+func (dao systemDao) UpdateKernelArgs(ctx context.Context, systemId int64, kernelArgs []string) error {
+	query := `UPDATE systems SET kernel_args = $2 WHERE id = $1`
+
+	if kernelArgs == nil {
+		kernelArgs = []string{}
+	}
+
+	tag, err := Pool.Exec(ctx, query, systemId, kernelArgs)
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+
+	if tag.RowsAffected() != 1 {
+		return fmt.Errorf("expected 1 row: %w", ErrAffectedMismatch)
+	}
+
+	invalidateSystemCache(systemId)
+	return nil
+}
+
+func (dao systemDao) UpdateNetwork(ctx context.Context, systemId int64, network *model.Network) error {
+	query := `UPDATE systems SET network = $2 WHERE id = $1`
+
+	tag, err := Pool.Exec(ctx, query, systemId, network)
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+
+	if tag.RowsAffected() != 1 {
+		return fmt.Errorf("expected 1 row: %w", ErrAffectedMismatch)
+	}
+
+	invalidateSystemCache(systemId)
+	return nil
 }
 
 func (dao systemDao) Rename(ctx context.Context, systemId int64, newName string) error {
 	query := `UPDATE systems SET
-		name = $2
+		name = $2, updated_at = current_timestamp
 		WHERE id = $1`
 
 	tag, err := Pool.Exec(ctx, query, systemId, newName)
@@ -146,15 +242,20 @@ func (dao systemDao) FindRelated(ctx context.Context, pattern string) (*model.Sy
 		s.hwaddrs AS "s.hwaddrs",
 		s.facts AS "s.facts",
 		s.comment AS "s.comment",
+		s.kernel_args AS "s.kernel_args",
+		s.network AS "s.network",
+		COALESCE(a.id, 0) AS "a.id",
 		COALESCE(a.name, '') AS "a.name",
 		COALESCE(a.kind, 0) AS "a.kind",
-		COALESCE(a.uri, '') AS "a.uri"
+		COALESCE(a.uri, '') AS "a.uri",
+		a.credentials AS "a.credentials"
 		FROM systems AS s LEFT JOIN appliances AS a ON a.id = s.appliance_id WHERE s.name ILIKE $1 LIMIT 1`
 
 	err := pgxscan.Get(ctx, Pool, result, query, name)
 	if err != nil {
 		return nil, fmt.Errorf("select error: %w", err)
 	}
+	decryptCredentials(ctx, &result.Appliance)
 
 	return result, nil
 }
@@ -188,14 +289,19 @@ func (dao systemDao) FindByMacRelated(ctx context.Context, mac net.HardwareAddr)
 		s.hwaddrs AS "s.hwaddrs",
 		s.facts AS "s.facts",
 		s.comment AS "s.comment",
+		s.kernel_args AS "s.kernel_args",
+		s.network AS "s.network",
+		COALESCE(a.id, 0) AS "a.id",
 		COALESCE(a.name, '') AS "a.name",
 		COALESCE(a.kind, 0) AS "a.kind",
-		COALESCE(a.uri, '') AS "a.uri"
+		COALESCE(a.uri, '') AS "a.uri",
+		a.credentials AS "a.credentials"
 		FROM systems AS s LEFT JOIN appliances AS a ON a.id = s.appliance_id WHERE $1 = ANY(s.hwaddrs) LIMIT 1`
 	err := pgxscan.Get(ctx, Pool, result, query, mac)
 	if err != nil {
 		return nil, fmt.Errorf("select error: %w", err)
 	}
+	decryptCredentials(ctx, &result.Appliance)
 
 	return result, nil
 }
@@ -221,14 +327,19 @@ func (dao systemDao) FindByIDRelated(ctx context.Context, id int64) (*model.Syst
 		s.hwaddrs AS "s.hwaddrs",
 		s.facts AS "s.facts",
 		s.comment AS "s.comment",
+		s.kernel_args AS "s.kernel_args",
+		s.network AS "s.network",
+		COALESCE(a.id, 0) AS "a.id",
 		COALESCE(a.name, '') AS "a.name",
 		COALESCE(a.kind, 0) AS "a.kind",
-		COALESCE(a.uri, '') AS "a.uri"
+		COALESCE(a.uri, '') AS "a.uri",
+		a.credentials AS "a.credentials"
 		FROM systems AS s LEFT JOIN appliances AS a ON a.id = s.appliance_id WHERE s.id = $1 LIMIT 1`
 	err := pgxscan.Get(ctx, Pool, result, query, id)
 	if err != nil {
 		return nil, fmt.Errorf("select error: %w", err)
 	}
+	decryptCredentials(ctx, &result.Appliance)
 
 	return result, nil
 }
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"forester/internal/model"
+)
+
+func TestListRemovedSince(t *testing.T) {
+	ctx := initTestDatabase(t)
+	since := time.Now().UTC().Add(-time.Minute)
+
+	var first, second int64
+	err := Pool.QueryRow(ctx, `INSERT INTO systems (hwaddrs, facts) VALUES ('{00:00:00:00:00:01,00:00:00:00:00:02}', '{}') RETURNING id`).
+		Scan(&first)
+	require.NoError(t, err)
+	err = Pool.QueryRow(ctx, `INSERT INTO systems (hwaddrs, facts) VALUES ('{00:00:00:00:00:03}', '{}') RETURNING id`).
+		Scan(&second)
+	require.NoError(t, err)
+
+	dao := GetSystemDao(ctx)
+	removed, err := dao.ListRemovedSince(ctx, since)
+	require.NoError(t, err)
+	require.Empty(t, removed)
+
+	// MAC moved to another system is not removed
+	_, err = Pool.Exec(ctx, `UPDATE systems SET hwaddrs = '{00:00:00:00:00:01}' WHERE id = $1`, first)
+	require.NoError(t, err)
+	_, err = Pool.Exec(ctx, `UPDATE systems SET hwaddrs = '{00:00:00:00:00:03,00:00:00:00:00:02}' WHERE id = $1`, second)
+	require.NoError(t, err)
+	removed, err = dao.ListRemovedSince(ctx, since)
+	require.NoError(t, err)
+	require.Empty(t, removed)
+
+	_, err = Pool.Exec(ctx, `DELETE FROM systems WHERE id = $1`, second)
+	require.NoError(t, err)
+	removed, err = dao.ListRemovedSince(ctx, since)
+	require.NoError(t, err)
+	require.Equal(t, []net.HardwareAddr{{0, 0, 0, 0, 0, 2}, {0, 0, 0, 0, 0, 3}}, removed)
+
+	now, err := dao.Now(ctx)
+	require.NoError(t, err)
+	removed, err = dao.ListRemovedSince(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Empty(t, removed)
+
+	n, err := dao.DeleteRemovedBefore(ctx, since)
+	require.NoError(t, err)
+	require.Zero(t, n)
+	n, err = dao.DeleteRemovedBefore(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.NotZero(t, n)
+	removed, err = dao.ListRemovedSince(ctx, since)
+	require.NoError(t, err)
+	require.Empty(t, removed)
+}
+
+func TestInvalidateSystemCache(t *testing.T) {
+	installationCache.Add("00:00:00:00:00:01", installationCacheEntry{inst: &model.Installation{ID: 1}, sys: &model.System{ID: 10}})
+	installationCache.Add("00:00:00:00:00:02", installationCacheEntry{inst: &model.Installation{ID: 1}, sys: &model.System{ID: 10}})
+	installationCache.Add("00:00:00:00:00:03", installationCacheEntry{inst: &model.Installation{ID: 2}, sys: &model.System{ID: 20}})
+	defer installationCache.Purge()
+
+	invalidateSystemCache(10)
+	require.Equal(t, []string{"00:00:00:00:00:03"}, installationCache.Keys())
+}
@@ -0,0 +1,193 @@
+package dhcp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+type MessageType byte
+
+const (
+	DiscoverMessage MessageType = 1
+	OfferMessage    MessageType = 2
+	RequestMessage  MessageType = 3
+	AckMessage      MessageType = 5
+)
+
+// Option codes used by the server
+const (
+	OptionPad         byte = 0
+	OptionVendorInfo  byte = 43
+	OptionMessageType byte = 53
+	OptionServerID    byte = 54
+	OptionVendorClass byte = 60
+	OptionBootFile    byte = 67
+	OptionClientArch  byte = 93
+	OptionClientUUID  byte = 97
+	OptionEnd         byte = 255
+)
+
+const (
+	bootRequest byte = 1
+	bootReply   byte = 2
+
+	headerLength    = 236
+	minPacketLength = 300
+	snameLength     = 64
+	fileLength      = 128
+
+	magicCookie uint32 = 0x63825363
+)
+
+var ErrMalformed = errors.New("malformed DHCP packet")
+
+// Packet is a BOOTP/DHCPv4 packet (RFC 2131), option overloading is not supported.
+type Packet struct {
+	Op      byte
+	HType   byte
+	HLen    byte
+	Hops    byte
+	XID     uint32
+	Secs    uint16
+	Flags   uint16
+	CIAddr  net.IP
+	YIAddr  net.IP
+	SIAddr  net.IP
+	GIAddr  net.IP
+	CHAddr  net.HardwareAddr
+	SName   string
+	File    string
+	Options map[byte][]byte
+}
+
+// Parse decodes DHCP packet
+func Parse(data []byte) (*Packet, error) {
+	if len(data) < headerLength+4 {
+		return nil, fmt.Errorf("%w: too short (%d bytes)", ErrMalformed, len(data))
+	}
+	if binary.BigEndian.Uint32(data[headerLength:]) != magicCookie {
+		return nil, fmt.Errorf("%w: invalid magic cookie", ErrMalformed)
+	}
+
+	p := &Packet{
+		Op:      data[0],
+		HType:   data[1],
+		HLen:    data[2],
+		Hops:    data[3],
+		XID:     binary.BigEndian.Uint32(data[4:8]),
+		Secs:    binary.BigEndian.Uint16(data[8:10]),
+		Flags:   binary.BigEndian.Uint16(data[10:12]),
+		CIAddr:  net.IP(append([]byte(nil), data[12:16]...)),
+		YIAddr:  net.IP(append([]byte(nil), data[16:20]...)),
+		SIAddr:  net.IP(append([]byte(nil), data[20:24]...)),
+		GIAddr:  net.IP(append([]byte(nil), data[24:28]...)),
+		SName:   cString(data[44:108]),
+		File:    cString(data[108:236]),
+		Options: make(map[byte][]byte),
+	}
+	if p.HLen > 16 {
+		return nil, fmt.Errorf("%w: hardware address length %d", ErrMalformed, p.HLen)
+	}
+	p.CHAddr = net.HardwareAddr(append([]byte(nil), data[28:28+p.HLen]...))
+
+	opts := data[headerLength+4:]
+	for i := 0; i < len(opts); {
+		code := opts[i]
+		if code == OptionEnd {
+			break
+		} else if code == OptionPad {
+			i++
+			continue
+		}
+		if i+1 >= len(opts) || i+2+int(opts[i+1]) > len(opts) {
+			return nil, fmt.Errorf("%w: truncated option %d", ErrMalformed, code)
+		}
+		length := int(opts[i+1])
+		// repeated options are concatenated (RFC 3396)
+		p.Options[code] = append(p.Options[code], opts[i+2:i+2+length]...)
+		i += 2 + length
+	}
+
+	return p, nil
+}
+
+func cString(b []byte) string {
+	if i := strings.IndexByte(string(b), 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// Marshal encodes DHCP packet, message type option is written first and long options are
+// split into multiple options.
+func (p *Packet) Marshal() []byte {
+	buf := make([]byte, headerLength+4, minPacketLength)
+	buf[0], buf[1], buf[2], buf[3] = p.Op, p.HType, p.HLen, p.Hops
+	binary.BigEndian.PutUint32(buf[4:8], p.XID)
+	binary.BigEndian.PutUint16(buf[8:10], p.Secs)
+	binary.BigEndian.PutUint16(buf[10:12], p.Flags)
+	copy(buf[12:16], p.CIAddr.To4())
+	copy(buf[16:20], p.YIAddr.To4())
+	copy(buf[20:24], p.SIAddr.To4())
+	copy(buf[24:28], p.GIAddr.To4())
+	copy(buf[28:44], p.CHAddr)
+	copy(buf[44:44+snameLength-1], p.SName)
+	copy(buf[108:108+fileLength-1], p.File)
+	binary.BigEndian.PutUint32(buf[headerLength:], magicCookie)
+
+	codes := make([]int, 0, len(p.Options))
+	for code := range p.Options {
+		if code != OptionMessageType {
+			codes = append(codes, int(code))
+		}
+	}
+	sort.Ints(codes)
+	if _, ok := p.Options[OptionMessageType]; ok {
+		codes = append([]int{int(OptionMessageType)}, codes...)
+	}
+
+	for _, code := range codes {
+		value := p.Options[byte(code)]
+		for {
+			chunk := value[:min(len(value), 255)]
+			buf = append(buf, byte(code), byte(len(chunk)))
+			buf = append(buf, chunk...)
+			value = value[len(chunk):]
+			if len(value) == 0 {
+				break
+			}
+		}
+	}
+	buf = append(buf, OptionEnd)
+
+	for len(buf) < minPacketLength {
+		buf = append(buf, OptionPad)
+	}
+
+	return buf
+}
+
+// MessageType returns DHCP message type or zero for BOOTP packets
+func (p *Packet) MessageType() MessageType {
+	if v := p.Options[OptionMessageType]; len(v) == 1 {
+		return MessageType(v[0])
+	}
+	return 0
+}
+
+// VendorClass returns vendor class identifier (option 60)
+func (p *Packet) VendorClass() string {
+	return string(p.Options[OptionVendorClass])
+}
+
+// ClientArch returns the first client system architecture type (option 93, RFC 4578)
+func (p *Packet) ClientArch() (uint16, bool) {
+	if v := p.Options[OptionClientArch]; len(v) >= 2 {
+		return binary.BigEndian.Uint16(v), true
+	}
+	return 0, false
+}
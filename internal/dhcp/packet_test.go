@@ -0,0 +1,55 @@
+package dhcp
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacket(t *testing.T) {
+	mac, _ := net.ParseMAC("52:54:00:12:34:56")
+	p := &Packet{
+		Op:     bootRequest,
+		HType:  1,
+		HLen:   6,
+		XID:    0xdeadbeef,
+		Flags:  0x8000,
+		CIAddr: net.IPv4zero,
+		YIAddr: net.IPv4zero,
+		SIAddr: net.IPv4(192, 168, 1, 1),
+		GIAddr: net.IPv4zero,
+		CHAddr: mac,
+		File:   "boot/bios/52:54:00:12:34:56/grubx64.0",
+		Options: map[byte][]byte{
+			OptionVendorClass: []byte("PXEClient:Arch:00000:UNDI:002001"),
+			OptionClientArch:  {0, 7},
+			OptionMessageType: {byte(DiscoverMessage)},
+			OptionBootFile:    bytes.Repeat([]byte("x"), 300),
+		},
+	}
+
+	data := p.Marshal()
+	require.GreaterOrEqual(t, len(data), minPacketLength)
+	require.Equal(t, []byte{OptionMessageType, 1, byte(DiscoverMessage)}, data[headerLength+4:headerLength+7])
+
+	parsed, err := Parse(data)
+	require.NoError(t, err)
+	require.Equal(t, p.XID, parsed.XID)
+	require.Equal(t, p.Flags, parsed.Flags)
+	require.Equal(t, mac, parsed.CHAddr)
+	require.True(t, p.SIAddr.Equal(parsed.SIAddr))
+	require.Equal(t, p.File, parsed.File)
+	require.Equal(t, DiscoverMessage, parsed.MessageType())
+	require.Equal(t, "PXEClient:Arch:00000:UNDI:002001", parsed.VendorClass())
+	require.Len(t, parsed.Options[OptionBootFile], 300)
+	arch, ok := parsed.ClientArch()
+	require.True(t, ok)
+	require.Equal(t, EFIX64BCArch, arch)
+
+	_, err = Parse(data[:100])
+	require.ErrorIs(t, err, ErrMalformed)
+	_, err = Parse(append(data[:headerLength+4:headerLength+4], OptionVendorClass, 10, 'x'))
+	require.ErrorIs(t, err, ErrMalformed)
+}
@@ -0,0 +1,278 @@
+package dhcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/ipv4"
+)
+
+// Client system architecture types (option 93, RFC 4578 and IANA registry)
+const (
+	BIOSArch        uint16 = 0
+	EFIX64BCArch    uint16 = 7
+	EFIX64Arch      uint16 = 9
+	EFIAA64Arch     uint16 = 11
+	EFIX64HTTPArch  uint16 = 16
+	EFIAA64HTTPArch uint16 = 19
+)
+
+const (
+	pxeClient  = "PXEClient"
+	httpClient = "HTTPClient"
+)
+
+// pxeDiscoveryControl is PXE vendor option 43 telling the client to boot the file from the
+// offer instead of performing boot server discovery
+var pxeDiscoveryControl = []byte{6, 1, 8, 255}
+
+// KnownFunc returns true for MAC addresses the server answers to
+type KnownFunc func(ctx context.Context, mac net.HardwareAddr) bool
+
+// Server is ProxyDHCP server (PXE specification 2.1): it does not assign addresses, it only
+// offers boot file names to PXE and HTTP Boot clients alongside the main DHCP server.
+// DHCPDISCOVER is answered on the DHCP port and DHCPREQUEST on the PXE port (4011).
+type Server struct {
+	serverIP net.IP
+	baseURL  string
+	known    KnownFunc
+	conns    []*ipv4.PacketConn
+	wg       sync.WaitGroup
+}
+
+// Start listens on DHCP (67) and PXE (4011) addresses. Server IP is announced as the TFTP
+// server and the server identifier, when nil it is detected from the receiving interface.
+// Base URL is used to build HTTP Boot URLs.
+func Start(ctx context.Context, dhcpAddress, pxeAddress string, serverIP net.IP, baseURL string, known KnownFunc) (*Server, error) {
+	server := &Server{
+		serverIP: serverIP.To4(),
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		known:    known,
+	}
+	slog.InfoContext(ctx, "starting ProxyDHCP server",
+		"dhcp_address", dhcpAddress,
+		"pxe_address", pxeAddress,
+		"server_ip", serverIP,
+		"url", baseURL)
+
+	for _, listen := range []struct {
+		address string
+		accept  MessageType
+	}{
+		{dhcpAddress, DiscoverMessage},
+		{pxeAddress, RequestMessage},
+	} {
+		conn, err := net.ListenPacket("udp4", listen.address)
+		if err != nil {
+			server.Shutdown()
+			return nil, fmt.Errorf("cannot listen on %s: %w", listen.address, err)
+		}
+		pc := ipv4.NewPacketConn(conn)
+		err = pc.SetControlMessage(ipv4.FlagDst|ipv4.FlagInterface, true)
+		if err != nil {
+			conn.Close()
+			server.Shutdown()
+			return nil, fmt.Errorf("cannot set control message: %w", err)
+		}
+		server.conns = append(server.conns, pc)
+
+		server.wg.Add(1)
+		go server.serve(ctx, pc, listen.accept)
+	}
+
+	return server, nil
+}
+
+func (s *Server) Shutdown() {
+	for _, pc := range s.conns {
+		pc.Close()
+	}
+	s.wg.Wait()
+}
+
+func (s *Server) serve(ctx context.Context, pc *ipv4.PacketConn, accept MessageType) {
+	defer s.wg.Done()
+
+	buf := make([]byte, 1500)
+	for {
+		n, cm, src, err := pc.ReadFrom(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		} else if err != nil {
+			slog.WarnContext(ctx, "cannot read DHCP packet", "err", err)
+			continue
+		}
+
+		req, err := Parse(buf[:n])
+		if err != nil {
+			slog.DebugContext(ctx, "ignoring DHCP packet", "src", src, "err", err)
+			continue
+		}
+		if req.MessageType() != accept {
+			continue
+		}
+
+		serverIP := s.serverIP
+		if serverIP == nil && cm != nil {
+			serverIP = interfaceIP(cm)
+		}
+		if serverIP == nil {
+			slog.WarnContext(ctx, "cannot detect ProxyDHCP server IP, set DHCP_SERVER_IP", "mac", req.CHAddr)
+			continue
+		}
+
+		res := s.reply(ctx, req, serverIP)
+		if res == nil {
+			continue
+		}
+
+		dst := replyAddr(req, src, accept)
+		var wcm *ipv4.ControlMessage
+		if cm != nil {
+			wcm = &ipv4.ControlMessage{IfIndex: cm.IfIndex}
+		}
+		if _, err := pc.WriteTo(res.Marshal(), wcm, dst); err != nil {
+			slog.WarnContext(ctx, "cannot send DHCP reply", "mac", req.CHAddr, "dst", dst, "err", err)
+			continue
+		}
+		slog.InfoContext(ctx, "sent ProxyDHCP reply", "mac", req.CHAddr, "dst", dst, "file", res.File)
+	}
+}
+
+// reply returns offer or acknowledgement for PXE and HTTP Boot requests of known clients,
+// nil is returned when the request is ignored
+func (s *Server) reply(ctx context.Context, req *Packet, serverIP net.IP) *Packet {
+	if req.Op != bootRequest || req.HType != 1 || len(req.CHAddr) != 6 {
+		return nil
+	}
+
+	vendor := req.VendorClass()
+	if !strings.HasPrefix(vendor, pxeClient) && !strings.HasPrefix(vendor, httpClient) {
+		return nil
+	}
+
+	arch, ok := req.ClientArch()
+	if !ok {
+		slog.DebugContext(ctx, "ignoring DHCP request without client architecture", "mac", req.CHAddr)
+		return nil
+	}
+
+	file, http := s.bootFile(req.CHAddr, arch, strings.HasPrefix(vendor, httpClient))
+	if file == "" {
+		slog.DebugContext(ctx, "ignoring DHCP request of unsupported architecture", "mac", req.CHAddr, "arch", arch)
+		return nil
+	}
+
+	if s.known != nil && !s.known(ctx, req.CHAddr) {
+		slog.DebugContext(ctx, "ignoring DHCP request of unknown system", "mac", req.CHAddr)
+		return nil
+	}
+
+	res := &Packet{
+		Op:     bootReply,
+		HType:  req.HType,
+		HLen:   req.HLen,
+		XID:    req.XID,
+		Flags:  req.Flags,
+		CIAddr: req.CIAddr,
+		YIAddr: net.IPv4zero,
+		SIAddr: serverIP,
+		GIAddr: req.GIAddr,
+		CHAddr: req.CHAddr,
+		Options: map[byte][]byte{
+			OptionServerID: serverIP.To4(),
+		},
+	}
+
+	if req.MessageType() == DiscoverMessage {
+		res.Options[OptionMessageType] = []byte{byte(OfferMessage)}
+	} else {
+		res.Options[OptionMessageType] = []byte{byte(AckMessage)}
+	}
+
+	if http {
+		res.Options[OptionVendorClass] = []byte(httpClient)
+	} else {
+		res.Options[OptionVendorClass] = []byte(pxeClient)
+		res.Options[OptionVendorInfo] = pxeDiscoveryControl
+	}
+
+	if uuid, ok := req.Options[OptionClientUUID]; ok {
+		res.Options[OptionClientUUID] = uuid
+	}
+
+	if len(file) < fileLength {
+		res.File = file
+	} else {
+		res.Options[OptionBootFile] = []byte(file)
+	}
+
+	return res
+}
+
+// bootFile returns TFTP path or HTTP URL of the boot file for a client architecture, blank
+// string for unsupported architectures
+func (s *Server) bootFile(mac net.HardwareAddr, arch uint16, httpVendor bool) (string, bool) {
+	switch arch {
+	case EFIX64HTTPArch:
+		return fmt.Sprintf("%s/boot/efi64/%s/shim.efi", s.baseURL, mac), true
+	case EFIAA64HTTPArch:
+		return fmt.Sprintf("%s/boot/efiaa64/%s/shim.efi", s.baseURL, mac), true
+	}
+
+	if httpVendor {
+		return "", false
+	}
+
+	switch arch {
+	case BIOSArch:
+		return fmt.Sprintf("boot/bios/%s/grubx64.0", mac), false
+	case EFIX64BCArch, EFIX64Arch:
+		return fmt.Sprintf("boot/efi64/%s/shim.efi", mac), false
+	case EFIAA64Arch:
+		return fmt.Sprintf("boot/efiaa64/%s/shim.efi", mac), false
+	}
+
+	return "", false
+}
+
+// replyAddr returns destination of a reply: PXE requests are answered to the source, DHCP
+// requests via relay agent or broadcast
+func replyAddr(req *Packet, src net.Addr, accept MessageType) net.Addr {
+	if accept == RequestMessage {
+		return src
+	}
+	if req.GIAddr != nil && !req.GIAddr.IsUnspecified() {
+		return &net.UDPAddr{IP: req.GIAddr, Port: 67}
+	}
+	return &net.UDPAddr{IP: net.IPv4bcast, Port: 68}
+}
+
+// interfaceIP returns destination address of a unicast packet or the first IPv4 address of
+// the interface which received a broadcast
+func interfaceIP(cm *ipv4.ControlMessage) net.IP {
+	if cm.Dst != nil && !cm.Dst.Equal(net.IPv4bcast) && !cm.Dst.IsUnspecified() {
+		return cm.Dst.To4()
+	}
+
+	iface, err := net.InterfaceByIndex(cm.IfIndex)
+	if err != nil {
+		return nil
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
+			return ipnet.IP.To4()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package dhcp
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func request(mac net.HardwareAddr, msg MessageType, vendor string, arch uint16) *Packet {
+	return &Packet{
+		Op:     bootRequest,
+		HType:  1,
+		HLen:   6,
+		XID:    42,
+		CHAddr: mac,
+		Options: map[byte][]byte{
+			OptionMessageType: {byte(msg)},
+			OptionVendorClass: []byte(vendor),
+			OptionClientArch:  {byte(arch >> 8), byte(arch)},
+			OptionClientUUID:  {0, 1, 2, 3},
+		},
+	}
+}
+
+func TestReply(t *testing.T) {
+	known, _ := net.ParseMAC("52:54:00:00:00:01")
+	unknown, _ := net.ParseMAC("52:54:00:00:00:02")
+	s := &Server{
+		baseURL: "http://forester:8000",
+		known: func(ctx context.Context, mac net.HardwareAddr) bool {
+			return mac.String() == known.String()
+		},
+	}
+	serverIP := net.IPv4(192, 168, 122, 1)
+
+	tests := map[string]struct {
+		req    *Packet
+		file   string
+		vendor string
+		msg    MessageType
+	}{
+		"bios": {
+			req:    request(known, DiscoverMessage, "PXEClient:Arch:00000:UNDI:002001", BIOSArch),
+			file:   "boot/bios/52:54:00:00:00:01/grubx64.0",
+			vendor: "PXEClient",
+			msg:    OfferMessage,
+		},
+		"efi x64": {
+			req:    request(known, RequestMessage, "PXEClient:Arch:00007:UNDI:003016", EFIX64BCArch),
+			file:   "boot/efi64/52:54:00:00:00:01/shim.efi",
+			vendor: "PXEClient",
+			msg:    AckMessage,
+		},
+		"efi aa64": {
+			req:    request(known, DiscoverMessage, "PXEClient:Arch:00011:UNDI:003000", EFIAA64Arch),
+			file:   "boot/efiaa64/52:54:00:00:00:01/shim.efi",
+			vendor: "PXEClient",
+			msg:    OfferMessage,
+		},
+		"http boot": {
+			req:    request(known, DiscoverMessage, "HTTPClient:Arch:00016:UNDI:003001", EFIX64HTTPArch),
+			file:   "http://forester:8000/boot/efi64/52:54:00:00:00:01/shim.efi",
+			vendor: "HTTPClient",
+			msg:    OfferMessage,
+		},
+		"unknown system": {
+			req: request(unknown, DiscoverMessage, "PXEClient:Arch:00000:UNDI:002001", BIOSArch),
+		},
+		"not pxe": {
+			req: request(known, DiscoverMessage, "MSFT 5.0", BIOSArch),
+		},
+		"unsupported arch": {
+			req: request(known, DiscoverMessage, "PXEClient:Arch:00006:UNDI:003016", 6),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			res := s.reply(context.Background(), tc.req, serverIP)
+			if tc.file == "" {
+				require.Nil(t, res)
+				return
+			}
+
+			require.NotNil(t, res)
+			require.Equal(t, bootReply, res.Op)
+			require.Equal(t, tc.req.XID, res.XID)
+			require.Equal(t, tc.file, res.File)
+			require.Equal(t, tc.msg, res.MessageType())
+			require.Equal(t, tc.vendor, res.VendorClass())
+			require.True(t, serverIP.Equal(res.SIAddr))
+			require.True(t, net.IPv4zero.Equal(res.YIAddr))
+			require.Equal(t, []byte(serverIP.To4()), res.Options[OptionServerID])
+			require.Equal(t, tc.req.Options[OptionClientUUID], res.Options[OptionClientUUID])
+		})
+	}
+}
+
+func TestServerPXE(t *testing.T) {
+	ctx := context.Background()
+	s, err := Start(ctx, "127.0.0.1:0", "127.0.0.1:0", nil, "http://forester:8000", nil)
+	require.NoError(t, err)
+	defer s.Shutdown()
+
+	conn, err := net.DialUDP("udp4", nil, s.conns[1].LocalAddr().(*net.UDPAddr))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	mac, _ := net.ParseMAC("52:54:00:00:00:03")
+	req := request(mac, RequestMessage, "PXEClient:Arch:00009:UNDI:003016", EFIX64Arch)
+	_, err = conn.Write(req.Marshal())
+	require.NoError(t, err)
+
+	buf := make([]byte, 1500)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+
+	res, err := Parse(buf[:n])
+	require.NoError(t, err)
+	require.Equal(t, AckMessage, res.MessageType())
+	require.Equal(t, "boot/efi64/52:54:00:00:00:03/shim.efi", res.File)
+	require.True(t, net.IPv4(127, 0, 0, 1).Equal(res.SIAddr))
+}
@@ -0,0 +1,305 @@
+// Package fat writes FAT16 file system images, it is used for EFI system partitions.
+package fat
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	sectorSize  = 512
+	rootEntries = 512
+	entrySize   = 32
+
+	// FAT16 requires number of clusters within this range
+	minClusters = 4085 + 16
+	maxClusters = 65524
+)
+
+var ErrInvalidName = errors.New("name is not a valid 8.3 name")
+
+var ErrTooLarge = errors.New("content does not fit into FAT16 image")
+
+// Image is a FAT16 image built in memory
+type Image struct {
+	label string
+	root  *node
+}
+
+type node struct {
+	name     string
+	data     []byte
+	children map[string]*node
+	cluster  uint16
+}
+
+func (n *node) isDir() bool {
+	return n.children != nil
+}
+
+// New creates an empty image with a volume label.
+func New(label string) *Image {
+	return &Image{label: label, root: &node{children: make(map[string]*node)}}
+}
+
+// AddFile adds a file, missing parent directories are created. Names must be in 8.3 format,
+// all-lowercase names keep their case.
+func (im *Image) AddFile(name string, data []byte) error {
+	dir := im.root
+	parts := strings.Split(path.Clean(name), "/")
+	for i, part := range parts {
+		if _, err := shortName(part); err != nil {
+			return fmt.Errorf("%w: %s", err, name)
+		}
+
+		key := strings.ToUpper(part)
+		last := i == len(parts)-1
+		child, ok := dir.children[key]
+		switch {
+		case ok && (last || !child.isDir()):
+			return fmt.Errorf("duplicate file: %s", name)
+		case ok:
+			dir = child
+		case last:
+			dir.children[key] = &node{name: part, data: data}
+		default:
+			child = &node{name: part, children: make(map[string]*node)}
+			dir.children[key] = child
+			dir = child
+		}
+	}
+
+	return nil
+}
+
+// shortName returns 11 bytes of directory entry name and case flags
+func shortName(name string) ([12]byte, error) {
+	var result [12]byte
+	base, ext, _ := strings.Cut(name, ".")
+	if base == "" || len(base) > 8 || len(ext) > 3 || strings.Contains(ext, ".") {
+		return result, ErrInvalidName
+	}
+
+	copy(result[:11], "           ")
+	for i, s := range []string{base, ext} {
+		upper := strings.ToUpper(s)
+		for _, c := range upper {
+			if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("_-~!#$%&'()@^`{}", c)) {
+				return result, ErrInvalidName
+			}
+		}
+
+		// NT case flags, mixed case names would need long file names
+		switch s {
+		case upper:
+		case strings.ToLower(s):
+			result[11] |= byte(0x08 << i)
+		default:
+			return result, ErrInvalidName
+		}
+
+		if i == 0 {
+			copy(result[0:8], upper)
+		} else {
+			copy(result[8:11], upper)
+		}
+	}
+
+	return result, nil
+}
+
+func sortedChildren(n *node) []*node {
+	result := make([]*node, 0, len(n.children))
+	for _, c := range n.children {
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+	return result
+}
+
+// WriteTo writes the image, the size is computed from the content.
+func (im *Image) WriteTo(w io.Writer) (int64, error) {
+	// find the smallest cluster size which fits FAT16 limits
+	var spc, clusters int
+	for spc = 1; spc <= 64; spc *= 2 {
+		clusters = im.clusters(im.root, spc*sectorSize, true)
+		if clusters <= maxClusters {
+			break
+		}
+	}
+	if spc > 64 {
+		return 0, ErrTooLarge
+	}
+	clusters = max(clusters, minClusters)
+	clusterSize := spc * sectorSize
+
+	fatSectors := ((clusters+2)*2 + sectorSize - 1) / sectorSize
+	rootSectors := rootEntries * entrySize / sectorSize
+	dataStart := 1 + 2*fatSectors + rootSectors
+	totalSectors := dataStart + clusters*spc
+
+	fat := make([]uint16, clusters+2)
+	fat[0], fat[1] = 0xfff8, 0xffff
+	data := make([]byte, clusters*clusterSize)
+
+	// allocate clusters, directories first so they can be written with known locations
+	next := uint16(2)
+	var alloc func(n *node)
+	alloc = func(n *node) {
+		for _, c := range sortedChildren(n) {
+			size := len(c.data)
+			if c.isDir() {
+				size = (len(c.children) + 2) * entrySize
+			}
+			count := (size + clusterSize - 1) / clusterSize
+			if count == 0 {
+				continue
+			}
+			c.cluster = next
+			for i := 0; i < count; i++ {
+				if i == count-1 {
+					fat[next] = 0xffff
+				} else {
+					fat[next] = next + 1
+				}
+				next++
+			}
+			if c.isDir() {
+				alloc(c)
+			}
+		}
+	}
+	alloc(im.root)
+
+	clusterData := func(cl uint16) []byte {
+		off := (int(cl) - 2) * clusterSize
+		return data[off:]
+	}
+
+	// volume label is the first entry of the root directory
+	rootDir := make([]byte, rootSectors*sectorSize)
+	first := 0
+	if im.label != "" {
+		copy(rootDir[0:11], fmt.Sprintf("%-11.11s", strings.ToUpper(im.label)))
+		rootDir[11] = 0x08
+		first = 1
+	}
+
+	var write func(n *node, dir []byte, parent uint16, first int) error
+	write = func(n *node, dir []byte, parent uint16, first int) error {
+		i := first
+		if n != im.root {
+			putEntry(dir[0:], [12]byte{'.', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '}, 0x10, n.cluster, 0)
+			putEntry(dir[entrySize:], [12]byte{'.', '.', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '}, 0x10, parent, 0)
+			i = 2
+		}
+		for _, c := range sortedChildren(n) {
+			if (i+1)*entrySize > len(dir) {
+				return ErrTooLarge
+			}
+			name, err := shortName(c.name)
+			if err != nil {
+				return err
+			}
+			if c.isDir() {
+				// parent of first level directories is root with cluster 0
+				putEntry(dir[i*entrySize:], name, 0x10, c.cluster, 0)
+				err = write(c, clusterData(c.cluster), n.cluster, 0)
+				if err != nil {
+					return err
+				}
+			} else {
+				putEntry(dir[i*entrySize:], name, 0x20, c.cluster, uint32(len(c.data)))
+				if c.cluster != 0 {
+					copy(clusterData(c.cluster), c.data)
+				}
+			}
+			i++
+		}
+		return nil
+	}
+	err := write(im.root, rootDir, 0, first)
+	if err != nil {
+		return 0, err
+	}
+
+	buf := &bytes.Buffer{}
+	buf.Write(bootSector(im.label, spc, fatSectors, totalSectors))
+	fatBytes := make([]byte, fatSectors*sectorSize)
+	for i, v := range fat {
+		binary.LittleEndian.PutUint16(fatBytes[i*2:], v)
+	}
+	buf.Write(fatBytes)
+	buf.Write(fatBytes)
+	buf.Write(rootDir)
+	buf.Write(data)
+
+	return buf.WriteTo(w)
+}
+
+// clusters returns number of clusters used by content of a directory
+func (im *Image) clusters(n *node, clusterSize int, root bool) int {
+	var result int
+	if !root {
+		result = ((len(n.children)+2)*entrySize + clusterSize - 1) / clusterSize
+	}
+	for _, c := range n.children {
+		if c.isDir() {
+			result += im.clusters(c, clusterSize, false)
+		} else {
+			result += (len(c.data) + clusterSize - 1) / clusterSize
+		}
+	}
+	return result
+}
+
+func putEntry(b []byte, name [12]byte, attr byte, cluster uint16, size uint32) {
+	copy(b[0:11], name[:11])
+	b[11] = attr
+	b[12] = name[11]
+	// 2020-01-01 00:00:00
+	binary.LittleEndian.PutUint16(b[16:18], (2020-1980)<<9|1<<5|1)
+	binary.LittleEndian.PutUint16(b[24:26], (2020-1980)<<9|1<<5|1)
+	binary.LittleEndian.PutUint16(b[26:28], cluster)
+	binary.LittleEndian.PutUint32(b[28:32], size)
+}
+
+func bootSector(label string, spc, fatSectors, totalSectors int) []byte {
+	b := make([]byte, sectorSize)
+	copy(b[0:3], []byte{0xeb, 0x3c, 0x90})
+	copy(b[3:11], "FORESTER")
+	binary.LittleEndian.PutUint16(b[11:13], sectorSize)
+	b[13] = byte(spc)
+	binary.LittleEndian.PutUint16(b[14:16], 1)
+	b[16] = 2
+	binary.LittleEndian.PutUint16(b[17:19], rootEntries)
+	if totalSectors < 0x10000 {
+		binary.LittleEndian.PutUint16(b[19:21], uint16(totalSectors))
+	} else {
+		binary.LittleEndian.PutUint32(b[32:36], uint32(totalSectors))
+	}
+	b[21] = 0xf8
+	binary.LittleEndian.PutUint16(b[22:24], uint16(fatSectors))
+	binary.LittleEndian.PutUint16(b[24:26], 32)
+	binary.LittleEndian.PutUint16(b[26:28], 64)
+	b[36] = 0x80
+	b[38] = 0x29
+	binary.LittleEndian.PutUint32(b[39:43], 0x464f5245)
+	copy(b[43:54], fmt.Sprintf("%-11.11s", strings.ToUpper(orNoName(label))))
+	copy(b[54:62], "FAT16   ")
+	b[510], b[511] = 0x55, 0xaa
+	return b
+}
+
+func orNoName(label string) string {
+	if label == "" {
+		return "NO NAME"
+	}
+	return label
+}
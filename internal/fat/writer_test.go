@@ -0,0 +1,120 @@
+package fat
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// readFile is a minimal FAT16 reader following directory entries and cluster chains
+func readFile(t *testing.T, img []byte, name string) []byte {
+	t.Helper()
+
+	spc := int(img[13])
+	fatSectors := int(binary.LittleEndian.Uint16(img[22:24]))
+	fatStart := sectorSize
+	rootStart := (1 + 2*fatSectors) * sectorSize
+	dataStart := rootStart + rootEntries*entrySize
+	clusterSize := spc * sectorSize
+
+	chain := func(cl uint16, size int) []byte {
+		var result []byte
+		for cl >= 2 && cl < 0xfff8 {
+			off := dataStart + (int(cl)-2)*clusterSize
+			result = append(result, img[off:off+clusterSize]...)
+			cl = binary.LittleEndian.Uint16(img[fatStart+int(cl)*2:])
+		}
+		if size >= 0 {
+			result = result[:size]
+		}
+		return result
+	}
+
+	dir := img[rootStart:dataStart]
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		want, err := shortName(part)
+		require.NoError(t, err)
+
+		var found bool
+		for off := 0; off+entrySize <= len(dir) && dir[off] != 0; off += entrySize {
+			e := dir[off : off+entrySize]
+			if !bytes.Equal(e[0:11], want[0:11]) || e[11]&0x08 != 0 {
+				continue
+			}
+			require.Equal(t, want[11], e[12], "case flags of %s", part)
+
+			cl := binary.LittleEndian.Uint16(e[26:28])
+			if i == len(parts)-1 {
+				require.Zero(t, e[11]&0x10, "%s is a directory", name)
+				return chain(cl, int(binary.LittleEndian.Uint32(e[28:32])))
+			}
+			require.NotZero(t, e[11]&0x10, "%s is not a directory", part)
+			dir = chain(cl, -1)
+			found = true
+			break
+		}
+		require.True(t, found, "%s not found", part)
+	}
+
+	return nil
+}
+
+func TestWriteTo(t *testing.T) {
+	large := bytes.Repeat([]byte("0123456789"), 300000)
+
+	im := New("efi")
+	require.NoError(t, im.AddFile("EFI/BOOT/BOOTX64.EFI", []byte("shim")))
+	require.NoError(t, im.AddFile("EFI/BOOT/grubx64.efi", large))
+	require.NoError(t, im.AddFile("EFI/BOOT/grub.cfg", []byte("set timeout=5\n")))
+	require.NoError(t, im.AddFile("EFI/BOOT/fonts/unicode.pf2", nil))
+
+	var buf bytes.Buffer
+	_, err := im.WriteTo(&buf)
+	require.NoError(t, err)
+	img := buf.Bytes()
+
+	require.Equal(t, []byte{0x55, 0xaa}, img[510:512])
+	require.Equal(t, "FAT16   ", string(img[54:62]))
+	require.Equal(t, "EFI        ", string(img[43:54]))
+	total := int(binary.LittleEndian.Uint16(img[19:21]))
+	if total == 0 {
+		total = int(binary.LittleEndian.Uint32(img[32:36]))
+	}
+	require.Equal(t, total*sectorSize, len(img))
+
+	require.Equal(t, "shim", string(readFile(t, img, "EFI/BOOT/BOOTX64.EFI")))
+	require.Equal(t, large, readFile(t, img, "EFI/BOOT/grubx64.efi"))
+	require.Equal(t, "set timeout=5\n", string(readFile(t, img, "EFI/BOOT/grub.cfg")))
+	require.Empty(t, readFile(t, img, "EFI/BOOT/fonts/unicode.pf2"))
+}
+
+func TestAddFile(t *testing.T) {
+	tests := map[string]struct {
+		name  string
+		valid bool
+	}{
+		"upper":     {"EFI/BOOT/BOOTX64.EFI", true},
+		"lower":     {"efi/boot/grub.cfg", true},
+		"no ext":    {"EFI/BOOT/LICENSE", true},
+		"mixed":     {"EFI/BOOT/Grub.cfg", false},
+		"long":      {"EFI/BOOT/grubx64-signed.efi", false},
+		"two dots":  {"EFI/BOOT/a.b.c", false},
+		"long ext":  {"EFI/BOOT/unicode.pf22", false},
+		"bad chars": {"EFI/BOOT/a b.efi", false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := New("").AddFile(tc.name, nil)
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, ErrInvalidName)
+			}
+		})
+	}
+}
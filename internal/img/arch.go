@@ -0,0 +1,53 @@
+package img
+
+import (
+	"io/fs"
+	"strings"
+
+	"forester/internal/model"
+)
+
+// normalizeArch returns architecture name as used by Fedora and uname
+func normalizeArch(arch string) string {
+	switch arch = strings.ToLower(strings.TrimSpace(arch)); arch {
+	case "amd64", "x64":
+		return model.X86_64Arch
+	case "arm64", "aa64":
+		return model.Aarch64Arch
+	}
+	return arch
+}
+
+// efiNames returns shim and grub file names in EFI/BOOT directory, x86_64 is the default
+func efiNames(arch string) (shim, grub string) {
+	if arch == model.Aarch64Arch {
+		return "BOOTAA64.EFI", "grubaa64.efi"
+	}
+	return "BOOTX64.EFI", "grubx64.efi"
+}
+
+// hasBIOS returns true for architectures booting via BIOS
+func hasBIOS(arch string) bool {
+	return arch == model.X86_64Arch || arch == ""
+}
+
+// DetectArch returns CPU architecture of an image from .treeinfo ("arch" key in "tree" or
+// "general" section) or from the third line of .discinfo. Blank string is returned when
+// the architecture cannot be detected.
+func DetectArch(fsys fs.FS) string {
+	ti := readTreeinfo(fsys)
+	for _, section := range []string{"tree", "general"} {
+		if arch := normalizeArch(ti.get(section, "arch")); arch != "" {
+			return arch
+		}
+	}
+
+	if data, err := fs.ReadFile(fsys, ".discinfo"); err == nil {
+		lines := strings.Split(string(data), "\n")
+		if len(lines) >= 3 {
+			return normalizeArch(lines[2])
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,49 @@
+package img
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectArch(t *testing.T) {
+	tests := map[string]struct {
+		files fstest.MapFS
+		want  string
+	}{
+		"treeinfo": {
+			files: fstest.MapFS{
+				".treeinfo": {Data: []byte("[header]\ntype = productmd.treeinfo\n\n[general]\narch = x86_64\n\n[tree]\narch = aarch64\nplatforms = aarch64\n")},
+				".discinfo": {Data: []byte("1700000000.0\n40\nx86_64\n")},
+			},
+			want: "aarch64",
+		},
+		"treeinfo general": {
+			files: fstest.MapFS{".treeinfo": {Data: []byte("[general]\narch = x86_64\n")}},
+			want:  "x86_64",
+		},
+		"discinfo": {
+			files: fstest.MapFS{".discinfo": {Data: []byte("1700000000.0\n40\naarch64\n")}},
+			want:  "aarch64",
+		},
+		"normalized": {
+			files: fstest.MapFS{".discinfo": {Data: []byte("1700000000.0\n40\narm64")}},
+			want:  "aarch64",
+		},
+		"short discinfo": {
+			files: fstest.MapFS{".discinfo": {Data: []byte("1700000000.0\n")}},
+			want:  "",
+		},
+		"none": {
+			files: fstest.MapFS{},
+			want:  "",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.want, DetectArch(tc.files))
+		})
+	}
+}
@@ -0,0 +1,444 @@
+package img
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"os"
+	"path"
+	"sort"
+)
+
+var (
+	ErrMalformedPE      = errors.New("malformed PE file")
+	ErrNotSigned        = errors.New("no Authenticode signature")
+	ErrInvalidSignature = errors.New("invalid Authenticode signature")
+	ErrNoCertificates   = errors.New("no certificates found")
+)
+
+const (
+	peMagicPE32     = 0x10b
+	peMagicPE32Plus = 0x20b
+
+	// security data directory holds WIN_CERTIFICATE structures
+	peSecurityDirectory = 4
+
+	winCertTypePKCSSignedData = 0x0002
+)
+
+var (
+	oidSignedData        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidSpcIndirectData   = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 2, 1, 4}
+	oidAttrContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttrMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidDigestSHA1        = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidDigestSHA256      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidDigestSHA384      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidDigestSHA512      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidEncryptionRSA     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidEncryptionECDSA   = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidRSAWithSHA1       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 5}
+	oidRSAWithSHA256     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidRSAWithSHA384     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidRSAWithSHA512     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	oidECDSAWithSHA256   = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+// PKCS #7 (RFC 2315) and Authenticode structures
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      contentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type issuerAndSerial struct {
+	Issuer asn1.RawValue
+	Serial *big.Int
+}
+
+type signerInfo struct {
+	Version                   int
+	IssuerAndSerial           issuerAndSerial
+	DigestAlgorithm           pkix.AlgorithmIdentifier
+	AuthenticatedAttributes   asn1.RawValue `asn1:"optional,tag:0"`
+	DigestEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedDigest           []byte
+	UnauthenticatedAttributes asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+type spcIndirectDataContent struct {
+	Data          asn1.RawValue
+	MessageDigest digestInfo
+}
+
+// peFile contains offsets of a PE/COFF image needed for Authenticode digest
+type peFile struct {
+	data        []byte
+	checksum    int
+	securityDir int
+	headersSize int
+	sections    [][2]int
+	certOffset  int
+	certSize    int
+}
+
+func parsePE(data []byte) (*peFile, error) {
+	if len(data) < 0x40 || !bytes.HasPrefix(data, []byte("MZ")) {
+		return nil, fmt.Errorf("%w: no DOS header", ErrMalformedPE)
+	}
+	le := binary.LittleEndian
+	peOffset := int(le.Uint32(data[0x3c:]))
+	if peOffset+24 > len(data) || !bytes.Equal(data[peOffset:peOffset+4], []byte("PE\x00\x00")) {
+		return nil, fmt.Errorf("%w: no PE signature", ErrMalformedPE)
+	}
+
+	coff := peOffset + 4
+	numSections := int(le.Uint16(data[coff+2:]))
+	optSize := int(le.Uint16(data[coff+16:]))
+	opt := coff + 20
+	if opt+optSize > len(data) || optSize < 96 {
+		return nil, fmt.Errorf("%w: truncated optional header", ErrMalformedPE)
+	}
+
+	var numDirs, dirs int
+	switch le.Uint16(data[opt:]) {
+	case peMagicPE32:
+		numDirs, dirs = int(le.Uint32(data[opt+92:])), opt+96
+	case peMagicPE32Plus:
+		if optSize < 112 {
+			return nil, fmt.Errorf("%w: truncated optional header", ErrMalformedPE)
+		}
+		numDirs, dirs = int(le.Uint32(data[opt+108:])), opt+112
+	default:
+		return nil, fmt.Errorf("%w: unknown optional header magic", ErrMalformedPE)
+	}
+	if numDirs <= peSecurityDirectory || dirs+(peSecurityDirectory+1)*8 > opt+optSize {
+		return nil, ErrNotSigned
+	}
+
+	pe := &peFile{
+		data:        data,
+		checksum:    opt + 64,
+		securityDir: dirs + peSecurityDirectory*8,
+		headersSize: int(le.Uint32(data[opt+60:])),
+	}
+	pe.certOffset = int(le.Uint32(data[pe.securityDir:]))
+	pe.certSize = int(le.Uint32(data[pe.securityDir+4:]))
+	if pe.headersSize < pe.securityDir+8 || pe.headersSize > len(data) {
+		return nil, fmt.Errorf("%w: invalid size of headers", ErrMalformedPE)
+	}
+	if pe.certOffset+pe.certSize > len(data) {
+		return nil, fmt.Errorf("%w: certificate table out of bounds", ErrMalformedPE)
+	}
+
+	table := opt + optSize
+	if table+numSections*40 > len(data) {
+		return nil, fmt.Errorf("%w: truncated section table", ErrMalformedPE)
+	}
+	for i := 0; i < numSections; i++ {
+		s := data[table+i*40:]
+		size, offset := int(le.Uint32(s[16:])), int(le.Uint32(s[20:]))
+		if size == 0 {
+			continue
+		}
+		if offset+size > len(data) {
+			return nil, fmt.Errorf("%w: section %d out of bounds", ErrMalformedPE, i)
+		}
+		pe.sections = append(pe.sections, [2]int{offset, size})
+	}
+	sort.Slice(pe.sections, func(i, j int) bool {
+		return pe.sections[i][0] < pe.sections[j][0]
+	})
+
+	return pe, nil
+}
+
+// digest returns Authenticode image hash: the whole file except the checksum, the security
+// directory entry and the certificate table
+func (pe *peFile) digest(hash crypto.Hash) []byte {
+	h := hash.New()
+	h.Write(pe.data[:pe.checksum])
+	h.Write(pe.data[pe.checksum+4 : pe.securityDir])
+	h.Write(pe.data[pe.securityDir+8 : pe.headersSize])
+
+	hashed := pe.headersSize
+	for _, s := range pe.sections {
+		h.Write(pe.data[s[0] : s[0]+s[1]])
+		hashed += s[1]
+	}
+
+	if end := len(pe.data) - pe.certSize; end > hashed {
+		h.Write(pe.data[hashed:end])
+	}
+
+	return h.Sum(nil)
+}
+
+// signatures returns PKCS #7 signatures from the certificate table
+func (pe *peFile) signatures() [][]byte {
+	var result [][]byte
+	table := pe.data[pe.certOffset : pe.certOffset+pe.certSize]
+	for len(table) >= 8 {
+		length := int(binary.LittleEndian.Uint32(table))
+		certType := binary.LittleEndian.Uint16(table[6:])
+		if length < 8 || length > len(table) {
+			break
+		}
+		if certType == winCertTypePKCSSignedData {
+			result = append(result, table[8:length])
+		}
+		// entries are aligned to 8 bytes
+		length = (length + 7) &^ 7
+		if length >= len(table) {
+			break
+		}
+		table = table[length:]
+	}
+	return result
+}
+
+func digestHash(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	switch {
+	case oid.Equal(oidDigestSHA1):
+		return crypto.SHA1, nil
+	case oid.Equal(oidDigestSHA256):
+		return crypto.SHA256, nil
+	case oid.Equal(oidDigestSHA384):
+		return crypto.SHA384, nil
+	case oid.Equal(oidDigestSHA512):
+		return crypto.SHA512, nil
+	}
+	return 0, fmt.Errorf("%w: unsupported digest algorithm %s", ErrInvalidSignature, oid)
+}
+
+func signatureAlgorithm(hash crypto.Hash, encryption asn1.ObjectIdentifier) (x509.SignatureAlgorithm, error) {
+	rsa := encryption.Equal(oidEncryptionRSA) || encryption.Equal(oidRSAWithSHA1) || encryption.Equal(oidRSAWithSHA256) ||
+		encryption.Equal(oidRSAWithSHA384) || encryption.Equal(oidRSAWithSHA512)
+	ecdsa := encryption.Equal(oidEncryptionECDSA) || encryption.Equal(oidECDSAWithSHA256)
+
+	switch {
+	case rsa && hash == crypto.SHA256:
+		return x509.SHA256WithRSA, nil
+	case rsa && hash == crypto.SHA384:
+		return x509.SHA384WithRSA, nil
+	case rsa && hash == crypto.SHA512:
+		return x509.SHA512WithRSA, nil
+	case ecdsa && hash == crypto.SHA256:
+		return x509.ECDSAWithSHA256, nil
+	case ecdsa && hash == crypto.SHA384:
+		return x509.ECDSAWithSHA384, nil
+	case ecdsa && hash == crypto.SHA512:
+		return x509.ECDSAWithSHA512, nil
+	}
+	return x509.UnknownSignatureAlgorithm, fmt.Errorf("%w: unsupported signature algorithm %s with %s", ErrInvalidSignature, encryption, hash)
+}
+
+// verifySignature verifies a single PKCS #7 signature of an image digest against root CAs
+func verifySignature(pe *peFile, der []byte, roots *x509.CertPool) error {
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return fmt.Errorf("%w: not a signed data", ErrInvalidSignature)
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	if !sd.ContentInfo.ContentType.Equal(oidSpcIndirectData) || len(sd.SignerInfos) != 1 {
+		return fmt.Errorf("%w: not an Authenticode signature", ErrInvalidSignature)
+	}
+
+	// content is hashed without the outer tag and length
+	var content asn1.RawValue
+	if _, err := asn1.Unmarshal(sd.ContentInfo.Content.Bytes, &content); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	var spc spcIndirectDataContent
+	if _, err := asn1.Unmarshal(content.FullBytes, &spc); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	// the image itself
+	imageHash, err := digestHash(spc.MessageDigest.Algorithm.Algorithm)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(pe.digest(imageHash), spc.MessageDigest.Digest) {
+		return fmt.Errorf("%w: image digest mismatch", ErrInvalidSignature)
+	}
+
+	// the signer
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	si := sd.SignerInfos[0]
+	var signer *x509.Certificate
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs {
+		if cert.SerialNumber.Cmp(si.IssuerAndSerial.Serial) == 0 && bytes.Equal(cert.RawIssuer, si.IssuerAndSerial.Issuer.FullBytes) {
+			signer = cert
+		} else {
+			intermediates.AddCert(cert)
+		}
+	}
+	if signer == nil {
+		return fmt.Errorf("%w: signer certificate not found", ErrInvalidSignature)
+	}
+
+	hash, err := digestHash(si.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return err
+	}
+	signed := content.Bytes
+	if len(si.AuthenticatedAttributes.FullBytes) > 0 {
+		// attributes are signed as SET OF instead of the implicit tag
+		signed = append([]byte{0x31}, si.AuthenticatedAttributes.FullBytes[1:]...)
+		if err := verifyAttributes(signed, hash, content.Bytes); err != nil {
+			return err
+		}
+	}
+	algo, err := signatureAlgorithm(hash, si.DigestEncryptionAlgorithm.Algorithm)
+	if err != nil {
+		return err
+	}
+	if err := signer.CheckSignature(algo, signed, si.EncryptedDigest); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	// firmware does not check validity period of certificates
+	_, err = signer.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   signer.NotBefore,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	return nil
+}
+
+// verifyAttributes checks content type and message digest of authenticated attributes
+func verifyAttributes(der []byte, hash crypto.Hash, content []byte) error {
+	var attrs []attribute
+	if _, err := asn1.UnmarshalWithParams(der, &attrs, "set"); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	var contentType, messageDigest bool
+	for _, attr := range attrs {
+		switch {
+		case attr.Type.Equal(oidAttrContentType):
+			var oid asn1.ObjectIdentifier
+			if _, err := asn1.Unmarshal(attr.Values.Bytes, &oid); err != nil || !oid.Equal(oidSpcIndirectData) {
+				return fmt.Errorf("%w: content type mismatch", ErrInvalidSignature)
+			}
+			contentType = true
+		case attr.Type.Equal(oidAttrMessageDigest):
+			var digest []byte
+			if _, err := asn1.Unmarshal(attr.Values.Bytes, &digest); err != nil {
+				return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+			}
+			h := hash.New()
+			h.Write(content)
+			if !bytes.Equal(h.Sum(nil), digest) {
+				return fmt.Errorf("%w: message digest mismatch", ErrInvalidSignature)
+			}
+			messageDigest = true
+		}
+	}
+	if !contentType || !messageDigest {
+		return fmt.Errorf("%w: missing authenticated attributes", ErrInvalidSignature)
+	}
+
+	return nil
+}
+
+// VerifyAuthenticode verifies that a PE/COFF (EFI) binary carries an Authenticode signature
+// chaining to one of the root certificates. Binaries with multiple signatures are accepted
+// when any of them is valid.
+func VerifyAuthenticode(data []byte, roots *x509.CertPool) error {
+	pe, err := parsePE(data)
+	if err != nil {
+		return err
+	}
+
+	sigs := pe.signatures()
+	if len(sigs) == 0 {
+		return ErrNotSigned
+	}
+
+	var errs []error
+	for _, sig := range sigs {
+		err := verifySignature(pe, sig, roots)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// VerifySecureBoot verifies signatures of shim and grub EFI binaries of an image, the
+// first failure is returned.
+func VerifySecureBoot(fsys fs.FS, arch string, roots *x509.CertPool) error {
+	shim, grub := efiNames(arch)
+	for _, name := range []string{shim, grub} {
+		file := path.Join("EFI/BOOT", name)
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return fmt.Errorf("cannot read %s: %w", file, err)
+		}
+
+		if err := VerifyAuthenticode(data, roots); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	return nil
+}
+
+// LoadCertPool reads PEM encoded CA certificates
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%w: %s", ErrNoCertificates, file)
+	}
+
+	return pool, nil
+}
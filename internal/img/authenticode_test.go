@@ -0,0 +1,187 @@
+package img
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rawContentInfo is contentInfo with the explicit tag already applied to the content
+type rawContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+type rawSignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      rawContentInfo
+	Certificates     asn1.RawValue
+	SignerInfos      []signerInfo `asn1:"set"`
+}
+
+func explicit(der []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: der}
+}
+
+func testCert(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key
+}
+
+// testPE returns a minimal PE32+ image with one section
+func testPE() []byte {
+	data := make([]byte, 0x400)
+	le := binary.LittleEndian
+	copy(data, "MZ")
+	le.PutUint32(data[0x3c:], 0x40)
+	copy(data[0x40:], "PE\x00\x00")
+	coff := 0x44
+	le.PutUint16(data[coff:], 0x8664)
+	le.PutUint16(data[coff+2:], 1)
+	le.PutUint16(data[coff+16:], 240)
+	opt := coff + 20
+	le.PutUint16(data[opt:], peMagicPE32Plus)
+	le.PutUint32(data[opt+60:], 0x200)
+	le.PutUint32(data[opt+108:], 16)
+	section := opt + 240
+	copy(data[section:], ".text")
+	le.PutUint32(data[section+16:], 0x200)
+	le.PutUint32(data[section+20:], 0x200)
+	copy(data[0x200:], "forester test section")
+
+	return data
+}
+
+// signPE appends Authenticode signature of the image to the certificate table
+func signPE(t *testing.T, data []byte, cert *x509.Certificate, key *ecdsa.PrivateKey) []byte {
+	t.Helper()
+	pe, err := parsePE(data)
+	require.NoError(t, err)
+
+	sha256 := pkix.AlgorithmIdentifier{Algorithm: oidDigestSHA256}
+	content, err := asn1.Marshal(spcIndirectDataContent{
+		Data:          asn1.RawValue{Tag: asn1.TagSequence, IsCompound: true, Bytes: []byte{}},
+		MessageDigest: digestInfo{Algorithm: sha256, Digest: pe.digest(crypto.SHA256)},
+	})
+	require.NoError(t, err)
+	var rawContent asn1.RawValue
+	_, err = asn1.Unmarshal(content, &rawContent)
+	require.NoError(t, err)
+
+	h := crypto.SHA256.New()
+	h.Write(rawContent.Bytes)
+	contentType, _ := asn1.Marshal(oidSpcIndirectData)
+	messageDigest, _ := asn1.Marshal(h.Sum(nil))
+	attrs, err := asn1.MarshalWithParams([]attribute{
+		{Type: oidAttrContentType, Values: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: contentType}},
+		{Type: oidAttrMessageDigest, Values: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: messageDigest}},
+	}, "set")
+	require.NoError(t, err)
+
+	h = crypto.SHA256.New()
+	h.Write(attrs)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, h.Sum(nil))
+	require.NoError(t, err)
+
+	var rawAttrs asn1.RawValue
+	_, err = asn1.Unmarshal(attrs, &rawAttrs)
+	require.NoError(t, err)
+
+	sd, err := asn1.Marshal(rawSignedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256},
+		ContentInfo:      rawContentInfo{ContentType: oidSpcIndirectData, Content: explicit(content)},
+		Certificates:     explicit(cert.Raw),
+		SignerInfos: []signerInfo{{
+			Version:                   1,
+			IssuerAndSerial:           issuerAndSerial{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}, Serial: cert.SerialNumber},
+			DigestAlgorithm:           sha256,
+			AuthenticatedAttributes:   explicit(rawAttrs.Bytes),
+			DigestEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidEncryptionECDSA},
+			EncryptedDigest:           sig,
+		}},
+	})
+	require.NoError(t, err)
+	ci, err := asn1.Marshal(rawContentInfo{ContentType: oidSignedData, Content: explicit(sd)})
+	require.NoError(t, err)
+
+	entry := make([]byte, (8+len(ci)+7)&^7)
+	binary.LittleEndian.PutUint32(entry, uint32(8+len(ci)))
+	binary.LittleEndian.PutUint16(entry[4:], 0x0200)
+	binary.LittleEndian.PutUint16(entry[6:], winCertTypePKCSSignedData)
+	copy(entry[8:], ci)
+
+	signed := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint32(signed[pe.securityDir:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(signed[pe.securityDir+4:], uint32(len(entry)))
+	return append(signed, entry...)
+}
+
+func TestVerifyAuthenticode(t *testing.T) {
+	ca, caKey := testCert(t, "Test Secure Boot CA", nil, nil)
+	signer, signerKey := testCert(t, "Test Secure Boot Signing", ca, caKey)
+	other, _ := testCert(t, "Other CA", nil, nil)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	otherRoots := x509.NewCertPool()
+	otherRoots.AddCert(other)
+
+	signed := signPE(t, testPE(), signer, signerKey)
+	tampered := append([]byte(nil), signed...)
+	tampered[0x210] ^= 0xff
+
+	tests := map[string]struct {
+		data  []byte
+		roots *x509.CertPool
+		err   error
+	}{
+		"signed":   {data: signed, roots: roots},
+		"unsigned": {data: testPE(), roots: roots, err: ErrNotSigned},
+		"tampered": {data: tampered, roots: roots, err: ErrInvalidSignature},
+		"other CA": {data: signed, roots: otherRoots, err: ErrInvalidSignature},
+		"not a PE": {data: []byte("#!/bin/sh"), roots: roots, err: ErrMalformedPE},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := VerifyAuthenticode(tc.data, tc.roots)
+			if tc.err == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package img
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+
+	"forester/internal/config"
+	"forester/internal/fat"
+	"forester/internal/iso9660"
+	"forester/internal/logging"
+	"forester/internal/tmpl"
+)
+
+const (
+	// bootISOVolumeID is searched by grub to find the root
+	bootISOVolumeID = "FORESTER"
+
+	// pcGrubDir contains BIOS grub modules
+	pcGrubDir = "/usr/lib/grub/i386-pc"
+)
+
+var ErrNoGrubMkimage = errors.New("grub2-mkimage not found")
+
+var ErrScriptArch = errors.New("boot.iso script supports only x86_64 images")
+
+// GenerateBootISO generates boot.iso and grubx64.0 (x86_64 only) into imageDir from files
+// extracted into sourceDir. Boot ISO is assembled in-process, the shell script is used when
+// configured. Image kernel arguments are applied on defaults of the boot.iso kernel command line.
+// Progress in percent is reported once boot.iso is written.
+func GenerateBootISO(ctx context.Context, imageID int64, arch string, kernelArgs []string, sourceDir, imageDir string, progress func(int16)) error {
+	wg.Add(1)
+	defer wg.Done()
+
+	if config.Images.BootISOScript {
+		if !hasBIOS(arch) {
+			return fmt.Errorf("%w: %s", ErrScriptArch, arch)
+		}
+		return generateBootISOScript(ctx, imageID, kernelArgs, sourceDir, imageDir)
+	}
+
+	err := buildBootISO(ctx, imageID, arch, kernelArgs, os.DirFS(sourceDir), filepath.Join(imageDir, "boot.iso"))
+	if err != nil {
+		return fmt.Errorf("cannot build boot.iso: %w", err)
+	}
+	progress(80)
+
+	if !hasBIOS(arch) {
+		return nil
+	}
+
+	err = grubMkimage(ctx, "i386-pc-pxe", filepath.Join(imageDir, "grubx64.0"), "/boot/bios/00-00-00-00-00-00/",
+		"tftp", "pxe", "normal", "ls", "echo", "minicmd", "halt", "reboot", "http", "linux")
+	if errors.Is(err, ErrNoGrubMkimage) {
+		slog.WarnContext(ctx, "BIOS PXE image not generated", "err", err)
+	} else if err != nil {
+		return fmt.Errorf("cannot generate grubx64.0: %w", err)
+	}
+
+	return nil
+}
+
+// BuildSystemBootISO writes boot.iso with kernel arguments of a particular system (e.g. static
+// network configuration) into output, files are read from the image file system.
+func BuildSystemBootISO(ctx context.Context, imageID int64, arch string, kernelArgs []string, src fs.FS, output string) error {
+	wg.Add(1)
+	defer wg.Done()
+
+	return buildBootISO(ctx, imageID, arch, kernelArgs, src, output)
+}
+
+// buildBootISO writes hybrid boot ISO with EFI system partition image, BIOS El Torito image
+// is added for x86_64 when grub2-mkimage and BIOS modules are available.
+func buildBootISO(ctx context.Context, imageID int64, arch string, kernelArgs []string, src fs.FS, output string) error {
+	efiCfg := &bytes.Buffer{}
+	err := tmpl.RenderBootISOGrub(ctx, efiCfg, tmpl.BootISOParams{ImageID: imageID, VolumeID: bootISOVolumeID, Arch: arch, KernelArgs: kernelArgs, EFI: true})
+	if err != nil {
+		return err
+	}
+
+	efiboot, err := buildEFIBoot(src, arch, efiCfg.Bytes())
+	if err != nil {
+		return fmt.Errorf("cannot build EFI image: %w", err)
+	}
+
+	w := iso9660.NewWriter(bootISOVolumeID)
+	pxeboot, err := fs.ReadDir(src, "images/pxeboot")
+	if err != nil {
+		return err
+	}
+	for _, e := range pxeboot {
+		if e.Type().IsRegular() {
+			err = w.AddFromFS(path.Join("images/pxeboot", e.Name()), src, path.Join("images/pxeboot", e.Name()))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := fs.Stat(src, "LICENSE"); err == nil {
+		err = w.AddFromFS("LICENSE", src, "LICENSE")
+		if err != nil {
+			return err
+		}
+	}
+	err = w.AddBytes("EFI/BOOT/grub.cfg", efiCfg.Bytes())
+	if err != nil {
+		return err
+	}
+	err = w.AddBytes("images/efiboot.img", efiboot)
+	if err != nil {
+		return err
+	}
+
+	if hasBIOS(arch) {
+		biosCfg := &bytes.Buffer{}
+		err = tmpl.RenderBootISOGrub(ctx, biosCfg, tmpl.BootISOParams{ImageID: imageID, VolumeID: bootISOVolumeID, Arch: arch, KernelArgs: kernelArgs})
+		if err != nil {
+			return err
+		}
+
+		err = addBIOSBoot(ctx, w, biosCfg.Bytes())
+		if err != nil {
+			slog.WarnContext(ctx, "boot.iso will be EFI only", "err", err)
+		}
+	}
+	w.AddBoot(iso9660.BootEntry{Path: "images/efiboot.img", Platform: iso9660.EFIPlatform})
+
+	tmp := output + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	defer f.Close()
+
+	_, err = w.WriteTo(f)
+	if err != nil {
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, output)
+}
+
+// buildEFIBoot returns FAT image of EFI system partition
+func buildEFIBoot(src fs.FS, arch string, grubCfg []byte) ([]byte, error) {
+	shim, grub := efiNames(arch)
+	im := fat.New("")
+	for _, name := range []string{"EFI/BOOT/" + shim, "EFI/BOOT/" + grub, "EFI/BOOT/fonts/unicode.pf2"} {
+		data, err := fs.ReadFile(src, name)
+		if errors.Is(err, fs.ErrNotExist) && path.Base(name) == "unicode.pf2" {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		err = im.AddFile(name, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := im.AddFile("EFI/BOOT/grub.cfg", grubCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	_, err = im.WriteTo(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// addBIOSBoot adds grub El Torito image, BIOS modules and hybrid MBR boot code
+func addBIOSBoot(ctx context.Context, w *iso9660.Writer, grubCfg []byte) error {
+	mbr, err := os.ReadFile(filepath.Join(pcGrubDir, "boot_hybrid.img"))
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp("", "forester-eltorito-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	eltoritoFile := filepath.Join(tmp, "eltorito.img")
+	err = grubMkimage(ctx, "i386-pc-eltorito", eltoritoFile, "/boot/grub2", "iso9660", "biosdisk")
+	if err != nil {
+		return err
+	}
+	eltorito, err := os.ReadFile(eltoritoFile)
+	if err != nil {
+		return err
+	}
+
+	modules, err := os.ReadDir(pcGrubDir)
+	if err != nil {
+		return err
+	}
+	for _, m := range modules {
+		if m.Type().IsRegular() {
+			err = w.AddFromFS(path.Join("boot/grub2/i386-pc", m.Name()), os.DirFS(pcGrubDir), m.Name())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = w.AddBytes("boot/grub2/grub.cfg", grubCfg)
+	if err != nil {
+		return err
+	}
+	err = w.AddBytes("images/eltorito.img", eltorito)
+	if err != nil {
+		return err
+	}
+	w.AddBoot(iso9660.BootEntry{
+		Path:          "images/eltorito.img",
+		Platform:      iso9660.BIOSPlatform,
+		LoadSectors:   4,
+		BootInfoTable: true,
+		Grub2BootInfo: true,
+	})
+	w.SetMBR(mbr)
+
+	return nil
+}
+
+// grubMkimage builds grub image with BIOS modules
+func grubMkimage(ctx context.Context, format, output, prefix string, modules ...string) error {
+	bin, err := exec.LookPath("grub2-mkimage")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNoGrubMkimage, err)
+	}
+
+	args := append([]string{"-O", format, "-d", pcGrubDir, "-o", output, "-p", prefix}, modules...)
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Stdout = logging.SlogWriter{Logger: slog.Default(), Level: slog.LevelDebug, Context: ctx}
+	cmd.Stderr = logging.SlogWriter{Logger: slog.Default(), Level: slog.LevelWarn, Context: ctx}
+
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("error calling `grub2-mkimage`: %w", err)
+	}
+
+	return nil
+}
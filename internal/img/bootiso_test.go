@@ -0,0 +1,57 @@
+package img
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"forester/internal/iso9660"
+)
+
+func TestBuildBootISO(t *testing.T) {
+	src := t.TempDir()
+	_, err := ExtractToDir(context.Background(), "../../fixtures/iso/fixture-netboot.iso", src, BootPaths)
+	require.NoError(t, err)
+
+	// aarch64 tree only differs in EFI binaries
+	for _, name := range []string{"BOOTAA64.EFI", "grubaa64.efi"} {
+		require.NoError(t, os.WriteFile(filepath.Join(src, "EFI/BOOT", name), nil, 0o644))
+	}
+
+	tests := map[string]struct {
+		arch  string
+		linux string
+	}{
+		"x86_64":  {arch: "x86_64", linux: "linuxefi /images/pxeboot/vmlinuz inst.stage2="},
+		"aarch64": {arch: "aarch64", linux: "linux /images/pxeboot/vmlinuz inst.stage2="},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "boot.iso")
+			require.NoError(t, buildBootISO(context.Background(), 42, tc.arch, nil, os.DirFS(src), output))
+
+			f, err := os.Open(output)
+			require.NoError(t, err)
+			defer f.Close()
+			iso, err := iso9660.Open(f)
+			require.NoError(t, err)
+			require.Equal(t, bootISOVolumeID, iso.VolumeID)
+
+			for _, name := range []string{"images/pxeboot/vmlinuz", "images/pxeboot/initrd.img", "images/efiboot.img", "LICENSE"} {
+				_, err := fs.Stat(iso, name)
+				require.NoError(t, err, name)
+			}
+
+			cfg, err := fs.ReadFile(iso, "EFI/BOOT/grub.cfg")
+			require.NoError(t, err)
+			require.Contains(t, string(cfg), "search --no-floppy --set=root -l 'FORESTER'")
+			require.Contains(t, string(cfg), tc.linux)
+			require.Contains(t, string(cfg), "/img/42 ")
+		})
+	}
+}
@@ -2,23 +2,34 @@ package img
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 )
 
-func Copy(ctx context.Context, destFile string, reader io.Reader) (int64, string, error) {
-	file, err := os.OpenFile(destFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+// Copy writes data from reader into destFile starting at offset, existing data after the offset
+// are discarded. Returns number of bytes written.
+func Copy(ctx context.Context, destFile string, offset int64, reader io.Reader) (int64, error) {
+	file, err := os.OpenFile(destFile, os.O_WRONLY|os.O_CREATE, 0644)
 	if err != nil {
-		return 0, "", fmt.Errorf("cannot open image: %w", err)
+		return 0, fmt.Errorf("cannot open image: %w", err)
 	}
 	defer file.Close()
 
-	h := sha256.New()
-	tee := io.TeeReader(reader, h)
+	err = file.Truncate(offset)
+	if err != nil {
+		return 0, fmt.Errorf("cannot truncate image: %w", err)
+	}
+
+	_, err = file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return 0, fmt.Errorf("cannot seek image: %w", err)
+	}
+
+	nBytes, err := io.Copy(file, reader)
+	if err != nil {
+		return nBytes, fmt.Errorf("cannot write image: %w", err)
+	}
 
-	nBytes, err := io.Copy(file, tee)
-	return nBytes, hex.EncodeToString(h.Sum(nil)), err
+	return nBytes, file.Sync()
 }
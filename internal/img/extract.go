@@ -0,0 +1,214 @@
+package img
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+
+	"forester/internal/iso9660"
+)
+
+// ServedPaths are files and directories of an ISO which are served, anaconda stage2 needs
+// install.img and .treeinfo, boot.iso generator uses LICENSE.
+var ServedPaths = []string{
+	"images/pxeboot",
+	"images/install.img",
+	"EFI/BOOT",
+	"liveimg.tar.gz",
+	"container",
+	".discinfo",
+	".treeinfo",
+	"LICENSE",
+}
+
+// BootPaths are files and directories needed to generate boot.iso
+var BootPaths = []string{
+	"images/pxeboot",
+	"EFI/BOOT",
+	"LICENSE",
+}
+
+// checksumPaths are files which SHA256 sums are computed during extraction
+var checksumPaths = []string{
+	"liveimg.tar.gz",
+	"container/index.json",
+}
+
+// ExtractToDir extracts files and directories of an ISO image into a directory and returns
+// SHA256 sums of extracted liveimg.tar.gz and container/index.json. The in-process reader is
+// used, xorriso is a fallback for images it cannot read and it extracts the whole image.
+func ExtractToDir(ctx context.Context, isoFile, outputDir string, paths []string) (map[string]string, error) {
+	wg.Add(1)
+	defer wg.Done()
+
+	sums, err := extractISO(ctx, isoFile, outputDir, paths)
+	if err == nil || ctx.Err() != nil {
+		return sums, err
+	}
+
+	slog.WarnContext(ctx, "cannot extract ISO image, falling back to xorriso", "file", isoFile, "err", err)
+	err = extractXorriso(ctx, isoFile, outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return Checksums(ctx, os.DirFS(outputDir))
+}
+
+func extractISO(ctx context.Context, isoFile, outputDir string, paths []string) (map[string]string, error) {
+	f, err := os.Open(isoFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open ISO image: %w", err)
+	}
+	defer f.Close()
+
+	iso, err := iso9660.Open(f)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read ISO image: %w", err)
+	}
+
+	sums := make(map[string]string)
+	for _, root := range paths {
+		_, err := iso.Lstat(root)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		err = fs.WalkDir(iso, root, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			dest := filepath.Join(outputDir, filepath.FromSlash(name))
+			switch {
+			case d.IsDir():
+				return os.MkdirAll(dest, 0755)
+			case d.Type()&fs.ModeSymlink != 0:
+				target, err := iso.ReadLink(name)
+				if err != nil {
+					return err
+				}
+				if path.IsAbs(target) || !fs.ValidPath(path.Join(path.Dir(name), target)) {
+					slog.WarnContext(ctx, "skipping symbolic link outside of the image", "file", name, "target", target)
+					return nil
+				}
+				err = os.MkdirAll(filepath.Dir(dest), 0755)
+				if err != nil {
+					return err
+				}
+				err = os.Remove(dest)
+				if err != nil && !errors.Is(err, fs.ErrNotExist) {
+					return err
+				}
+				return os.Symlink(target, dest)
+			default:
+				sum, err := extractFile(iso, name, dest)
+				if err != nil {
+					return err
+				}
+				if sum != "" {
+					slog.DebugContext(ctx, "calculated SHA256", "file", name, "sha256sum", sum)
+					sums[name] = sum
+				}
+				return nil
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot extract %s: %w", root, err)
+		}
+	}
+
+	return sums, nil
+}
+
+// extractFile copies a file from the image, SHA256 sum is returned for checksumPaths.
+func extractFile(iso fs.FS, name, dest string) (string, error) {
+	src, err := iso.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	err = os.MkdirAll(filepath.Dir(dest), 0755)
+	if err != nil {
+		return "", err
+	}
+
+	dst, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer dst.Close()
+
+	var h hash.Hash
+	var w io.Writer = dst
+	for _, p := range checksumPaths {
+		if p == path.Clean(name) {
+			h = sha256.New()
+			w = io.MultiWriter(dst, h)
+		}
+	}
+
+	_, err = io.Copy(w, src)
+	if err != nil {
+		return "", err
+	}
+
+	err = dst.Close()
+	if err != nil {
+		return "", err
+	}
+
+	if h == nil {
+		return "", nil
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Checksums returns SHA256 sums of liveimg.tar.gz and container/index.json present in a file
+// system.
+func Checksums(ctx context.Context, fsys fs.FS) (map[string]string, error) {
+	sums := make(map[string]string)
+	for _, name := range checksumPaths {
+		f, err := fsys.Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		sum, err := readSha256(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s: %w", name, err)
+		}
+		slog.DebugContext(ctx, "calculated SHA256", "file", name, "sha256sum", sum)
+		sums[name] = sum
+	}
+
+	return sums, nil
+}
+
+func readSha256(r io.Reader) (string, error) {
+	h := sha256.New()
+	_, err := io.Copy(h, r)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,56 @@
+package img
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const emptySha256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+func TestExtractToDir(t *testing.T) {
+	tests := []struct {
+		file    string
+		sums    map[string]string
+		present []string
+		absent  []string
+	}{
+		{
+			file:    "fixture-netboot.iso",
+			sums:    map[string]string{},
+			present: []string{"images/pxeboot/vmlinuz", "EFI/BOOT/grubx64.efi", ".discinfo", "LICENSE"},
+			absent:  []string{"boot", "Fedora-Legal-README.txt", "images/eltorito.img"},
+		},
+		{
+			file:    "fixture-liveimg.iso",
+			sums:    map[string]string{"liveimg.tar.gz": emptySha256},
+			present: []string{"liveimg.tar.gz", "images/pxeboot/initrd.img"},
+		},
+		{
+			file:    "fixture-container.iso",
+			sums:    map[string]string{"container/index.json": emptySha256},
+			present: []string{"container/index.json", "container/oci-layout"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			dir := t.TempDir()
+			sums, err := ExtractToDir(context.Background(), filepath.Join("../../fixtures/iso", tt.file), dir, ServedPaths)
+			require.NoError(t, err)
+			require.Equal(t, tt.sums, sums)
+
+			for _, name := range tt.present {
+				_, err := os.Stat(filepath.Join(dir, name))
+				require.NoError(t, err, name)
+			}
+			for _, name := range tt.absent {
+				_, err := os.Stat(filepath.Join(dir, name))
+				require.ErrorIs(t, err, os.ErrNotExist, name)
+			}
+		})
+	}
+}
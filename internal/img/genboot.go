@@ -1,19 +1,50 @@
 package img
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 
 	"forester/internal/logging"
+	"forester/internal/model"
+	"forester/internal/tmpl"
 )
 
-func GenerateBootISO(ctx context.Context, imageID int64, imageDir string) error {
-	wg.Add(1)
-	defer wg.Done()
+// generateBootISOScript generates boot.iso and grubx64.0 via shell script, it needs mtools,
+// grub2-mkimage, xorrisofs and syslinux. Grub configurations are rendered from the same
+// template as the built-in writer uses.
+func generateBootISOScript(ctx context.Context, imageID int64, kernelArgs []string, sourceDir, imageDir string) error {
+	cfgDir, err := os.MkdirTemp("", "forester-grubcfg-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(cfgDir)
+
+	p := BootISOParams{
+		SourceDir:   sourceDir,
+		ImageDir:    imageDir,
+		BIOSGrubCfg: filepath.Join(cfgDir, "bios.cfg"),
+		EFIGrubCfg:  filepath.Join(cfgDir, "efi.cfg"),
+	}
+	for _, cfg := range []struct {
+		file string
+		efi  bool
+	}{{p.BIOSGrubCfg, false}, {p.EFIGrubCfg, true}} {
+		buf := &bytes.Buffer{}
+		err = tmpl.RenderBootISOGrub(ctx, buf, tmpl.BootISOParams{ImageID: imageID, VolumeID: bootISOVolumeID, Arch: model.X86_64Arch, KernelArgs: kernelArgs, EFI: cfg.efi})
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(cfg.file, buf.Bytes(), 0644)
+		if err != nil {
+			return err
+		}
+	}
 
 	cmd := exec.CommandContext(ctx, "/usr/bin/bash")
 	cmd.Stdout = logging.SlogWriter{Logger: slog.Default(), Level: slog.LevelDebug, Context: ctx}
@@ -22,7 +53,7 @@ func GenerateBootISO(ctx context.Context, imageID int64, imageDir string) error
 	if err != nil {
 		return fmt.Errorf("error opening stdin of shell: %w", err)
 	}
-	err = renderGenerateBootISO(ctx, stdin, imageID, imageDir)
+	err = renderGenerateBootISO(ctx, stdin, p)
 	if err != nil {
 		return fmt.Errorf("error rendering boot.iso generator script template: %w", err)
 	}
@@ -35,7 +66,7 @@ func GenerateBootISO(ctx context.Context, imageID int64, imageDir string) error
 		if ferr == nil {
 			defer f.Close()
 			slog.WarnContext(ctx, "writing failed generate ISO script", "file", script, "err", err)
-			renderGenerateBootISO(ctx, f, imageID, imageDir)
+			renderGenerateBootISO(ctx, f, p)
 		}
 		return fmt.Errorf("error calling ISO generator script: %w", err)
 	}
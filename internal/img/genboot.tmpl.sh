@@ -1,6 +1,6 @@
 #!/bin/bash
 set -xe
-SRCDIR="{{ .ImageDir }}"
+SRCDIR="{{ .SourceDir }}"
 DSTDIR="{{ .ImageDir }}"
 
 TROOT=$(mktemp -d /tmp/forester-troot-XXXXXXX)
@@ -12,46 +12,11 @@ PCGRUBDIR=/usr/lib/grub/i386-pc
 
 mkdir -p $TROOT/images/pxeboot $TROOT/boot/grub2/i386-pc $TROOT/EFI/BOOT
 cp $SRCDIR/images/pxeboot/* $TROOT/images/pxeboot
-cp $SRCDIR/EFI/BOOT/grub.cfg $TROOT/EFI/BOOT
 cp $PCGRUBDIR/* $TROOT/boot/grub2/i386-pc
 (test -f $SRCDIR/LICENSE && cp $SRCDIR/LICENSE $TROOT) || true
 
-cat >$TROOT/boot/grub2/grub.cfg <<EOBIOS
-# Generated by FORESTER (BIOS) version {{ .Version }}
-function load_video {
-  insmod all_video
-}
-load_video
-set gfxpayload=keep
-insmod gzio
-insmod part_gpt
-insmod ext2
-insmod chain
-search --no-floppy --set=root -l 'FORESTER'
-linux /images/pxeboot/vmlinuz inst.stage2={{ .BaseURL }}/img/{{ .ImageID }} ip=dhcp inst.text inst.sshd inst.ks.sendmac inst.ks={{ .BaseURL }}/ks inst.syslog={{ .BaseHost }}:{{ .SyslogPort }} systemd.hostname=f-${net_default_mac}
-initrd /images/pxeboot/initrd.img
-boot
-EOBIOS
-
-cat >$TROOT/EFI/BOOT/grub.cfg <<EOEFI
-# Generated by FORESTER (EFI) version {{ .Version }}
-function load_video {
-  insmod efi_gop
-  insmod efi_uga
-  insmod video_bochs
-  insmod video_cirrus
-  insmod all_video
-}
-load_video
-set gfxpayload=keep
-insmod gzio
-insmod part_gpt
-insmod ext2
-search --no-floppy --set=root -l 'FORESTER'
-linuxefi /images/pxeboot/vmlinuz inst.stage2={{ .BaseURL }}/img/{{ .ImageID }} ip=dhcp inst.text inst.sshd inst.ks.sendmac inst.ks={{ .BaseURL }}/ks inst.syslog={{ .BaseHost }}:{{ .SyslogPort }} systemd.hostname=f-${net_default_mac}
-initrdefi /images/pxeboot/initrd.img
-boot
-EOEFI
+cp "{{ .BIOSGrubCfg }}" $TROOT/boot/grub2/grub.cfg
+cp "{{ .EFIGrubCfg }}" $TROOT/EFI/BOOT/grub.cfg
 
 truncate -s 8M $TAUX/efiboot.img
 mkfs.vfat $TAUX/efiboot.img
@@ -0,0 +1,75 @@
+package img
+
+import (
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Image metadata keys
+const (
+	DistroMeta    = "distro"
+	VersionMeta   = "version"
+	ArchMeta      = "arch"
+	VariantMeta   = "variant"
+	BuildTimeMeta = "build_time"
+	MediaMeta     = "media"
+)
+
+// DetectMeta returns metadata of an image from .treeinfo, .discinfo and media.repo files.
+// Only detected keys are present, the map is empty when no metadata file exists.
+func DetectMeta(fsys fs.FS) map[string]string {
+	result := make(map[string]string)
+	set := func(key, value string) {
+		if value = strings.TrimSpace(value); value != "" && result[key] == "" {
+			result[key] = value
+		}
+	}
+
+	// productmd 1.x sections first, legacy [general] section as fallback
+	ti := readTreeinfo(fsys)
+	set(DistroMeta, ti.get("release", "name"))
+	set(DistroMeta, ti.get("general", "family"))
+	set(VersionMeta, ti.get("release", "version"))
+	set(VersionMeta, ti.get("general", "version"))
+	set(VariantMeta, ti.get("tree", "variants"))
+	set(VariantMeta, ti.get("general", "variant"))
+	set(BuildTimeMeta, unixTime(ti.get("tree", "build_timestamp")))
+	set(BuildTimeMeta, unixTime(ti.get("general", "timestamp")))
+
+	// .discinfo: timestamp, release, architecture
+	if data, err := fs.ReadFile(fsys, ".discinfo"); err == nil {
+		lines := strings.Split(string(data), "\n")
+		if len(lines) >= 1 {
+			set(BuildTimeMeta, unixTime(lines[0]))
+		}
+		if len(lines) >= 2 {
+			set(VersionMeta, lines[1])
+		}
+	}
+
+	// media.repo: repository name of installation media
+	if data, err := fs.ReadFile(fsys, "media.repo"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if key, value, ok := strings.Cut(line, "="); ok && strings.TrimSpace(key) == "name" {
+				set(MediaMeta, value)
+			}
+		}
+	}
+
+	set(ArchMeta, DetectArch(fsys))
+
+	return result
+}
+
+// unixTime converts unix timestamp (can be fractional) to RFC3339, blank string is returned
+// for invalid values
+func unixTime(s string) string {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || f <= 0 {
+		return ""
+	}
+
+	return time.Unix(int64(f), 0).UTC().Format(time.RFC3339)
+}
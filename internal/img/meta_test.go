@@ -0,0 +1,50 @@
+package img
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectMeta(t *testing.T) {
+	tests := map[string]struct {
+		files fstest.MapFS
+		want  map[string]string
+	}{
+		"rhel": {
+			files: fstest.MapFS{
+				".treeinfo": {Data: []byte("[general]\nfamily = Red Hat Enterprise Linux\nversion = 9.4\narch = x86_64\ntimestamp = 1712265637\nvariant = BaseOS\n\n" +
+					"[release]\nname = Red Hat Enterprise Linux\nshort = RHEL\nversion = 9.4\n\n[tree]\narch = x86_64\nbuild_timestamp = 1712265637\nvariants = AppStream,BaseOS\n")},
+				".discinfo":  {Data: []byte("1712265637.123456\n9.4\nx86_64\n")},
+				"media.repo": {Data: []byte("[InstallMedia]\nname=Red Hat Enterprise Linux 9.4.0\nmediaid=None\nmetadata_expire=-1\n")},
+			},
+			want: map[string]string{
+				"distro":     "Red Hat Enterprise Linux",
+				"version":    "9.4",
+				"arch":       "x86_64",
+				"variant":    "AppStream,BaseOS",
+				"build_time": "2024-04-04T21:20:37Z",
+				"media":      "Red Hat Enterprise Linux 9.4.0",
+			},
+		},
+		"discinfo only": {
+			files: fstest.MapFS{".discinfo": {Data: []byte("1712265637.123456\nFedora 40\naarch64\n")}},
+			want: map[string]string{
+				"version":    "Fedora 40",
+				"arch":       "aarch64",
+				"build_time": "2024-04-04T21:20:37Z",
+			},
+		},
+		"none": {
+			files: fstest.MapFS{},
+			want:  map[string]string{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.want, DetectMeta(tc.files))
+		})
+	}
+}
@@ -5,11 +5,7 @@ import (
 	"embed"
 	"fmt"
 	"io"
-	"strconv"
 	"text/template"
-
-	"forester/internal/config"
-	"forester/internal/version"
 )
 
 //go:embed *.tmpl.*
@@ -19,31 +15,24 @@ var templates *template.Template
 
 func init() {
 	var err error
-	templates, err = template.ParseFS(templatesFS, "*.tmpl.*")
+	templates, err = template.New("").ParseFS(templatesFS, "*.tmpl.*")
 	if err != nil {
 		panic(err)
 	}
 }
 
 type BootISOParams struct {
-	BaseHost   string
-	BaseURL    string
-	SyslogPort string
-	Version    string
-	ImageID    int64
-	ImageDir   string
+	SourceDir string
+	ImageDir  string
+
+	// BIOSGrubCfg and EFIGrubCfg are paths to grub configurations rendered from the shared
+	// boot.iso template
+	BIOSGrubCfg string
+	EFIGrubCfg  string
 }
 
 // Generates BIOS/EFI common boot ISO: https://fedoraproject.org/wiki/Changes/BIOSBootISOWithGrub2
-func renderGenerateBootISO(ctx context.Context, w io.Writer, imgID int64, imgDir string) error {
-	p := BootISOParams{
-		BaseHost:   config.BaseHost(),
-		BaseURL:    config.BaseURL(),
-		Version:    version.BuildTag,
-		SyslogPort: strconv.Itoa(config.Application.SyslogPort),
-		ImageID:    imgID,
-		ImageDir:   imgDir,
-	}
+func renderGenerateBootISO(ctx context.Context, w io.Writer, p BootISOParams) error {
 	err := templates.ExecuteTemplate(w, "genboot.tmpl.sh", p)
 	if err != nil {
 		return fmt.Errorf("error executing template: %w", err)
@@ -0,0 +1,73 @@
+package img
+
+import (
+	"bufio"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// treeinfo is parsed .treeinfo file: section name to key/value pairs
+type treeinfo map[string]map[string]string
+
+// readTreeinfo parses .treeinfo INI file of an installation tree, nil is returned when
+// the file does not exist or cannot be read.
+func readTreeinfo(fsys fs.FS) treeinfo {
+	data, err := fs.ReadFile(fsys, ".treeinfo")
+	if err != nil {
+		return nil
+	}
+
+	result := make(treeinfo)
+	var section string
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(strings.Trim(line, "[]"))
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			if result[section] == nil {
+				result[section] = make(map[string]string)
+			}
+			result[section][strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	return result
+}
+
+// get returns value of a key in a section, blank string when not present
+func (ti treeinfo) get(section, key string) string {
+	return ti[section][key]
+}
+
+// IsInstallTree returns true when the image is an Anaconda installation tree (.treeinfo)
+func IsInstallTree(fsys fs.FS) bool {
+	_, err := fs.Stat(fsys, ".treeinfo")
+	return err == nil
+}
+
+// HasRepository returns true when the image contains a package repository, either in the
+// root directory or in variant directories listed in .treeinfo (e.g. BaseOS and AppStream).
+func HasRepository(fsys fs.FS) bool {
+	if _, err := fs.Stat(fsys, "repodata/repomd.xml"); err == nil {
+		return true
+	}
+
+	for section, values := range readTreeinfo(fsys) {
+		repo, ok := values["repository"]
+		if !ok || !strings.HasPrefix(section, "variant-") || !fs.ValidPath(repo) {
+			continue
+		}
+		if _, err := fs.Stat(fsys, path.Join(repo, "repodata/repomd.xml")); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
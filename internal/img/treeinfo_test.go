@@ -0,0 +1,54 @@
+package img
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasRepository(t *testing.T) {
+	treeinfo := []byte("[general]\nvariants = BaseOS,AppStream\n\n[variant-BaseOS]\nrepository = BaseOS\n\n[variant-AppStream]\nrepository = AppStream\n")
+	tests := map[string]struct {
+		files fstest.MapFS
+		tree  bool
+		want  bool
+	}{
+		"netinstall": {
+			files: fstest.MapFS{".treeinfo": {Data: []byte("[general]\narch = x86_64\n\n[variant-BaseOS]\nrepository = .\n")}},
+			tree:  true,
+			want:  false,
+		},
+		"dvd variants": {
+			files: fstest.MapFS{
+				".treeinfo":                       {Data: treeinfo},
+				"AppStream/repodata/repomd.xml":   {},
+				"AppStream/Packages/bash-5.2.rpm": {},
+			},
+			tree: true,
+			want: true,
+		},
+		"root repository": {
+			files: fstest.MapFS{".treeinfo": {Data: []byte("[general]\n")}, "repodata/repomd.xml": {}},
+			tree:  true,
+			want:  true,
+		},
+		"invalid path": {
+			files: fstest.MapFS{".treeinfo": {Data: []byte("[variant-BaseOS]\nrepository = ../BaseOS\n")}},
+			tree:  true,
+			want:  false,
+		},
+		"not a tree": {
+			files: fstest.MapFS{"liveimg.tar.gz": {}},
+			tree:  false,
+			want:  false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.tree, IsInstallTree(tc.files))
+			require.Equal(t, tc.want, HasRepository(tc.files))
+		})
+	}
+}
@@ -13,10 +13,8 @@ import (
 
 var wg sync.WaitGroup
 
-func ExtractToDir(ctx context.Context, isoFile, outputDir string) error {
-	wg.Add(1)
-	defer wg.Done()
-
+// extractXorriso extracts the whole ISO image using xorriso
+func extractXorriso(ctx context.Context, isoFile, outputDir string) error {
 	cmd := exec.CommandContext(ctx,
 		"/usr/bin/xorriso",
 		"-osirrox", "on",
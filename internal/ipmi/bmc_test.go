@@ -0,0 +1,190 @@
+package ipmi
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+)
+
+// fakeBMC is an in-process BMC implementing RMCP+ session setup with cipher suite 3
+// and a few chassis commands.
+type fakeBMC struct {
+	t        *testing.T
+	conn     *net.UDPConn
+	username string
+	password string
+
+	mu         sync.Mutex
+	powerOn    bool
+	bootFlags  []byte
+	actions    []ChassisControl
+	privilege  Privilege
+	closed     bool
+	dropFirst  int
+	unanswered int
+
+	hs       handshake
+	keys     *sessionKeys
+	sequence uint32
+}
+
+func newFakeBMC(t *testing.T, username, password string) *fakeBMC {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &fakeBMC{t: t, conn: conn, username: username, password: password}
+	go b.serve()
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return b
+}
+
+func (b *fakeBMC) addr() string {
+	return b.conn.LocalAddr().String()
+}
+
+func (b *fakeBMC) serve() {
+	buf := make([]byte, 1024)
+	for {
+		n, addr, err := b.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+
+		b.mu.Lock()
+		if b.dropFirst > 0 {
+			b.dropFirst--
+			b.unanswered++
+			b.mu.Unlock()
+			continue
+		}
+		reply := b.handle(buf[:n])
+		b.mu.Unlock()
+
+		if reply != nil {
+			_, _ = b.conn.WriteToUDP(reply, addr)
+		}
+	}
+}
+
+func (b *fakeBMC) send(pt payloadType, payload []byte, keys *sessionKeys) []byte {
+	p := packet{payloadType: pt, payload: payload}
+	if keys != nil {
+		b.sequence++
+		p.sessionID = b.hs.consoleID
+		p.sequence = b.sequence
+	}
+	out, err := p.marshal(keys)
+	if err != nil {
+		b.t.Error(err)
+	}
+	return out
+}
+
+func (b *fakeBMC) handle(in []byte) []byte {
+	p, err := unmarshalPacket(in, b.keys)
+	if err != nil {
+		b.t.Errorf("fake BMC received invalid packet: %v", err)
+		return nil
+	}
+
+	switch p.payloadType {
+	case payloadOpenSessionRq:
+		b.hs = handshake{consoleID: binary.LittleEndian.Uint32(p.payload[4:8]), managedID: 0x0a0b0c0d}
+		b.keys = nil
+		rs := []byte{p.payload[0], rakpStatusOK, p.payload[1], 0}
+		rs = binary.LittleEndian.AppendUint32(rs, b.hs.consoleID)
+		rs = binary.LittleEndian.AppendUint32(rs, b.hs.managedID)
+		rs = append(rs, p.payload[8:]...)
+		return b.send(payloadOpenSessionRs, rs, nil)
+	case payloadRAKP1:
+		copy(b.hs.rm[:], p.payload[8:24])
+		b.hs.role = p.payload[24]
+		b.hs.username = append([]byte(nil), p.payload[28:28+int(p.payload[27])]...)
+		if string(b.hs.username) != b.username {
+			// unauthorized name
+			return b.send(payloadRAKP2, []byte{p.payload[0], 0x0d, 0, 0, 0, 0, 0, 0}, nil)
+		}
+		b.hs.kuid = []byte(b.password)
+		_, _ = rand.Read(b.hs.rc[:])
+		_, _ = rand.Read(b.hs.guid[:])
+		rs := []byte{p.payload[0], rakpStatusOK, 0, 0}
+		rs = binary.LittleEndian.AppendUint32(rs, b.hs.consoleID)
+		rs = append(rs, b.hs.rc[:]...)
+		rs = append(rs, b.hs.guid[:]...)
+		rs = append(rs, b.hs.rakp2AuthCode()...)
+		return b.send(payloadRAKP2, rs, nil)
+	case payloadRAKP3:
+		if !hmac.Equal(p.payload[8:28], b.hs.rakp3AuthCode()) {
+			// invalid integrity check value
+			return b.send(payloadRAKP4, []byte{p.payload[0], 0x0f, 0, 0, 0, 0, 0, 0}, nil)
+		}
+		sik := b.hs.sik()
+		rs := []byte{p.payload[0], rakpStatusOK, 0, 0}
+		rs = binary.LittleEndian.AppendUint32(rs, b.hs.consoleID)
+		rs = append(rs, b.hs.rakp4IntegrityCheck(sik)...)
+		out := b.send(payloadRAKP4, rs, nil)
+		b.keys = deriveKeys(sik)
+		b.privilege = UserPrivilege
+		return out
+	case payloadIPMI:
+		if b.keys == nil || p.sessionID != b.hs.managedID {
+			b.t.Errorf("fake BMC received message outside of session")
+			return nil
+		}
+		return b.send(payloadIPMI, b.command(p.payload), b.keys)
+	}
+
+	b.t.Errorf("fake BMC received unexpected payload type 0x%02x", p.payloadType)
+	return nil
+}
+
+func (b *fakeBMC) command(rq []byte) []byte {
+	if checksum(rq[:2]) != rq[2] || checksum(rq[3:len(rq)-1]) != rq[len(rq)-1] {
+		b.t.Errorf("fake BMC received request with invalid checksum")
+	}
+	netFn, seq, cmd, data := rq[1]>>2, rq[4]>>2, rq[5], rq[6:len(rq)-1]
+
+	var code byte
+	var rsData []byte
+	switch {
+	case netFn == netFnApp && cmd == cmdSetSessionPrivilege:
+		b.privilege = Privilege(data[0])
+		rsData = []byte{data[0]}
+	case netFn == netFnApp && cmd == cmdCloseSession:
+		b.closed = true
+	case netFn == netFnChassis && cmd == cmdGetChassisStatus:
+		var state byte
+		if b.powerOn {
+			state = 0x01
+		}
+		rsData = []byte{state, 0, 0}
+	case netFn == netFnChassis && cmd == cmdChassisControl:
+		if b.privilege < OperatorPrivilege {
+			code = 0xd4
+			break
+		}
+		action := ChassisControl(data[0])
+		b.actions = append(b.actions, action)
+		switch action {
+		case PowerUp, PowerCycle, HardReset:
+			b.powerOn = true
+		case PowerDown, SoftShutdown:
+			b.powerOn = false
+		}
+	case netFn == netFnChassis && cmd == cmdSetSystemBootOptions:
+		b.bootFlags = append([]byte(nil), data...)
+	default:
+		code = 0xc1
+	}
+
+	rs := []byte{consoleAddress, (netFn | 1) << 2, 0, bmcAddress, seq << 2, cmd, code}
+	rs[2] = checksum(rs[:2])
+	rs = append(rs, rsData...)
+	return append(rs, checksum(rs[3:]))
+}
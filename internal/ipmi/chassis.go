@@ -0,0 +1,132 @@
+package ipmi
+
+import (
+	"context"
+	"fmt"
+)
+
+// Network functions
+const (
+	netFnChassis = 0x00
+	netFnApp     = 0x06
+)
+
+// Commands
+const (
+	cmdGetChassisStatus     = 0x01
+	cmdChassisControl       = 0x02
+	cmdSetSystemBootOptions = 0x08
+	cmdSetSessionPrivilege  = 0x3b
+	cmdCloseSession         = 0x3c
+)
+
+// ChassisControl is a power action of the Chassis Control command
+type ChassisControl byte
+
+const (
+	PowerDown    ChassisControl = 0x00
+	PowerUp      ChassisControl = 0x01
+	PowerCycle   ChassisControl = 0x02
+	HardReset    ChassisControl = 0x03
+	SoftShutdown ChassisControl = 0x05
+)
+
+func (cc ChassisControl) String() string {
+	switch cc {
+	case PowerDown:
+		return "power down"
+	case PowerUp:
+		return "power up"
+	case PowerCycle:
+		return "power cycle"
+	case HardReset:
+		return "hard reset"
+	case SoftShutdown:
+		return "soft shutdown"
+	}
+	return fmt.Sprintf("0x%02x", byte(cc))
+}
+
+// BootDevice is a boot device selector of boot flags parameter
+type BootDevice byte
+
+const (
+	NoOverrideBootDevice BootDevice = 0x00
+	PXEBootDevice        BootDevice = 0x04
+	DiskBootDevice       BootDevice = 0x08
+	CDROMBootDevice      BootDevice = 0x14
+	BIOSSetupBootDevice  BootDevice = 0x18
+)
+
+func (bd BootDevice) String() string {
+	switch bd {
+	case NoOverrideBootDevice:
+		return "none"
+	case PXEBootDevice:
+		return "pxe"
+	case DiskBootDevice:
+		return "disk"
+	case CDROMBootDevice:
+		return "cdrom"
+	case BIOSSetupBootDevice:
+		return "bios"
+	}
+	return fmt.Sprintf("0x%02x", byte(bd))
+}
+
+// Boot flags parameter (5) of system boot options
+const (
+	bootOptionBootFlags = 0x05
+	bootFlagValid       = 0x80
+	bootFlagPersistent  = 0x40
+	bootFlagEFI         = 0x20
+)
+
+// SetBootDevice sets boot device for the next boot only.
+func (c *Client) SetBootDevice(ctx context.Context, device BootDevice, efi bool) error {
+	flags := byte(bootFlagValid)
+	if efi {
+		flags |= bootFlagEFI
+	}
+
+	_, err := c.Command(ctx, netFnChassis, cmdSetSystemBootOptions, []byte{bootOptionBootFlags, flags, byte(device), 0, 0, 0})
+	if err != nil {
+		return fmt.Errorf("cannot set boot device %s: %w", device, err)
+	}
+
+	return nil
+}
+
+// ChassisControl performs a power action.
+func (c *Client) ChassisControl(ctx context.Context, action ChassisControl) error {
+	_, err := c.Command(ctx, netFnChassis, cmdChassisControl, []byte{byte(action)})
+	if err != nil {
+		return fmt.Errorf("cannot perform %s: %w", action, err)
+	}
+
+	return nil
+}
+
+type ChassisStatus struct {
+	// PowerOn is true when system power is on
+	PowerOn bool
+
+	// PowerFault is true when power fault was detected
+	PowerFault bool
+}
+
+// ChassisStatus returns current power status.
+func (c *Client) ChassisStatus(ctx context.Context) (*ChassisStatus, error) {
+	data, err := c.Command(ctx, netFnChassis, cmdGetChassisStatus, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get chassis status: %w", err)
+	}
+	if len(data) < 3 {
+		return nil, fmt.Errorf("%w: short chassis status", ErrMalformedPacket)
+	}
+
+	return &ChassisStatus{
+		PowerOn:    data[0]&0x01 != 0,
+		PowerFault: data[0]&0x08 != 0,
+	}, nil
+}
@@ -0,0 +1,357 @@
+// Package ipmi implements a minimal IPMI v2.0 over LAN (RMCP+) client, only commands
+// needed for boot device selection and chassis power control are available.
+package ipmi
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Privilege is a session privilege level
+type Privilege byte
+
+const (
+	CallbackPrivilege      Privilege = 0x01
+	UserPrivilege          Privilege = 0x02
+	OperatorPrivilege      Privilege = 0x03
+	AdministratorPrivilege Privilege = 0x04
+)
+
+// ParsePrivilege parses privilege name as used by ipmitool (-L option).
+func ParsePrivilege(s string) (Privilege, error) {
+	switch strings.ToLower(s) {
+	case "callback":
+		return CallbackPrivilege, nil
+	case "user":
+		return UserPrivilege, nil
+	case "operator":
+		return OperatorPrivilege, nil
+	case "", "administrator", "admin":
+		return AdministratorPrivilege, nil
+	}
+	return 0, fmt.Errorf("%w: %s", ErrUnknownPrivilege, s)
+}
+
+var ErrUnknownPrivilege = errors.New("unknown privilege level")
+
+// DefaultPort is the standard RMCP port
+const DefaultPort = 623
+
+type Config struct {
+	// Address in host:port form.
+	Address string
+
+	// Username and Password (up to 20 bytes) used for RAKP authentication.
+	Username string
+	Password string
+
+	// Privilege requested for the session, defaults to administrator.
+	Privilege Privilege
+
+	// Timeout of a single request attempt, defaults to two seconds.
+	Timeout time.Duration
+
+	// Retries is number of resends of unanswered requests, defaults to three.
+	Retries int
+}
+
+// Client is an established RMCP+ session with a BMC. Session uses cipher suite 3:
+// RAKP-HMAC-SHA1 authentication, HMAC-SHA1-96 integrity and AES-CBC-128 confidentiality.
+type Client struct {
+	cfg  Config
+	conn net.Conn
+	mu   sync.Mutex
+
+	consoleID uint32
+	managedID uint32
+	keys      *sessionKeys
+	sequence  uint32
+	rqSeq     byte
+	tag       byte
+}
+
+var ErrSession = errors.New("ipmi session error")
+
+var ErrTimeout = errors.New("ipmi request timed out")
+
+// CompletionError is a non-zero completion code returned by the BMC
+type CompletionError byte
+
+func (e CompletionError) Error() string {
+	switch e {
+	case 0xc0:
+		return "ipmi: node busy (0xc0)"
+	case 0xc1:
+		return "ipmi: invalid command (0xc1)"
+	case 0xc7, 0xc8:
+		return fmt.Sprintf("ipmi: invalid request data length (0x%02x)", byte(e))
+	case 0xcc:
+		return "ipmi: invalid data field in request (0xcc)"
+	case 0xd4:
+		return "ipmi: insufficient privilege level (0xd4)"
+	case 0xd5:
+		return "ipmi: command not supported in present state (0xd5)"
+	}
+	return fmt.Sprintf("ipmi: completion code 0x%02x", byte(e))
+}
+
+// Dial establishes RMCP+ session with a BMC, the client must be closed by the caller.
+func Dial(ctx context.Context, cfg Config) (*Client, error) {
+	if cfg.Privilege == 0 {
+		cfg.Privilege = AdministratorPrivilege
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 2 * time.Second
+	}
+	if cfg.Retries == 0 {
+		cfg.Retries = 3
+	}
+	if len(cfg.Username) > 16 {
+		return nil, fmt.Errorf("%w: username longer than 16 characters", ErrSession)
+	}
+	if len(cfg.Password) > 20 {
+		return nil, fmt.Errorf("%w: password longer than 20 characters", ErrSession)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("cannot dial %s: %w", cfg.Address, err)
+	}
+
+	c := &Client{cfg: cfg, conn: conn}
+	err = c.openSession(ctx)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	if cfg.Privilege > UserPrivilege {
+		// sessions are activated with user privilege
+		_, err = c.Command(ctx, netFnApp, cmdSetSessionPrivilege, []byte{byte(cfg.Privilege)})
+		if err != nil {
+			_ = c.Close()
+			return nil, fmt.Errorf("cannot set session privilege: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+// Close closes the session and the connection.
+func (c *Client) Close() error {
+	if c.keys != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), c.cfg.Timeout)
+		defer cancel()
+
+		c.cfg.Retries = 1
+		_, _ = c.Command(ctx, netFnApp, cmdCloseSession, binary.LittleEndian.AppendUint32(nil, c.managedID))
+		c.keys = nil
+	}
+
+	return c.conn.Close()
+}
+
+// Command sends a request within the session and returns response data, non-zero
+// completion codes are returned as CompletionError.
+func (c *Client) Command(ctx context.Context, netFn, cmd byte, data []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.keys == nil {
+		return nil, fmt.Errorf("%w: session is not active", ErrSession)
+	}
+
+	c.rqSeq = (c.rqSeq + 1) & 0x3f
+	c.sequence++
+	req := message{netFn: netFn, cmd: cmd, seq: c.rqSeq, data: data}
+	p := packet{
+		payloadType: payloadIPMI,
+		sessionID:   c.managedID,
+		sequence:    c.sequence,
+		payload:     req.marshalRequest(),
+	}
+
+	var res *message
+	_, err := c.roundTrip(ctx, &p, func(rp *packet) bool {
+		if rp.payloadType != payloadIPMI || rp.sessionID != c.consoleID {
+			return false
+		}
+		m, err := unmarshalResponse(rp.payload)
+		if err != nil || m.seq != req.seq || m.cmd != req.cmd || m.netFn != req.netFn|1 {
+			return false
+		}
+		res = m
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if res.code != 0 {
+		return nil, CompletionError(res.code)
+	}
+
+	return res.data, nil
+}
+
+// roundTrip sends a packet and waits until a matching response arrives, the request
+// is resent after each timeout.
+func (c *Client) roundTrip(ctx context.Context, p *packet, accept func(*packet) bool) (*packet, error) {
+	b, err := p.marshal(c.keys)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 1024)
+	for attempt := 0; attempt < c.cfg.Retries; attempt++ {
+		if _, err := c.conn.Write(b); err != nil {
+			return nil, fmt.Errorf("write error: %w", err)
+		}
+
+		deadline := time.Now().Add(c.cfg.Timeout)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		if err := c.conn.SetReadDeadline(deadline); err != nil {
+			return nil, fmt.Errorf("deadline error: %w", err)
+		}
+
+		for {
+			n, err := c.conn.Read(buf)
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("read error: %w", err)
+			}
+
+			// stale and unrelated packets are ignored
+			rp, err := unmarshalPacket(buf[:n], c.keys)
+			if err != nil {
+				continue
+			}
+			if accept(rp) {
+				return rp, nil
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrTimeout, c.cfg.Address)
+}
+
+// Algorithms of cipher suite 3
+const (
+	authRAKPHMACSHA1     = 0x01
+	integrityHMACSHA1_96 = 0x01
+	confAESCBC128        = 0x01
+)
+
+// roleNameOnlyLookup selects the user by name only, privilege is not part of the lookup
+const roleNameOnlyLookup = 0x10
+
+// RMCP+ status codes of session setup
+const rakpStatusOK = 0x00
+
+func (c *Client) openSession(ctx context.Context) error {
+	var random [4]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return fmt.Errorf("random error: %w", err)
+	}
+	c.consoleID = binary.LittleEndian.Uint32(random[:]) | 1
+
+	// open session request
+	c.tag++
+	rq := []byte{c.tag, byte(c.cfg.Privilege), 0, 0}
+	rq = binary.LittleEndian.AppendUint32(rq, c.consoleID)
+	rq = append(rq, 0x00, 0, 0, 8, authRAKPHMACSHA1, 0, 0, 0)
+	rq = append(rq, 0x01, 0, 0, 8, integrityHMACSHA1_96, 0, 0, 0)
+	rq = append(rq, 0x02, 0, 0, 8, confAESCBC128, 0, 0, 0)
+	rs, err := c.roundTrip(ctx, &packet{payloadType: payloadOpenSessionRq, payload: rq}, c.acceptHandshake(payloadOpenSessionRs))
+	if err != nil {
+		return fmt.Errorf("open session error: %w", err)
+	}
+	if rs.payload[1] != rakpStatusOK {
+		return fmt.Errorf("%w: open session status 0x%02x", ErrSession, rs.payload[1])
+	}
+	if len(rs.payload) < 36 || rs.payload[16] != authRAKPHMACSHA1 || rs.payload[24] != integrityHMACSHA1_96 || rs.payload[32] != confAESCBC128 {
+		return fmt.Errorf("%w: cipher suite 3 not accepted", ErrSession)
+	}
+	c.managedID = binary.LittleEndian.Uint32(rs.payload[8:12])
+
+	// RAKP message 1
+	h := handshake{
+		consoleID: c.consoleID,
+		managedID: c.managedID,
+		role:      byte(c.cfg.Privilege) | roleNameOnlyLookup,
+		username:  []byte(c.cfg.Username),
+		kuid:      []byte(c.cfg.Password),
+	}
+	if _, err := rand.Read(h.rm[:]); err != nil {
+		return fmt.Errorf("random error: %w", err)
+	}
+	c.tag++
+	rq = []byte{c.tag, 0, 0, 0}
+	rq = binary.LittleEndian.AppendUint32(rq, c.managedID)
+	rq = append(rq, h.rm[:]...)
+	rq = append(rq, h.role, 0, 0, byte(len(h.username)))
+	rq = append(rq, h.username...)
+	rs, err = c.roundTrip(ctx, &packet{payloadType: payloadRAKP1, payload: rq}, c.acceptHandshake(payloadRAKP2))
+	if err != nil {
+		return fmt.Errorf("RAKP error: %w", err)
+	}
+	if rs.payload[1] != rakpStatusOK {
+		return fmt.Errorf("%w: RAKP 2 status 0x%02x", ErrSession, rs.payload[1])
+	}
+	if len(rs.payload) < 40+20 {
+		return fmt.Errorf("%w: short RAKP 2", ErrSession)
+	}
+	copy(h.rc[:], rs.payload[8:24])
+	copy(h.guid[:], rs.payload[24:40])
+	if !hmac.Equal(rs.payload[40:60], h.rakp2AuthCode()) {
+		return fmt.Errorf("%w: RAKP 2 auth code mismatch (invalid password?)", ErrSession)
+	}
+
+	// RAKP message 3
+	sik := h.sik()
+	c.tag++
+	rq = []byte{c.tag, rakpStatusOK, 0, 0}
+	rq = binary.LittleEndian.AppendUint32(rq, c.managedID)
+	rq = append(rq, h.rakp3AuthCode()...)
+	rs, err = c.roundTrip(ctx, &packet{payloadType: payloadRAKP3, payload: rq}, c.acceptHandshake(payloadRAKP4))
+	if err != nil {
+		return fmt.Errorf("RAKP error: %w", err)
+	}
+	if rs.payload[1] != rakpStatusOK {
+		return fmt.Errorf("%w: RAKP 4 status 0x%02x", ErrSession, rs.payload[1])
+	}
+	if len(rs.payload) < 8+integrityLen || !hmac.Equal(rs.payload[8:8+integrityLen], h.rakp4IntegrityCheck(sik)) {
+		return fmt.Errorf("%w: RAKP 4 integrity check mismatch", ErrSession)
+	}
+
+	c.keys = deriveKeys(sik)
+	return nil
+}
+
+// acceptHandshake matches session setup responses by type, tag and console session ID
+func (c *Client) acceptHandshake(pt payloadType) func(*packet) bool {
+	return func(p *packet) bool {
+		return p.payloadType == pt &&
+			len(p.payload) >= 8 &&
+			p.payload[0] == c.tag &&
+			// error responses may not carry session ID
+			(p.payload[1] != rakpStatusOK || binary.LittleEndian.Uint32(p.payload[4:8]) == c.consoleID)
+	}
+}
@@ -0,0 +1,119 @@
+package ipmi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func dialFake(t *testing.T, bmc *fakeBMC, password string) (*Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	return Dial(ctx, Config{
+		Address:  bmc.addr(),
+		Username: "admin",
+		Password: password,
+		Timeout:  200 * time.Millisecond,
+	})
+}
+
+func TestPacketRoundTrip(t *testing.T) {
+	keys := deriveKeys([]byte("session integrity key"))
+	for size := 0; size < 40; size++ {
+		p := packet{payloadType: payloadIPMI, sessionID: 42, sequence: 7, payload: make([]byte, size)}
+		for i := range p.payload {
+			p.payload[i] = byte(i)
+		}
+
+		b, err := p.marshal(keys)
+		require.NoError(t, err)
+		require.Zero(t, (len(b)-4-integrityLen)%4, "integrity pad")
+
+		res, err := unmarshalPacket(b, keys)
+		require.NoError(t, err)
+		require.Equal(t, p.payload, res.payload)
+		require.Equal(t, p.sessionID, res.sessionID)
+		require.Equal(t, p.sequence, res.sequence)
+	}
+}
+
+func TestPacketIntegrity(t *testing.T) {
+	keys := deriveKeys([]byte("session integrity key"))
+	p := packet{payloadType: payloadIPMI, payload: []byte{1, 2, 3}}
+	b, err := p.marshal(keys)
+	require.NoError(t, err)
+
+	b[20] ^= 0xff
+	_, err = unmarshalPacket(b, keys)
+	require.ErrorIs(t, err, ErrIntegrity)
+}
+
+func TestChecksum(t *testing.T) {
+	b := []byte{0x20, 0x18}
+	require.Equal(t, byte(0xc8), checksum(b))
+	require.Equal(t, byte(0), checksum(append(b, checksum(b))))
+}
+
+func TestSessionAndChassis(t *testing.T) {
+	bmc := newFakeBMC(t, "admin", "secret")
+	c, err := dialFake(t, bmc, "secret")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	status, err := c.ChassisStatus(ctx)
+	require.NoError(t, err)
+	require.False(t, status.PowerOn)
+
+	require.NoError(t, c.SetBootDevice(ctx, PXEBootDevice, true))
+	require.NoError(t, c.ChassisControl(ctx, PowerUp))
+	require.NoError(t, c.SetBootDevice(ctx, DiskBootDevice, false))
+	require.NoError(t, c.ChassisControl(ctx, PowerCycle))
+
+	status, err = c.ChassisStatus(ctx)
+	require.NoError(t, err)
+	require.True(t, status.PowerOn)
+
+	require.NoError(t, c.Close())
+
+	bmc.mu.Lock()
+	defer bmc.mu.Unlock()
+	require.Equal(t, AdministratorPrivilege, bmc.privilege)
+	require.Equal(t, []ChassisControl{PowerUp, PowerCycle}, bmc.actions)
+	require.Equal(t, []byte{bootOptionBootFlags, bootFlagValid, byte(DiskBootDevice), 0, 0, 0}, bmc.bootFlags)
+	require.True(t, bmc.closed)
+}
+
+func TestInvalidPassword(t *testing.T) {
+	bmc := newFakeBMC(t, "admin", "secret")
+	_, err := dialFake(t, bmc, "wrong")
+	require.ErrorIs(t, err, ErrSession)
+}
+
+func TestInsufficientPrivilege(t *testing.T) {
+	bmc := newFakeBMC(t, "admin", "secret")
+	ctx := context.Background()
+	c, err := Dial(ctx, Config{Address: bmc.addr(), Username: "admin", Password: "secret", Privilege: UserPrivilege})
+	require.NoError(t, err)
+	defer c.Close()
+
+	err = c.ChassisControl(ctx, PowerCycle)
+	var ce CompletionError
+	require.True(t, errors.As(err, &ce))
+	require.Equal(t, CompletionError(0xd4), ce)
+}
+
+func TestRetry(t *testing.T) {
+	bmc := newFakeBMC(t, "admin", "secret")
+	bmc.dropFirst = 2
+	c, err := dialFake(t, bmc, "secret")
+	require.NoError(t, err)
+	defer c.Close()
+
+	bmc.mu.Lock()
+	defer bmc.mu.Unlock()
+	require.Equal(t, 2, bmc.unanswered)
+}
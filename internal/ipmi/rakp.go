@@ -0,0 +1,56 @@
+package ipmi
+
+import (
+	"encoding/binary"
+)
+
+// handshake holds values exchanged during RAKP messages 1-4 which are needed to
+// compute authentication codes on both sides of the session.
+type handshake struct {
+	// remote console and managed system session IDs
+	consoleID uint32
+	managedID uint32
+
+	// remote console and managed system random numbers
+	rm [16]byte
+	rc [16]byte
+
+	// managed system GUID
+	guid [16]byte
+
+	// requested role including name-only lookup bit
+	role byte
+
+	username []byte
+
+	// user key (password)
+	kuid []byte
+}
+
+func le32(v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(nil, v)
+}
+
+func (h *handshake) roleAndName() []byte {
+	return append([]byte{h.role, byte(len(h.username))}, h.username...)
+}
+
+// rakp2AuthCode is HMAC of SIDm, SIDc, Rm, Rc, GUIDc, ROLEm, ULENGTHm and UNAMEm
+func (h *handshake) rakp2AuthCode() []byte {
+	return hmacSHA1(h.kuid, le32(h.consoleID), le32(h.managedID), h.rm[:], h.rc[:], h.guid[:], h.roleAndName())
+}
+
+// rakp3AuthCode is HMAC of Rc, SIDm, ROLEm, ULENGTHm and UNAMEm
+func (h *handshake) rakp3AuthCode() []byte {
+	return hmacSHA1(h.kuid, h.rc[:], le32(h.consoleID), h.roleAndName())
+}
+
+// sik is session integrity key, BMC key (Kg) is not supported so Kuid is used instead
+func (h *handshake) sik() []byte {
+	return hmacSHA1(h.kuid, h.rm[:], h.rc[:], h.roleAndName())
+}
+
+// rakp4IntegrityCheck is HMAC-SHA1-96 of Rm, SIDc and GUIDc
+func (h *handshake) rakp4IntegrityCheck(sik []byte) []byte {
+	return hmacSHA1(sik, h.rm[:], le32(h.managedID), h.guid[:])[:integrityLen]
+}
@@ -0,0 +1,252 @@
+package ipmi
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// RMCP header fields
+const (
+	rmcpVersion   = 0x06
+	rmcpNoAck     = 0xff
+	rmcpClassIPMI = 0x07
+)
+
+// authTypeRMCPPlus marks IPMI v2.0 (RMCP+) session header
+const authTypeRMCPPlus = 0x06
+
+type payloadType byte
+
+const (
+	payloadIPMI          payloadType = 0x00
+	payloadOpenSessionRq payloadType = 0x10
+	payloadOpenSessionRs payloadType = 0x11
+	payloadRAKP1         payloadType = 0x12
+	payloadRAKP2         payloadType = 0x13
+	payloadRAKP3         payloadType = 0x14
+	payloadRAKP4         payloadType = 0x15
+)
+
+const (
+	payloadFlagEncrypted     = 0x80
+	payloadFlagAuthenticated = 0x40
+	payloadTypeMask          = 0x3f
+)
+
+// integrityLen is length of HMAC-SHA1-96 auth code
+const integrityLen = 12
+
+var ErrMalformedPacket = errors.New("malformed RMCP+ packet")
+
+var ErrIntegrity = errors.New("RMCP+ packet integrity check failed")
+
+// sessionKeys are derived from session integrity key during RAKP handshake,
+// K1 is used for HMAC-SHA1-96 integrity and K2 for AES-CBC-128 confidentiality.
+type sessionKeys struct {
+	k1 []byte
+	k2 []byte
+}
+
+func deriveKeys(sik []byte) *sessionKeys {
+	return &sessionKeys{
+		k1: hmacSHA1(sik, bytes.Repeat([]byte{0x01}, sha1.Size)),
+		k2: hmacSHA1(sik, bytes.Repeat([]byte{0x02}, sha1.Size)),
+	}
+}
+
+func hmacSHA1(key []byte, data ...[]byte) []byte {
+	h := hmac.New(sha1.New, key)
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// packet is a single RMCP+ session packet, payload is always in plain text.
+type packet struct {
+	payloadType payloadType
+	sessionID   uint32
+	sequence    uint32
+	payload     []byte
+}
+
+// marshal encodes the packet, when keys are provided the payload is encrypted and signed.
+func (p *packet) marshal(keys *sessionKeys) ([]byte, error) {
+	pt := byte(p.payloadType)
+	payload := p.payload
+	if keys != nil {
+		pt |= payloadFlagEncrypted | payloadFlagAuthenticated
+		var err error
+		payload, err = encrypt(keys.k2, payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	buf := make([]byte, 0, 16+len(payload)+integrityLen+6)
+	buf = append(buf, rmcpVersion, 0x00, rmcpNoAck, rmcpClassIPMI)
+	buf = append(buf, authTypeRMCPPlus, pt)
+	buf = binary.LittleEndian.AppendUint32(buf, p.sessionID)
+	buf = binary.LittleEndian.AppendUint32(buf, p.sequence)
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(payload)))
+	buf = append(buf, payload...)
+
+	if keys != nil {
+		// integrity pad aligns data from auth type to next header field to 4 bytes
+		padLen := (4 - (len(buf)-4+2)%4) % 4
+		buf = append(buf, bytes.Repeat([]byte{0xff}, padLen)...)
+		buf = append(buf, byte(padLen), rmcpClassIPMI)
+		buf = append(buf, hmacSHA1(keys.k1, buf[4:])[:integrityLen]...)
+	}
+
+	return buf, nil
+}
+
+// unmarshalPacket decodes a packet, authenticated packets are verified and decrypted
+// using provided keys.
+func unmarshalPacket(b []byte, keys *sessionKeys) (*packet, error) {
+	if len(b) < 16 || b[0] != rmcpVersion || b[3] != rmcpClassIPMI || b[4] != authTypeRMCPPlus {
+		return nil, ErrMalformedPacket
+	}
+
+	pt := b[5]
+	p := &packet{
+		payloadType: payloadType(pt & payloadTypeMask),
+		sessionID:   binary.LittleEndian.Uint32(b[6:10]),
+		sequence:    binary.LittleEndian.Uint32(b[10:14]),
+	}
+	length := int(binary.LittleEndian.Uint16(b[14:16]))
+	if len(b) < 16+length {
+		return nil, fmt.Errorf("%w: payload length %d", ErrMalformedPacket, length)
+	}
+	p.payload = b[16 : 16+length]
+
+	if pt&payloadFlagAuthenticated != 0 {
+		if keys == nil {
+			return nil, fmt.Errorf("%w: authenticated packet outside of session", ErrMalformedPacket)
+		}
+		if len(b) < 16+length+2+integrityLen {
+			return nil, fmt.Errorf("%w: missing session trailer", ErrMalformedPacket)
+		}
+		authCode := b[len(b)-integrityLen:]
+		if !hmac.Equal(authCode, hmacSHA1(keys.k1, b[4:len(b)-integrityLen])[:integrityLen]) {
+			return nil, ErrIntegrity
+		}
+	}
+
+	if pt&payloadFlagEncrypted != 0 {
+		if keys == nil {
+			return nil, fmt.Errorf("%w: encrypted packet outside of session", ErrMalformedPacket)
+		}
+		var err error
+		p.payload, err = decrypt(keys.k2, p.payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// encrypt performs AES-CBC-128 encryption with random IV prepended to the result.
+func encrypt(k2, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(k2[:aes.BlockSize])
+	if err != nil {
+		return nil, fmt.Errorf("cipher error: %w", err)
+	}
+
+	// confidentiality pad bytes are 1, 2, 3... followed by pad length
+	padLen := (aes.BlockSize - (len(data)+1)%aes.BlockSize) % aes.BlockSize
+	plain := make([]byte, 0, len(data)+padLen+1)
+	plain = append(plain, data...)
+	for i := 1; i <= padLen; i++ {
+		plain = append(plain, byte(i))
+	}
+	plain = append(plain, byte(padLen))
+
+	out := make([]byte, aes.BlockSize+len(plain))
+	iv := out[:aes.BlockSize]
+	if _, err := rand.Read(iv); err != nil {
+		return nil, fmt.Errorf("random error: %w", err)
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out[aes.BlockSize:], plain)
+
+	return out, nil
+}
+
+func decrypt(k2, data []byte) ([]byte, error) {
+	if len(data) < 2*aes.BlockSize || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("%w: invalid encrypted payload length %d", ErrMalformedPacket, len(data))
+	}
+
+	block, err := aes.NewCipher(k2[:aes.BlockSize])
+	if err != nil {
+		return nil, fmt.Errorf("cipher error: %w", err)
+	}
+
+	plain := make([]byte, len(data)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, data[:aes.BlockSize]).CryptBlocks(plain, data[aes.BlockSize:])
+
+	padLen := int(plain[len(plain)-1])
+	if padLen >= aes.BlockSize {
+		return nil, fmt.Errorf("%w: invalid confidentiality pad", ErrMalformedPacket)
+	}
+
+	return plain[:len(plain)-padLen-1], nil
+}
+
+// checksum is two's complement of the sum of the bytes
+func checksum(b []byte) byte {
+	var sum byte
+	for _, c := range b {
+		sum += c
+	}
+	return -sum
+}
+
+// Addresses of the BMC and the remote console software
+const (
+	bmcAddress     = 0x20
+	consoleAddress = 0x81
+)
+
+// message is an IPMI LAN message (request or response)
+type message struct {
+	netFn byte
+	cmd   byte
+	seq   byte
+	// completion code, responses only
+	code byte
+	data []byte
+}
+
+func (m *message) marshalRequest() []byte {
+	b := []byte{bmcAddress, m.netFn << 2, 0, consoleAddress, m.seq << 2, m.cmd}
+	b[2] = checksum(b[:2])
+	b = append(b, m.data...)
+	return append(b, checksum(b[3:]))
+}
+
+func unmarshalResponse(b []byte) (*message, error) {
+	if len(b) < 8 {
+		return nil, fmt.Errorf("%w: short response", ErrMalformedPacket)
+	}
+	if checksum(b[:2]) != b[2] || checksum(b[3:len(b)-1]) != b[len(b)-1] {
+		return nil, fmt.Errorf("%w: response checksum mismatch", ErrMalformedPacket)
+	}
+
+	return &message{
+		netFn: b[1] >> 2,
+		seq:   b[4] >> 2,
+		cmd:   b[5],
+		code:  b[6],
+		data:  b[7 : len(b)-1],
+	}, nil
+}
@@ -0,0 +1,89 @@
+package iso9660
+
+import (
+	"io"
+	"io/fs"
+	"time"
+)
+
+// fileInfo implements fs.FileInfo and fs.DirEntry of an entry
+type fileInfo struct {
+	e    *entry
+	name string
+}
+
+func (fi *fileInfo) Name() string               { return fi.name }
+func (fi *fileInfo) Size() int64                { return fi.e.size }
+func (fi *fileInfo) Mode() fs.FileMode          { return fi.e.mode }
+func (fi *fileInfo) ModTime() time.Time         { return fi.e.modTime }
+func (fi *fileInfo) IsDir() bool                { return fi.e.mode.IsDir() }
+func (fi *fileInfo) Sys() any                   { return nil }
+func (fi *fileInfo) Type() fs.FileMode          { return fi.e.mode.Type() }
+func (fi *fileInfo) Info() (fs.FileInfo, error) { return fi, nil }
+
+// File is a regular file of an image, it supports seeking and reading at offset.
+type File struct {
+	info *fileInfo
+	sr   *io.SectionReader
+}
+
+var (
+	_ io.ReaderAt = (*File)(nil)
+	_ io.Seeker   = (*File)(nil)
+)
+
+func (f *File) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *File) Read(p []byte) (int, error) {
+	return f.sr.Read(p)
+}
+
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	return f.sr.ReadAt(p, off)
+}
+
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	return f.sr.Seek(offset, whence)
+}
+
+func (f *File) Close() error {
+	return nil
+}
+
+// dir is an open directory
+type dir struct {
+	info    *fileInfo
+	entries []*entry
+	pos     int
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *dir) Close() error {
+	return nil
+}
+
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.pos:]
+	if n > 0 && len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && n < len(rest) {
+		rest = rest[:n]
+	}
+	d.pos += len(rest)
+
+	result := make([]fs.DirEntry, len(rest))
+	for i, e := range rest {
+		result[i] = &fileInfo{e: e, name: e.name}
+	}
+	return result, nil
+}
@@ -0,0 +1,564 @@
+// Package iso9660 implements read-only access to ISO9660 images with Rock Ridge and Joliet
+// extensions as fs.FS.
+package iso9660
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+)
+
+const sectorSize = 2048
+
+// maxSymlinks limits number of symbolic links followed during a lookup
+const maxSymlinks = 16
+
+var ErrNotISO9660 = errors.New("not an ISO9660 image")
+
+var ErrMalformed = errors.New("malformed ISO9660 image")
+
+// FS is a read-only file system of an ISO9660 image. It is safe for concurrent use when the
+// underlying reader is.
+type FS struct {
+	r io.ReaderAt
+
+	// VolumeID is the volume label of the primary volume descriptor
+	VolumeID string
+
+	root     *entry
+	joliet   bool
+	rr       bool
+	suspSkip int
+
+	mu   sync.Mutex
+	dirs map[uint32][]*entry
+}
+
+var (
+	_ fs.FS        = (*FS)(nil)
+	_ fs.StatFS    = (*FS)(nil)
+	_ fs.ReadDirFS = (*FS)(nil)
+)
+
+// extent is a contiguous area of the image
+type extent struct {
+	lba  uint32
+	size uint32
+}
+
+// entry is a parsed directory record
+type entry struct {
+	name    string
+	extents []extent
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+	target  string
+	hidden  bool
+}
+
+// Open reads volume descriptors of an image. Rock Ridge names are preferred, Joliet names
+// are used for images without Rock Ridge.
+func Open(r io.ReaderAt) (*FS, error) {
+	f := &FS{r: r, dirs: make(map[uint32][]*entry)}
+
+	var primary, joliet []byte
+	buf := make([]byte, sectorSize)
+	for sector := int64(16); sector < 16+32; sector++ {
+		_, err := r.ReadAt(buf, sector*sectorSize)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read volume descriptor: %w", err)
+		}
+		if string(buf[1:6]) != "CD001" {
+			return nil, ErrNotISO9660
+		}
+
+		switch buf[0] {
+		case 1:
+			if primary == nil {
+				primary = bytes.Clone(buf)
+			}
+		case 2:
+			esc := string(buf[88:91])
+			if joliet == nil && (esc == "%/@" || esc == "%/C" || esc == "%/E") {
+				joliet = bytes.Clone(buf)
+			}
+		}
+		if buf[0] == 255 {
+			break
+		}
+	}
+	if primary == nil {
+		return nil, ErrNotISO9660
+	}
+	f.VolumeID = strings.TrimRight(string(primary[40:72]), " ")
+
+	root, err := f.parseRecord(primary[156:190])
+	if err != nil {
+		return nil, err
+	}
+
+	// Rock Ridge is detected from the SUSP "SP" entry of the root "." record
+	first := make([]byte, sectorSize)
+	_, err = r.ReadAt(first, int64(root.extents[0].lba)*sectorSize)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read root directory: %w", err)
+	}
+	if l := int(first[0]); l >= 34 && l <= len(first) {
+		su := systemUse(first[:l])
+		if len(su) >= 7 && string(su[0:2]) == "SP" && su[4] == 0xbe && su[5] == 0xef {
+			f.rr = true
+			f.suspSkip = int(su[6])
+		}
+	}
+
+	if !f.rr && joliet != nil {
+		f.joliet = true
+		root, err = f.parseRecord(joliet[156:190])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	root.name = "."
+	f.root = root
+	return f, nil
+}
+
+// systemUse returns system use area of a directory record
+func systemUse(rec []byte) []byte {
+	start := 33 + int(rec[32])
+	if start%2 == 1 {
+		start++
+	}
+	if start >= len(rec) {
+		return nil
+	}
+	return rec[start:]
+}
+
+func recordTime(b []byte) time.Time {
+	offset := time.Duration(int8(b[6])) * 15 * time.Minute
+	loc := time.FixedZone("", int(offset.Seconds()))
+	return time.Date(1900+int(b[0]), time.Month(b[1]), int(b[2]), int(b[3]), int(b[4]), int(b[5]), 0, loc)
+}
+
+func (f *FS) parseRecord(rec []byte) (*entry, error) {
+	if len(rec) < 34 || int(rec[0]) > len(rec) || 33+int(rec[32]) > int(rec[0]) {
+		return nil, ErrMalformed
+	}
+
+	e := &entry{
+		extents: []extent{{lba: binary.LittleEndian.Uint32(rec[2:6]), size: binary.LittleEndian.Uint32(rec[10:14])}},
+		modTime: recordTime(rec[18:25]),
+		mode:    0o444,
+	}
+	e.size = int64(e.extents[0].size)
+
+	flags := rec[25]
+	if flags&0x02 != 0 {
+		e.mode = fs.ModeDir | 0o555
+	}
+	if flags&0x04 != 0 {
+		// associated files are not part of the hierarchy
+		e.hidden = true
+	}
+
+	id := rec[33 : 33+int(rec[32])]
+	switch {
+	case len(id) == 1 && id[0] == 0:
+		e.name = "."
+	case len(id) == 1 && id[0] == 1:
+		e.name = ".."
+	case f.joliet:
+		u := make([]uint16, len(id)/2)
+		for i := range u {
+			u[i] = binary.BigEndian.Uint16(id[2*i:])
+		}
+		e.name = stripVersion(string(utf16.Decode(u)))
+	default:
+		e.name = stripVersion(string(id))
+	}
+
+	if f.rr {
+		err := f.parseRockRidge(e, systemUse(rec[:rec[0]]))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
+
+// stripVersion removes ";1" version suffix and the trailing dot of names without extension
+func stripVersion(name string) string {
+	if i := strings.LastIndexByte(name, ';'); i >= 0 {
+		name = name[:i]
+	}
+	if len(name) > 1 && strings.HasSuffix(name, ".") {
+		name = name[:len(name)-1]
+	}
+	return name
+}
+
+// parseRockRidge applies SUSP entries of a record, continuation areas are followed.
+func (f *FS) parseRockRidge(e *entry, su []byte) error {
+	if f.suspSkip <= len(su) {
+		su = su[f.suspSkip:]
+	}
+
+	var name strings.Builder
+	var hasName bool
+	var link []string
+	var linkCont bool
+	for areas := 0; len(su) > 0 && areas < 64; areas++ {
+		var next []byte
+		for len(su) >= 4 {
+			sig, l := string(su[0:2]), int(su[2])
+			if l < 4 || l > len(su) {
+				break
+			}
+			data := su[4:l]
+			switch sig {
+			case "NM":
+				if len(data) >= 1 {
+					switch {
+					case data[0]&0x02 != 0:
+						name.WriteString(".")
+					case data[0]&0x04 != 0:
+						name.WriteString("..")
+					default:
+						name.Write(data[1:])
+					}
+					hasName = true
+				}
+			case "PX":
+				if len(data) >= 4 {
+					e.mode = posixMode(binary.LittleEndian.Uint32(data[0:4]))
+				}
+			case "SL":
+				if len(data) >= 1 {
+					link, linkCont = parseSymlink(link, linkCont, data[1:])
+				}
+			case "RE":
+				e.hidden = true
+			case "CL":
+				if len(data) >= 4 {
+					e.extents = []extent{{lba: binary.LittleEndian.Uint32(data[0:4])}}
+					e.mode = fs.ModeDir | e.mode.Perm()
+				}
+			case "CE":
+				if len(data) >= 24 {
+					block := binary.LittleEndian.Uint32(data[0:4])
+					offset := binary.LittleEndian.Uint32(data[8:12])
+					length := binary.LittleEndian.Uint32(data[16:20])
+					next = make([]byte, length)
+					_, err := f.r.ReadAt(next, int64(block)*sectorSize+int64(offset))
+					if err != nil {
+						return fmt.Errorf("cannot read continuation area: %w", err)
+					}
+				}
+			case "ST":
+				su = nil
+				continue
+			}
+			su = su[l:]
+		}
+		su = next
+	}
+
+	if hasName {
+		e.name = name.String()
+	}
+	if link != nil {
+		e.target = strings.Join(link, "/")
+		if strings.HasPrefix(e.target, "//") {
+			e.target = e.target[1:]
+		}
+	}
+
+	return nil
+}
+
+// parseSymlink appends components of SL entry, cont is true when the last component
+// continues in the next entry.
+func parseSymlink(link []string, cont bool, data []byte) ([]string, bool) {
+	for len(data) >= 2 {
+		flags, l := data[0], int(data[1])
+		if 2+l > len(data) {
+			break
+		}
+
+		var comp string
+		switch {
+		case flags&0x02 != 0:
+			comp = "."
+		case flags&0x04 != 0:
+			comp = ".."
+		case flags&0x08 != 0:
+			comp = ""
+		default:
+			comp = string(data[2 : 2+l])
+		}
+
+		if cont && len(link) > 0 {
+			link[len(link)-1] += comp
+		} else {
+			link = append(link, comp)
+		}
+		cont = flags&0x01 != 0
+		data = data[2+l:]
+	}
+
+	return link, cont
+}
+
+func posixMode(m uint32) fs.FileMode {
+	mode := fs.FileMode(m & 0o777)
+	switch m & 0o170000 {
+	case 0o040000:
+		mode |= fs.ModeDir
+	case 0o120000:
+		mode |= fs.ModeSymlink
+	}
+	return mode
+}
+
+// readDir returns sorted entries of a directory, results are cached.
+func (f *FS) readDir(dir *entry) ([]*entry, error) {
+	lba := dir.extents[0].lba
+	f.mu.Lock()
+	entries, ok := f.dirs[lba]
+	f.mu.Unlock()
+	if ok {
+		return entries, nil
+	}
+
+	size := dir.size
+	if size == 0 {
+		// relocated directories (CL) do not carry size, it is in their "." record
+		first := make([]byte, 34)
+		_, err := f.r.ReadAt(first, int64(lba)*sectorSize)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read directory: %w", err)
+		}
+		size = int64(binary.LittleEndian.Uint32(first[10:14]))
+	}
+
+	buf := make([]byte, size)
+	_, err := f.r.ReadAt(buf, int64(lba)*sectorSize)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read directory: %w", err)
+	}
+
+	var pending *entry
+	for off := 0; off < len(buf); {
+		l := int(buf[off])
+		if l == 0 {
+			// records do not cross sector boundary
+			off = (off/sectorSize + 1) * sectorSize
+			continue
+		}
+		if off+l > len(buf) {
+			return nil, ErrMalformed
+		}
+
+		rec := buf[off : off+l]
+		off += l
+		e, err := f.parseRecord(rec)
+		if err != nil {
+			return nil, err
+		}
+
+		// files larger than 4 GiB consist of multiple records of the same name
+		if pending != nil {
+			pending.extents = append(pending.extents, e.extents...)
+			pending.size += e.size
+			if rec[25]&0x80 == 0 {
+				pending = nil
+			}
+			continue
+		}
+		if rec[25]&0x80 != 0 {
+			pending = e
+		}
+
+		if e.hidden || e.name == "." || e.name == ".." || e.name == "" || strings.Contains(e.name, "/") {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+
+	f.mu.Lock()
+	f.dirs[lba] = entries
+	f.mu.Unlock()
+
+	return entries, nil
+}
+
+// lookup finds an entry, symbolic links are followed in all components and in the last
+// one when follow is true.
+func (f *FS) lookup(op, name string, follow bool) (*entry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	var links int
+	var walk func(dir *entry, dirPath string, rest string) (*entry, error)
+	walk = func(dir *entry, dirPath string, rest string) (*entry, error) {
+		for rest != "" && rest != "." {
+			comp, remaining, _ := strings.Cut(rest, "/")
+			rest = remaining
+
+			if !dir.mode.IsDir() {
+				return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+			}
+			entries, err := f.readDir(dir)
+			if err != nil {
+				return nil, &fs.PathError{Op: op, Path: name, Err: err}
+			}
+			i := sort.Search(len(entries), func(i int) bool { return entries[i].name >= comp })
+			if i == len(entries) || entries[i].name != comp {
+				return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+			}
+
+			e := entries[i]
+			if e.mode&fs.ModeSymlink != 0 && (rest != "" || follow) {
+				links++
+				if links > maxSymlinks {
+					return nil, &fs.PathError{Op: op, Path: name, Err: ErrMalformed}
+				}
+
+				target := e.target
+				if !strings.HasPrefix(target, "/") {
+					target = path.Join(dirPath, target)
+				}
+				target = strings.TrimPrefix(path.Clean("/"+target), "/")
+				e, err = walk(f.root, "", target)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			dir, dirPath = e, path.Join(dirPath, comp)
+		}
+		return dir, nil
+	}
+
+	return walk(f.root, "", name)
+}
+
+// Open opens a file or a directory, symbolic links are followed.
+func (f *FS) Open(name string) (fs.File, error) {
+	e, err := f.lookup("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &fileInfo{e: e, name: path.Base(name)}
+	if e.mode.IsDir() {
+		entries, err := f.readDir(e)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &dir{info: info, entries: entries}, nil
+	}
+
+	return &File{info: info, sr: io.NewSectionReader(extentReader{f: f, e: e}, 0, e.size)}, nil
+}
+
+// Stat returns file information, symbolic links are followed.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	e, err := f.lookup("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return &fileInfo{e: e, name: path.Base(name)}, nil
+}
+
+// Lstat returns file information, the last symbolic link is not followed.
+func (f *FS) Lstat(name string) (fs.FileInfo, error) {
+	e, err := f.lookup("lstat", name, false)
+	if err != nil {
+		return nil, err
+	}
+	return &fileInfo{e: e, name: path.Base(name)}, nil
+}
+
+// ReadLink returns target of a symbolic link.
+func (f *FS) ReadLink(name string) (string, error) {
+	e, err := f.lookup("readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+	if e.mode&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return e.target, nil
+}
+
+// ReadDir returns sorted directory entries.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	e, err := f.lookup("readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if !e.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	entries, err := f.readDir(e)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+
+	result := make([]fs.DirEntry, len(entries))
+	for i, e := range entries {
+		result[i] = &fileInfo{e: e, name: e.name}
+	}
+	return result, nil
+}
+
+// extentReader reads file data spread over extents
+type extentReader struct {
+	f *FS
+	e *entry
+}
+
+func (er extentReader) ReadAt(p []byte, off int64) (int, error) {
+	var n int
+	for _, ext := range er.e.extents {
+		if len(p) == 0 {
+			break
+		}
+		size := int64(ext.size)
+		if off >= size {
+			off -= size
+			continue
+		}
+
+		chunk := p[:min(int64(len(p)), size-off)]
+		m, err := er.f.r.ReadAt(chunk, int64(ext.lba)*sectorSize+off)
+		n += m
+		if err != nil && !(errors.Is(err, io.EOF) && m == len(chunk)) {
+			return n, err
+		}
+		p, off = p[m:], 0
+	}
+
+	if len(p) > 0 {
+		return n, io.EOF
+	}
+	return n, nil
+}
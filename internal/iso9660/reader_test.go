@@ -0,0 +1,131 @@
+package iso9660
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const vmlinuzSha256 = "040885261707b78da1d44f262586a68fc3f1fe33b96a5b7e4faf53911eca5398"
+
+func openGzip(t *testing.T, name string) *FS {
+	t.Helper()
+
+	f, err := os.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	data, err := io.ReadAll(gz)
+	require.NoError(t, err)
+
+	iso, err := Open(bytes.NewReader(data))
+	require.NoError(t, err)
+	return iso
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		file     string
+		volumeID string
+	}{
+		{"testdata/rr.iso.gz", "ROCKRIDGE"},
+		{"testdata/joliet.iso.gz", "JOLIET"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			iso := openGzip(t, tt.file)
+			require.Equal(t, tt.volumeID, iso.VolumeID)
+
+			entries, err := fs.ReadDir(iso, "images/pxeboot")
+			require.NoError(t, err)
+			require.Len(t, entries, 2)
+			require.Equal(t, "initrd.img", entries[0].Name())
+			require.Equal(t, "vmlinuz", entries[1].Name())
+
+			data, err := fs.ReadFile(iso, ".discinfo")
+			require.NoError(t, err)
+			require.Equal(t, "1234\n", string(data))
+
+			data, err = fs.ReadFile(iso, "images/pxeboot/vmlinuz")
+			require.NoError(t, err)
+			sum := sha256.Sum256(data)
+			require.Equal(t, vmlinuzSha256, hex.EncodeToString(sum[:]))
+
+			_, err = iso.Open("missing")
+			require.ErrorIs(t, err, fs.ErrNotExist)
+		})
+	}
+}
+
+func TestFileSeek(t *testing.T) {
+	iso := openGzip(t, "testdata/rr.iso.gz")
+	whole, err := fs.ReadFile(iso, "images/pxeboot/vmlinuz")
+	require.NoError(t, err)
+
+	f, err := iso.Open("images/pxeboot/vmlinuz")
+	require.NoError(t, err)
+	defer f.Close()
+
+	// read across sector boundary
+	buf := make([]byte, 100)
+	_, err = f.(io.ReaderAt).ReadAt(buf, 2000)
+	require.NoError(t, err)
+	require.Equal(t, whole[2000:2100], buf)
+
+	pos, err := f.(io.Seeker).Seek(-10, io.SeekEnd)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(whole)-10), pos)
+	rest, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.Equal(t, whole[len(whole)-10:], rest)
+}
+
+func TestSymlink(t *testing.T) {
+	iso := openGzip(t, "testdata/rr.iso.gz")
+
+	fi, err := iso.Lstat("vmlinuz")
+	require.NoError(t, err)
+	require.Equal(t, fs.ModeSymlink, fi.Mode().Type())
+
+	target, err := iso.ReadLink("vmlinuz")
+	require.NoError(t, err)
+	require.Equal(t, "images/pxeboot/vmlinuz", target)
+
+	data, err := fs.ReadFile(iso, "vmlinuz")
+	require.NoError(t, err)
+	sum := sha256.Sum256(data)
+	require.Equal(t, vmlinuzSha256, hex.EncodeToString(sum[:]))
+}
+
+func TestFixtures(t *testing.T) {
+	f, err := os.Open("../../fixtures/iso/fixture-netboot.iso")
+	require.NoError(t, err)
+	defer f.Close()
+
+	iso, err := Open(f)
+	require.NoError(t, err)
+
+	// directory spanning multiple sectors
+	entries, err := iso.ReadDir("boot/grub2/i386-pc")
+	require.NoError(t, err)
+	require.Greater(t, len(entries), 100)
+
+	require.NoError(t, fs.WalkDir(iso, ".", func(name string, d fs.DirEntry, err error) error {
+		return err
+	}))
+}
+
+func TestNotISO9660(t *testing.T) {
+	_, err := Open(bytes.NewReader(make([]byte, 20*sectorSize)))
+	require.ErrorIs(t, err, ErrNotISO9660)
+}
@@ -0,0 +1,801 @@
+package iso9660
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Platform of an El Torito boot entry
+type Platform byte
+
+const (
+	BIOSPlatform Platform = 0x00
+	EFIPlatform  Platform = 0xef
+)
+
+// BootEntry is an El Torito no-emulation boot entry
+type BootEntry struct {
+	// Path of a file added to the image
+	Path     string
+	Platform Platform
+
+	// LoadSectors is number of 512-byte sectors loaded by BIOS, size of the file when zero
+	LoadSectors uint16
+
+	// BootInfoTable patches the boot image with its location (mkisofs -boot-info-table)
+	BootInfoTable bool
+
+	// Grub2BootInfo patches the boot image for grub2 (xorriso --grub2-boot-info)
+	Grub2BootInfo bool
+}
+
+var ErrDuplicateFile = errors.New("duplicate file")
+
+var ErrBootFileNotFound = errors.New("boot file not found")
+
+var ErrFileTooLarge = errors.New("file is larger than 4 GiB")
+
+var ErrNameTooLong = errors.New("name is too long")
+
+// maxNameLength keeps directory records with Rock Ridge entries under 255 bytes
+const maxNameLength = 150
+
+// maxJolietLength is the maximum number of UCS-2 characters of a Joliet name
+const maxJolietLength = 64
+
+// Writer creates ISO9660 images with Rock Ridge and Joliet names, El Torito boot catalog and
+// a hybrid MBR so the image can be booted from a disk too.
+type Writer struct {
+	volumeID string
+	modTime  time.Time
+	root     *node
+	boot     []BootEntry
+	mbr      []byte
+}
+
+type node struct {
+	name     string
+	isoName  string
+	parent   *node
+	children map[string]*node
+	size     int64
+	open     func() (io.ReadCloser, error)
+
+	lba    uint32
+	number int
+	sorted []*node
+
+	// Joliet directories have their own extents, files share extents of the primary tree
+	jolietName   []byte
+	jolietLBA    uint32
+	jolietSize   int64
+	jolietNumber int
+	jolietSorted []*node
+}
+
+func (n *node) isDir() bool {
+	return n.children != nil
+}
+
+// NewWriter creates an empty image with a volume label.
+func NewWriter(volumeID string) *Writer {
+	root := &node{children: make(map[string]*node)}
+	root.parent = root
+	return &Writer{volumeID: volumeID, modTime: time.Now().UTC(), root: root}
+}
+
+// AddFile adds a file which content is read when the image is written, missing parent
+// directories are created.
+func (w *Writer) AddFile(name string, size int64, open func() (io.ReadCloser, error)) error {
+	if size > 0xffffffff {
+		return fmt.Errorf("%w: %s", ErrFileTooLarge, name)
+	}
+
+	dir := w.root
+	parts := strings.Split(path.Clean(strings.TrimPrefix(name, "/")), "/")
+	for i, part := range parts {
+		if len(part) > maxNameLength {
+			return fmt.Errorf("%w: %s", ErrNameTooLong, name)
+		}
+
+		child, ok := dir.children[part]
+		last := i == len(parts)-1
+		switch {
+		case ok && (last || !child.isDir()):
+			return fmt.Errorf("%w: %s", ErrDuplicateFile, name)
+		case ok:
+			dir = child
+		case last:
+			dir.children[part] = &node{name: part, parent: dir, size: size, open: open}
+		default:
+			child = &node{name: part, parent: dir, children: make(map[string]*node)}
+			dir.children[part] = child
+			dir = child
+		}
+	}
+
+	return nil
+}
+
+// AddBytes adds a file with given content.
+func (w *Writer) AddBytes(name string, data []byte) error {
+	return w.AddFile(name, int64(len(data)), func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+}
+
+// AddFromFS adds a file from a file system.
+func (w *Writer) AddFromFS(name string, fsys fs.FS, src string) error {
+	fi, err := fs.Stat(fsys, src)
+	if err != nil {
+		return err
+	}
+
+	return w.AddFile(name, fi.Size(), func() (io.ReadCloser, error) {
+		return fsys.Open(src)
+	})
+}
+
+// AddBoot adds El Torito boot entry, the first entry is the default one.
+func (w *Writer) AddBoot(entry BootEntry) {
+	w.boot = append(w.boot, entry)
+}
+
+// SetMBR sets boot code of the hybrid MBR written for bootable images, only the first 432
+// bytes are used. When grub2 boot info is enabled, the code is patched with location of the
+// boot image.
+func (w *Writer) SetMBR(code []byte) {
+	w.mbr = code
+}
+
+func (w *Writer) lookup(name string) *node {
+	n := w.root
+	for _, part := range strings.Split(path.Clean(strings.TrimPrefix(name, "/")), "/") {
+		if !n.isDir() {
+			return nil
+		}
+		n = n.children[part]
+		if n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// isoName returns level 2 identifier of a file or a directory
+func isoName(name string, dir bool) string {
+	var base, ext string
+	if i := strings.LastIndexByte(name, '.'); i > 0 && !dir {
+		base, ext = name[:i], name[i+1:]
+	} else {
+		base = name
+	}
+
+	clean := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+				return r
+			case r >= 'a' && r <= 'z':
+				return r - 'a' + 'A'
+			}
+			return '_'
+		}, s)
+	}
+	base, ext = clean(base), clean(ext)
+
+	if dir {
+		return base[:min(len(base), 31)]
+	}
+	ext = ext[:min(len(ext), 8)]
+	base = base[:min(len(base), 30-len(ext)-1)]
+	return base + "." + ext + ";1"
+}
+
+// layout sorts directories, assigns unique ISO names and path table numbers
+func (w *Writer) layout() []*node {
+	dirs := []*node{w.root}
+	for i := 0; i < len(dirs); i++ {
+		d := dirs[i]
+		d.number = i + 1
+
+		used := make(map[string]bool)
+		d.sorted = d.sorted[:0]
+		names := make([]string, 0, len(d.children))
+		for name := range d.children {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			c := d.children[name]
+			c.isoName = isoName(c.name, c.isDir())
+			for n := 1; used[c.isoName]; n++ {
+				suffix := fmt.Sprintf("%d", n)
+				base, rest, _ := strings.Cut(isoName(c.name, c.isDir()), ".")
+				base = base[:max(0, min(len(base), 8-len(suffix)))] + suffix
+				if c.isDir() {
+					c.isoName = base
+				} else {
+					c.isoName = base + "." + rest
+				}
+			}
+			used[c.isoName] = true
+			d.sorted = append(d.sorted, c)
+		}
+		sort.Slice(d.sorted, func(i, j int) bool { return d.sorted[i].isoName < d.sorted[j].isoName })
+
+		for _, c := range d.sorted {
+			if c.isDir() {
+				dirs = append(dirs, c)
+			}
+		}
+	}
+
+	return dirs
+}
+
+// jolietName returns UCS-2 big endian identifier of a file or a directory, names longer
+// than maxJolietLength characters are truncated
+func jolietName(name string, dir bool) []byte {
+	u := utf16.Encode([]rune(name))
+	limit := maxJolietLength
+	if !dir {
+		limit -= 2
+	}
+	u = u[:min(len(u), limit)]
+	if !dir {
+		u = append(u, ';', '1')
+	}
+
+	b := make([]byte, 2*len(u))
+	for i, c := range u {
+		binary.BigEndian.PutUint16(b[2*i:], c)
+	}
+	return b
+}
+
+// jolietLayout sorts directories of the Joliet tree and assigns unique names and path table
+// numbers
+func (w *Writer) jolietLayout() []*node {
+	dirs := []*node{w.root}
+	for i := 0; i < len(dirs); i++ {
+		d := dirs[i]
+		d.jolietNumber = i + 1
+
+		used := make(map[string]bool)
+		d.jolietSorted = d.jolietSorted[:0]
+		for _, c := range d.sorted {
+			c.jolietName = jolietName(c.name, c.isDir())
+			for n := 1; used[string(c.jolietName)]; n++ {
+				suffix := fmt.Sprintf("~%d", n)
+				runes := []rune(c.name)
+				c.jolietName = jolietName(string(runes[:max(0, min(len(runes), maxJolietLength-2-len(suffix)))])+suffix, c.isDir())
+			}
+			used[string(c.jolietName)] = true
+			d.jolietSorted = append(d.jolietSorted, c)
+		}
+		sort.Slice(d.jolietSorted, func(i, j int) bool {
+			return bytes.Compare(d.jolietSorted[i].jolietName, d.jolietSorted[j].jolietName) < 0
+		})
+
+		for _, c := range d.jolietSorted {
+			if c.isDir() {
+				dirs = append(dirs, c)
+			}
+		}
+	}
+
+	return dirs
+}
+
+// susp returns Rock Ridge system use entries of a directory record
+func susp(name string, dir, root bool) []byte {
+	var b []byte
+	if root {
+		// SP marks SUSP usage, ER identifies Rock Ridge
+		b = append(b, 'S', 'P', 7, 1, 0xbe, 0xef, 0)
+		b = append(b, 'E', 'R', 8+10, 1, 10, 0, 0, 1)
+		b = append(b, "RRIP_1991A"...)
+	}
+
+	mode := uint32(0o100444)
+	nlink := uint32(1)
+	if dir {
+		mode, nlink = 0o040555, 2
+	}
+	px := []byte{'P', 'X', 36, 1}
+	for _, v := range []uint32{mode, nlink, 0, 0} {
+		px = binary.LittleEndian.AppendUint32(px, v)
+		px = binary.BigEndian.AppendUint32(px, v)
+	}
+	b = append(b, px...)
+
+	if name != "" {
+		b = append(b, 'N', 'M', byte(5+len(name)), 1, 0)
+		b = append(b, name...)
+	}
+
+	return b
+}
+
+func bothUint16(b []byte, v uint16) {
+	binary.LittleEndian.PutUint16(b[0:2], v)
+	binary.BigEndian.PutUint16(b[2:4], v)
+}
+
+func bothUint32(b []byte, v uint32) {
+	binary.LittleEndian.PutUint32(b[0:4], v)
+	binary.BigEndian.PutUint32(b[4:8], v)
+}
+
+func (w *Writer) record(id []byte, lba, size uint32, dir bool, su []byte) []byte {
+	l := 33 + len(id)
+	if l%2 == 1 {
+		l++
+	}
+	b := make([]byte, l+len(su))
+	b[0] = byte(len(b))
+	bothUint32(b[2:10], lba)
+	bothUint32(b[10:18], size)
+	t := w.modTime
+	copy(b[18:25], []byte{byte(t.Year() - 1900), byte(t.Month()), byte(t.Day()), byte(t.Hour()), byte(t.Minute()), byte(t.Second()), 0})
+	if dir {
+		b[25] = 0x02
+	}
+	bothUint16(b[28:32], 1)
+	b[32] = byte(len(id))
+	copy(b[33:], id)
+	copy(b[l:], su)
+	return b
+}
+
+// directory returns extent of a directory, records do not cross sector boundaries
+func (w *Writer) directory(d *node) []byte {
+	var buf []byte
+	add := func(rec []byte) {
+		used := len(buf) % sectorSize
+		if used+len(rec) > sectorSize {
+			buf = append(buf, make([]byte, sectorSize-used)...)
+		}
+		buf = append(buf, rec...)
+	}
+
+	add(w.record([]byte{0}, d.lba, uint32(d.size), true, susp("", true, d == w.root)))
+	add(w.record([]byte{1}, d.parent.lba, uint32(d.parent.size), true, susp("", true, false)))
+	for _, c := range d.sorted {
+		add(w.record([]byte(c.isoName), c.lba, uint32(c.size), c.isDir(), susp(c.name, c.isDir(), false)))
+	}
+
+	if len(buf)%sectorSize != 0 {
+		buf = append(buf, make([]byte, sectorSize-len(buf)%sectorSize)...)
+	}
+	return buf
+}
+
+// jolietDirectory returns extent of a directory of the Joliet tree
+func (w *Writer) jolietDirectory(d *node) []byte {
+	var buf []byte
+	add := func(rec []byte) {
+		used := len(buf) % sectorSize
+		if used+len(rec) > sectorSize {
+			buf = append(buf, make([]byte, sectorSize-used)...)
+		}
+		buf = append(buf, rec...)
+	}
+
+	add(w.record([]byte{0}, d.jolietLBA, uint32(d.jolietSize), true, nil))
+	add(w.record([]byte{1}, d.parent.jolietLBA, uint32(d.parent.jolietSize), true, nil))
+	for _, c := range d.jolietSorted {
+		if c.isDir() {
+			add(w.record(c.jolietName, c.jolietLBA, uint32(c.jolietSize), true, nil))
+		} else {
+			add(w.record(c.jolietName, c.lba, uint32(c.size), false, nil))
+		}
+	}
+
+	if len(buf)%sectorSize != 0 {
+		buf = append(buf, make([]byte, sectorSize-len(buf)%sectorSize)...)
+	}
+	return buf
+}
+
+func pathTable(dirs []*node, bigEndian, joliet bool) []byte {
+	var b []byte
+	for _, d := range dirs {
+		id, lba, parent := []byte(d.isoName), d.lba, d.parent.number
+		if joliet {
+			id, lba, parent = d.jolietName, d.jolietLBA, d.parent.jolietNumber
+		}
+		if d == d.parent {
+			id = []byte{0}
+		}
+		e := make([]byte, 8+len(id)+len(id)%2)
+		e[0] = byte(len(id))
+		if bigEndian {
+			binary.BigEndian.PutUint32(e[2:6], lba)
+			binary.BigEndian.PutUint16(e[6:8], uint16(parent))
+		} else {
+			binary.LittleEndian.PutUint32(e[2:6], lba)
+			binary.LittleEndian.PutUint16(e[6:8], uint16(parent))
+		}
+		copy(e[8:], id)
+		b = append(b, e...)
+	}
+	return b
+}
+
+func sectors(size int64) uint32 {
+	return uint32((size + sectorSize - 1) / sectorSize)
+}
+
+func padded(b []byte) []byte {
+	if len(b)%sectorSize == 0 {
+		return b
+	}
+	return append(b, make([]byte, sectorSize-len(b)%sectorSize)...)
+}
+
+// WriteTo writes the image, files are read in the process.
+func (w *Writer) WriteTo(out io.Writer) (int64, error) {
+	dirs := w.layout()
+	jolietDirs := w.jolietLayout()
+
+	// resolve boot files
+	bootNodes := make([]*node, len(w.boot))
+	for i, e := range w.boot {
+		bootNodes[i] = w.lookup(e.Path)
+		if bootNodes[i] == nil || bootNodes[i].isDir() {
+			return 0, fmt.Errorf("%w: %s", ErrBootFileNotFound, e.Path)
+		}
+	}
+
+	// sizes of directories depend only on names, locations are assigned afterwards
+	for _, d := range dirs {
+		d.size = int64(len(w.directory(d)))
+		d.jolietSize = int64(len(w.jolietDirectory(d)))
+	}
+	ptSize := len(pathTable(dirs, false, false))
+	jolietPTSize := len(pathTable(jolietDirs, false, true))
+
+	lba := uint32(16 + 1)
+	if len(w.boot) > 0 {
+		lba++
+	}
+	lba++ // Joliet
+	lba++ // terminator
+	lPathLBA := lba
+	lba += sectors(int64(ptSize))
+	mPathLBA := lba
+	lba += sectors(int64(ptSize))
+	jolietLPathLBA := lba
+	lba += sectors(int64(jolietPTSize))
+	jolietMPathLBA := lba
+	lba += sectors(int64(jolietPTSize))
+	catalogLBA := lba
+	if len(w.boot) > 0 {
+		lba++
+	}
+	for _, d := range dirs {
+		d.lba = lba
+		lba += sectors(d.size)
+	}
+	for _, d := range jolietDirs {
+		d.jolietLBA = lba
+		lba += sectors(d.jolietSize)
+	}
+	var files []*node
+	for _, d := range dirs {
+		for _, c := range d.sorted {
+			if !c.isDir() {
+				c.lba = lba
+				lba += sectors(c.size)
+				files = append(files, c)
+			}
+		}
+	}
+	total := lba
+
+	bw := bufio.NewWriterSize(out, 64*1024)
+	var written int64
+	write := func(b []byte) error {
+		n, err := bw.Write(b)
+		written += int64(n)
+		return err
+	}
+
+	// system area with hybrid MBR
+	err := write(padded(w.systemArea(bootNodes, total)))
+	if err != nil {
+		return written, err
+	}
+	if written < 16*sectorSize {
+		err = write(make([]byte, 16*sectorSize-written))
+		if err != nil {
+			return written, err
+		}
+	}
+
+	for _, b := range [][]byte{
+		w.primaryDescriptor(dirs[0], total, ptSize, lPathLBA, mPathLBA),
+		w.bootDescriptor(catalogLBA),
+		w.jolietDescriptor(dirs[0], total, jolietPTSize, jolietLPathLBA, jolietMPathLBA),
+		terminator(),
+		padded(pathTable(dirs, false, false)),
+		padded(pathTable(dirs, true, false)),
+		padded(pathTable(jolietDirs, false, true)),
+		padded(pathTable(jolietDirs, true, true)),
+		w.catalog(bootNodes),
+	} {
+		err = write(b)
+		if err != nil {
+			return written, err
+		}
+	}
+
+	for _, d := range dirs {
+		err = write(w.directory(d))
+		if err != nil {
+			return written, err
+		}
+	}
+	for _, d := range jolietDirs {
+		err = write(w.jolietDirectory(d))
+		if err != nil {
+			return written, err
+		}
+	}
+
+	for _, f := range files {
+		err = w.writeFile(bw, f, bootNodes, &written)
+		if err != nil {
+			return written, fmt.Errorf("cannot write %s: %w", f.name, err)
+		}
+	}
+
+	return written, bw.Flush()
+}
+
+func (w *Writer) writeFile(out io.Writer, f *node, bootNodes []*node, written *int64) error {
+	if f.size == 0 {
+		return nil
+	}
+
+	r, err := f.open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var src io.Reader = r
+	for i, bn := range bootNodes {
+		if bn != f || !(w.boot[i].BootInfoTable || w.boot[i].Grub2BootInfo) {
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(r, f.size))
+		if err != nil {
+			return err
+		}
+		patchBootImage(data, w.boot[i], f.lba)
+		src = bytes.NewReader(data)
+	}
+
+	n, err := io.Copy(out, io.LimitReader(src, f.size))
+	*written += n
+	if err != nil {
+		return err
+	}
+	if n != f.size {
+		return io.ErrUnexpectedEOF
+	}
+
+	pad := int64(sectors(f.size))*sectorSize - f.size
+	m, err := out.Write(make([]byte, pad))
+	*written += int64(m)
+	return err
+}
+
+// patchBootImage writes boot info table and grub2 boot info into a boot image
+func patchBootImage(data []byte, e BootEntry, lba uint32) {
+	if e.BootInfoTable && len(data) >= 64 {
+		var sum uint32
+		for i := 64; i+4 <= len(data); i += 4 {
+			sum += binary.LittleEndian.Uint32(data[i:])
+		}
+		clear(data[8:64])
+		binary.LittleEndian.PutUint32(data[8:12], 16)
+		binary.LittleEndian.PutUint32(data[12:16], lba)
+		binary.LittleEndian.PutUint32(data[16:20], uint32(len(data)))
+		binary.LittleEndian.PutUint32(data[20:24], sum)
+	}
+
+	if e.Grub2BootInfo && len(data) >= 2556 {
+		binary.LittleEndian.PutUint64(data[2548:2556], uint64(lba)*4+5)
+	}
+}
+
+func (w *Writer) systemArea(bootNodes []*node, total uint32) []byte {
+	mbr := make([]byte, 512)
+	if len(w.boot) == 0 {
+		return mbr
+	}
+	copy(mbr[:432], w.mbr[:min(len(w.mbr), 432)])
+
+	for i, e := range w.boot {
+		if e.Platform == BIOSPlatform && e.Grub2BootInfo {
+			binary.LittleEndian.PutUint64(mbr[432:440], uint64(bootNodes[i].lba)*4+4)
+		}
+	}
+	binary.LittleEndian.PutUint32(mbr[440:444], 0x46524553)
+
+	// the first partition covers the whole image, EFI system partitions point to FAT images
+	// inside of the ISO
+	partition := func(i int, active bool, kind byte, start, count uint32) {
+		p := mbr[446+16*i : 446+16*(i+1)]
+		if active {
+			p[0] = 0x80
+		}
+		copy(p[1:4], []byte{0xfe, 0xff, 0xff})
+		p[4] = kind
+		copy(p[5:8], []byte{0xfe, 0xff, 0xff})
+		binary.LittleEndian.PutUint32(p[8:12], start)
+		binary.LittleEndian.PutUint32(p[12:16], count)
+	}
+	partition(0, true, 0x17, 0, total*4)
+	next := 1
+	for i, e := range w.boot {
+		if e.Platform == EFIPlatform && next < 4 {
+			partition(next, false, 0xef, bootNodes[i].lba*4, uint32((bootNodes[i].size+511)/512))
+			next++
+		}
+	}
+	mbr[510], mbr[511] = 0x55, 0xaa
+
+	return mbr
+}
+
+func isoDate(t time.Time) []byte {
+	return append([]byte(t.Format("20060102150405")+"00"), 0)
+}
+
+func (w *Writer) primaryDescriptor(root *node, total uint32, ptSize int, lPath, mPath uint32) []byte {
+	b := make([]byte, sectorSize)
+	b[0] = 1
+	copy(b[1:6], "CD001")
+	b[6] = 1
+	copy(b[8:40], fmt.Sprintf("%-32.32s", "LINUX"))
+	copy(b[40:72], fmt.Sprintf("%-32.32s", w.volumeID))
+	bothUint32(b[80:88], total)
+	bothUint16(b[120:124], 1)
+	bothUint16(b[124:128], 1)
+	bothUint16(b[128:132], sectorSize)
+	bothUint32(b[132:140], uint32(ptSize))
+	binary.LittleEndian.PutUint32(b[140:144], lPath)
+	binary.BigEndian.PutUint32(b[148:152], mPath)
+	copy(b[156:190], w.record([]byte{0}, root.lba, uint32(root.size), true, nil))
+	for _, r := range [][2]int{{190, 318}, {318, 446}, {446, 574}, {574, 702}, {702, 739}, {739, 776}, {776, 813}} {
+		copy(b[r[0]:r[1]], bytes.Repeat([]byte(" "), r[1]-r[0]))
+	}
+	copy(b[574:702], fmt.Sprintf("%-128.128s", "FORESTER"))
+	copy(b[813:830], isoDate(w.modTime))
+	copy(b[830:847], isoDate(w.modTime))
+	copy(b[847:864], "0000000000000000")
+	copy(b[864:881], isoDate(w.modTime))
+	b[881] = 1
+	return b
+}
+
+// jolietDescriptor returns supplementary volume descriptor of the Joliet tree (UCS-2 level 3)
+func (w *Writer) jolietDescriptor(root *node, total uint32, ptSize int, lPath, mPath uint32) []byte {
+	ucs2 := func(b []byte, s string) {
+		for i := 0; i+1 < len(b); i += 2 {
+			binary.BigEndian.PutUint16(b[i:], ' ')
+		}
+		for i, c := range utf16.Encode([]rune(s)) {
+			if 2*i+1 >= len(b) {
+				break
+			}
+			binary.BigEndian.PutUint16(b[2*i:], c)
+		}
+	}
+
+	b := make([]byte, sectorSize)
+	b[0] = 2
+	copy(b[1:6], "CD001")
+	b[6] = 1
+	ucs2(b[8:40], "LINUX")
+	ucs2(b[40:72], w.volumeID)
+	bothUint32(b[80:88], total)
+	copy(b[88:91], "%/E")
+	bothUint16(b[120:124], 1)
+	bothUint16(b[124:128], 1)
+	bothUint16(b[128:132], sectorSize)
+	bothUint32(b[132:140], uint32(ptSize))
+	binary.LittleEndian.PutUint32(b[140:144], lPath)
+	binary.BigEndian.PutUint32(b[148:152], mPath)
+	copy(b[156:190], w.record([]byte{0}, root.jolietLBA, uint32(root.jolietSize), true, nil))
+	for _, r := range [][2]int{{190, 318}, {318, 446}, {446, 574}, {574, 702}, {702, 739}, {739, 776}, {776, 813}} {
+		ucs2(b[r[0]:r[1]], "")
+	}
+	ucs2(b[574:702], "FORESTER")
+	copy(b[813:830], isoDate(w.modTime))
+	copy(b[830:847], isoDate(w.modTime))
+	copy(b[847:864], "0000000000000000")
+	copy(b[864:881], isoDate(w.modTime))
+	b[881] = 1
+	return b
+}
+
+func (w *Writer) bootDescriptor(catalogLBA uint32) []byte {
+	if len(w.boot) == 0 {
+		return nil
+	}
+
+	b := make([]byte, sectorSize)
+	copy(b[1:6], "CD001")
+	b[6] = 1
+	copy(b[7:39], "EL TORITO SPECIFICATION")
+	binary.LittleEndian.PutUint32(b[71:75], catalogLBA)
+	return b
+}
+
+func terminator() []byte {
+	b := make([]byte, sectorSize)
+	b[0] = 255
+	copy(b[1:6], "CD001")
+	b[6] = 1
+	return b
+}
+
+func (w *Writer) catalog(bootNodes []*node) []byte {
+	if len(w.boot) == 0 {
+		return nil
+	}
+
+	b := make([]byte, sectorSize)
+	b[0] = 1
+	b[1] = byte(w.boot[0].Platform)
+	copy(b[4:28], "FORESTER")
+	b[30], b[31] = 0x55, 0xaa
+	var sum uint16
+	for i := 0; i < 32; i += 2 {
+		sum += binary.LittleEndian.Uint16(b[i:])
+	}
+	binary.LittleEndian.PutUint16(b[28:30], -sum)
+
+	entry := func(e []byte, be BootEntry, n *node) {
+		e[0] = 0x88
+		count := be.LoadSectors
+		if count == 0 {
+			count = uint16(min((n.size+511)/512, 0xffff))
+		}
+		binary.LittleEndian.PutUint16(e[6:8], count)
+		binary.LittleEndian.PutUint32(e[8:12], n.lba)
+	}
+	entry(b[32:64], w.boot[0], bootNodes[0])
+
+	off := 64
+	for i := 1; i < len(w.boot) && off+64 <= sectorSize; i++ {
+		h := b[off : off+32]
+		h[0] = 0x90
+		if i == len(w.boot)-1 {
+			h[0] = 0x91
+		}
+		h[1] = byte(w.boot[i].Platform)
+		binary.LittleEndian.PutUint16(h[2:4], 1)
+		entry(b[off+32:off+64], w.boot[i], bootNodes[i])
+		off += 64
+	}
+
+	return b
+}
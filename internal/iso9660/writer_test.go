@@ -0,0 +1,120 @@
+package iso9660
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	kernel := []byte(strings.Repeat("kernel", 1000))
+	eltorito := make([]byte, 4096)
+	eltorito[4000] = 1
+	efiboot := make([]byte, 100000)
+
+	w := NewWriter("FORESTER")
+	require.NoError(t, w.AddBytes("images/pxeboot/vmlinuz", kernel))
+	require.NoError(t, w.AddBytes("images/pxeboot/initrd.img", nil))
+	require.NoError(t, w.AddBytes("images/eltorito.img", eltorito))
+	require.NoError(t, w.AddBytes("images/efiboot.img", efiboot))
+	require.NoError(t, w.AddBytes("EFI/BOOT/grub.cfg", []byte("menuentry")))
+	require.NoError(t, w.AddBytes("boot/grub2/i386-pc/long_module_name_test.mod", []byte("a")))
+	require.NoError(t, w.AddBytes("boot/grub2/i386-pc/long_module_name_test.lst", []byte("b")))
+	require.ErrorIs(t, w.AddBytes("EFI/BOOT/grub.cfg", nil), ErrDuplicateFile)
+	w.AddBoot(BootEntry{Path: "images/eltorito.img", Platform: BIOSPlatform, LoadSectors: 4, BootInfoTable: true, Grub2BootInfo: true})
+	w.AddBoot(BootEntry{Path: "images/efiboot.img", Platform: EFIPlatform})
+	w.SetMBR(bytes.Repeat([]byte{0xfa}, 512))
+
+	var buf bytes.Buffer
+	n, err := w.WriteTo(&buf)
+	require.NoError(t, err)
+	require.Equal(t, int64(buf.Len()), n)
+	require.Zero(t, n%sectorSize)
+	img := buf.Bytes()
+
+	iso, err := Open(bytes.NewReader(img))
+	require.NoError(t, err)
+	require.Equal(t, "FORESTER", iso.VolumeID)
+	require.True(t, iso.rr)
+
+	data, err := fs.ReadFile(iso, "images/pxeboot/vmlinuz")
+	require.NoError(t, err)
+	require.Equal(t, kernel, data)
+	data, err = fs.ReadFile(iso, "EFI/BOOT/grub.cfg")
+	require.NoError(t, err)
+	require.Equal(t, "menuentry", string(data))
+	entries, err := fs.ReadDir(iso, "boot/grub2/i386-pc")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "long_module_name_test.lst", entries[0].Name())
+
+	// Joliet tree is read when Rock Ridge is not supported
+	svd := img[18*sectorSize : 19*sectorSize]
+	require.Equal(t, byte(2), svd[0])
+	require.Equal(t, "%/E", string(svd[88:91]))
+	joliet := &FS{r: bytes.NewReader(img), dirs: make(map[uint32][]*entry), joliet: true}
+	joliet.root, err = joliet.parseRecord(svd[156:190])
+	require.NoError(t, err)
+	joliet.root.name = "."
+	data, err = fs.ReadFile(joliet, "images/pxeboot/vmlinuz")
+	require.NoError(t, err)
+	require.Equal(t, kernel, data)
+	entries, err = fs.ReadDir(joliet, "boot/grub2/i386-pc")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "long_module_name_test.lst", entries[0].Name())
+
+	// El Torito boot catalog
+	require.Equal(t, "EL TORITO SPECIFICATION", string(img[17*sectorSize+7:17*sectorSize+30]))
+	catalog := img[int(binary.LittleEndian.Uint32(img[17*sectorSize+71:]))*sectorSize:]
+	var sum uint16
+	for i := 0; i < 32; i += 2 {
+		sum += binary.LittleEndian.Uint16(catalog[i:])
+	}
+	require.Zero(t, sum)
+	require.Equal(t, byte(0x88), catalog[32])
+	require.Equal(t, uint16(4), binary.LittleEndian.Uint16(catalog[38:40]))
+	require.Equal(t, byte(0x91), catalog[64])
+	require.Equal(t, byte(EFIPlatform), catalog[65])
+	efiLBA := binary.LittleEndian.Uint32(catalog[104:108])
+	require.Equal(t, efiboot, img[efiLBA*sectorSize:efiLBA*sectorSize+uint32(len(efiboot))])
+
+	// patched boot image
+	biosLBA := binary.LittleEndian.Uint32(catalog[40:44])
+	boot := img[biosLBA*sectorSize : biosLBA*sectorSize+4096]
+	require.Equal(t, uint32(16), binary.LittleEndian.Uint32(boot[8:12]))
+	require.Equal(t, biosLBA, binary.LittleEndian.Uint32(boot[12:16]))
+	require.Equal(t, uint32(4096), binary.LittleEndian.Uint32(boot[16:20]))
+	require.Equal(t, uint32(1), binary.LittleEndian.Uint32(boot[20:24]))
+	require.Equal(t, uint64(biosLBA)*4+5, binary.LittleEndian.Uint64(boot[2548:2556]))
+
+	// hybrid MBR
+	require.Equal(t, []byte{0x55, 0xaa}, img[510:512])
+	require.Equal(t, byte(0xfa), img[0])
+	require.Equal(t, uint64(biosLBA)*4+4, binary.LittleEndian.Uint64(img[432:440]))
+	require.Equal(t, byte(0x17), img[446+4])
+	require.Equal(t, byte(0xef), img[462+4])
+	require.Equal(t, efiLBA*4, binary.LittleEndian.Uint32(img[462+8:]))
+}
+
+func TestIsoName(t *testing.T) {
+	tests := []struct {
+		name string
+		dir  bool
+		want string
+	}{
+		{"vmlinuz", false, "VMLINUZ.;1"},
+		{"initrd.img", false, "INITRD.IMG;1"},
+		{"grub-2.cfg", false, "GRUB_2.CFG;1"},
+		{"i386-pc", true, "I386_PC"},
+		{".discinfo", false, "_DISCINFO.;1"},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, isoName(tt.name, tt.dir))
+	}
+}
@@ -0,0 +1,318 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"forester/internal/config"
+	"forester/internal/db"
+	"forester/internal/logging"
+	"forester/internal/metal"
+	"forester/internal/model"
+	"forester/internal/ptr"
+)
+
+// ErrPermanent marks job errors which are not retried.
+var ErrPermanent = errors.New("permanent error")
+
+// ImportFunc downloads and extracts image of an import job.
+type ImportFunc func(ctx context.Context, job *model.Job) error
+
+// Queue is a set of workers executing jobs stored in the database.
+type Queue struct {
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+	importImage ImportFunc
+}
+
+// pool is a group of workers executing jobs of the given kinds, image imports have their
+// own workers so long downloads do not block power operations.
+type pool struct {
+	kinds []model.JobKind
+	// wake is signalled when a job is enqueued so workers do not need to wait for the next poll
+	wake chan struct{}
+}
+
+var powerPool = pool{
+	kinds: []model.JobKind{model.BootNetworkJobKind, model.BootLocalJobKind, model.PowerOnJobKind,
+		model.PowerOffJobKind, model.ForceOffJobKind, model.PowerCycleJobKind},
+	wake: make(chan struct{}, 1),
+}
+
+var importPool = pool{
+	kinds: []model.JobKind{model.ImportImageJobKind},
+	wake:  make(chan struct{}, 1),
+}
+
+// Start requeues jobs interrupted by previous shutdown and starts workers, importImage
+// executes image import jobs.
+func Start(ctx context.Context, importImage ImportFunc) (*Queue, error) {
+	count, err := db.GetJobDao(ctx).Requeue(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot requeue interrupted jobs: %w", err)
+	}
+	if count > 0 {
+		slog.InfoContext(ctx, "requeued interrupted jobs", "count", count)
+	}
+
+	q := Queue{importImage: importImage}
+	var workerCtx context.Context
+	workerCtx, q.cancel = context.WithCancel(ctx)
+	for i := 0; i < config.Jobs.Workers; i++ {
+		q.wg.Add(1)
+		go q.worker(workerCtx, &powerPool)
+	}
+	for i := 0; i < config.Images.ImportWorkers; i++ {
+		q.wg.Add(1)
+		go q.worker(workerCtx, &importPool)
+	}
+
+	return &q, nil
+}
+
+// Shutdown stops workers and waits until running jobs are finished.
+func (q *Queue) Shutdown() {
+	slog.Debug("stopping job workers")
+	q.cancel()
+	q.wg.Wait()
+}
+
+// Enqueue stores a new job for a system which is executed after the delay. Events of boot
+// jobs are recorded for the installation, it can be nil.
+func Enqueue(ctx context.Context, kind model.JobKind, system *model.SystemAppliance, instID *int64, delay time.Duration) (*model.Job, error) {
+	if system.ApplianceID == nil {
+		return nil, metal.ErrSystemWithNoAppliance
+	}
+
+	if system.UID == nil {
+		return nil, metal.ErrSystemWithNoUID
+	}
+
+	job := model.Job{
+		Kind:           kind,
+		SystemID:       &system.System.ID,
+		ApplianceID:    system.ApplianceID,
+		InstallationID: instID,
+		TraceID:        logging.TraceId(ctx),
+		MaxAttempts:    max(config.Jobs.MaxAttempts, 1),
+		RunAt:          time.Now().Add(delay),
+	}
+
+	err := db.GetJobDao(ctx).Enqueue(ctx, &job)
+	if err != nil {
+		return nil, fmt.Errorf("cannot enqueue job: %w", err)
+	}
+	slog.InfoContext(ctx, "enqueued job", "job_id", job.ID, "kind", kind.String(), "system_id", system.System.ID, "run_at", job.RunAt)
+	powerPool.signal()
+
+	return &job, nil
+}
+
+// EnqueueImport stores a new job which downloads image ISO from URL and extracts it.
+func EnqueueImport(ctx context.Context, imageID int64, url string) (*model.Job, error) {
+	job := model.Job{
+		Kind:        model.ImportImageJobKind,
+		ImageID:     &imageID,
+		URL:         url,
+		TraceID:     logging.TraceId(ctx),
+		MaxAttempts: max(config.Images.ImportAttempts, 1),
+		RunAt:       time.Now(),
+	}
+
+	err := db.GetJobDao(ctx).Enqueue(ctx, &job)
+	if err != nil {
+		return nil, fmt.Errorf("cannot enqueue job: %w", err)
+	}
+	slog.InfoContext(ctx, "enqueued job", "job_id", job.ID, "kind", job.Kind.String(), "image_id", imageID)
+	importPool.signal()
+
+	return &job, nil
+}
+
+// signal wakes up a waiting worker of the pool
+func (p *pool) signal() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *Queue) worker(ctx context.Context, p *pool) {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(config.Jobs.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// process all due jobs before waiting
+		for q.process(ctx, p) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-p.wake:
+		}
+	}
+}
+
+// process acquires and executes a single job, returns false when there was nothing to do.
+func (q *Queue) process(ctx context.Context, p *pool) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	dao := db.GetJobDao(ctx)
+	job, err := dao.Acquire(ctx, p.kinds, config.Jobs.ApplianceLimit)
+	if errors.Is(err, db.ErrNoRows) {
+		return false
+	} else if err != nil {
+		slog.ErrorContext(ctx, "cannot acquire job", "err", err)
+		return false
+	}
+
+	// power jobs are not interrupted by shutdown, the attempt is given its own timeout. Image
+	// imports take long, they are interrupted and resumed after restart.
+	parent, timeout, first := context.Background(), config.Jobs.Timeout, config.Jobs.RetryBackoff
+	if job.Kind == model.ImportImageJobKind {
+		parent, timeout, first = ctx, config.Images.ImportTimeout, config.Images.ImportBackoff
+	}
+	jctx := logging.WithJobId(logging.WithTraceId(parent, job.TraceID), strconv.FormatInt(job.ID, 10))
+	jctx, cancel := context.WithTimeout(jctx, timeout)
+	defer cancel()
+
+	slog.InfoContext(jctx, "executing job", "kind", job.Kind.String(), "system_id", ptr.From(job.SystemID),
+		"image_id", ptr.From(job.ImageID), "attempt", job.Attempts)
+	err = q.execute(jctx, job)
+	if err != nil && job.Kind == model.ImportImageJobKind && ctx.Err() != nil {
+		// left running, the job is requeued on the next start
+		slog.WarnContext(jctx, "job interrupted by shutdown", "err", err)
+		return false
+	}
+	if err == nil {
+		err = dao.Succeed(jctx, job.ID)
+		if err != nil {
+			slog.ErrorContext(jctx, "cannot mark job as succeeded", "err", err)
+		}
+		return true
+	}
+
+	if job.Attempts >= job.MaxAttempts || errors.Is(err, ErrPermanent) {
+		slog.ErrorContext(jctx, "job failed", "attempt", job.Attempts, "err", err)
+		err = dao.Fail(jctx, job.ID, err.Error())
+		if err != nil {
+			slog.ErrorContext(jctx, "cannot mark job as failed", "err", err)
+		}
+		return true
+	}
+
+	runAt := time.Now().Add(backoff(first, job.Attempts))
+	slog.WarnContext(jctx, "job attempt failed, will retry", "attempt", job.Attempts, "run_at", runAt, "err", err)
+	err = dao.Retry(jctx, job.ID, err.Error(), runAt)
+	if err != nil {
+		slog.ErrorContext(jctx, "cannot reschedule job", "err", err)
+	}
+
+	return true
+}
+
+// maxBackoff caps exponential retry delay.
+const maxBackoff = 15 * time.Minute
+
+// backoff returns retry delay after the given (already performed) number of attempts, the
+// first delay is doubled for each next retry.
+func backoff(first time.Duration, attempts int16) time.Duration {
+	d := first
+	for i := int16(1); i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
+
+var ErrBootISONotReady = errors.New("boot.iso of the image is not generated yet")
+
+// checkBootISO returns error when boot.iso of the installation image is not generated yet,
+// installations are deployed as soon as the image ISO is uploaded but virtual media boot
+// needs boot.iso.
+func checkBootISO(ctx context.Context, system *model.SystemAppliance) error {
+	mac := db.NullMAC
+	if len(system.HwAddrs) > 0 {
+		mac = system.HwAddrs[0]
+	}
+	inst, _, err := db.GetInstallationDao(ctx).FindInstallationForMAC(ctx, mac)
+	if err != nil {
+		return fmt.Errorf("cannot find installation: %w", err)
+	}
+
+	image, err := db.GetImageDao(ctx).FindByID(ctx, inst.ImageID)
+	if err != nil {
+		return fmt.Errorf("cannot find image: %w", err)
+	}
+	if image.Status != model.ReadyImageStatus {
+		return fmt.Errorf("%w: %s is %s", ErrBootISONotReady, image.Name, image.Status.String())
+	}
+
+	return nil
+}
+
+// recordJobEvent records an event for the installation of a job, jobs enqueued for no
+// installation (e.g. by a power command) record nothing.
+func recordJobEvent(ctx context.Context, job *model.Job, kind model.EventKind, payload string) {
+	if job.InstallationID == nil {
+		return
+	}
+	db.RecordEvent(ctx, *job.InstallationID, kind, payload)
+}
+
+func (q *Queue) execute(ctx context.Context, job *model.Job) error {
+	if job.Kind == model.ImportImageJobKind {
+		return q.importImage(ctx, job)
+	}
+
+	if job.SystemID == nil {
+		return fmt.Errorf("%w: job with no system", ErrPermanent)
+	}
+	system, err := db.GetSystemDao(ctx).FindByIDRelated(ctx, *job.SystemID)
+	if err != nil {
+		return fmt.Errorf("cannot find system: %w", err)
+	}
+
+	switch job.Kind {
+	case model.BootNetworkJobKind:
+		if system.Appliance.Kind == model.RedfishVMediaApplianceKind {
+			err = checkBootISO(ctx, system)
+			if err != nil {
+				return err
+			}
+		}
+		err = metal.BootNetwork(ctx, system)
+		if err != nil {
+			return err
+		}
+		recordJobEvent(ctx, job, model.BootNetworkEventKind, system.Appliance.Name)
+	case model.BootLocalJobKind:
+		err = metal.BootLocal(ctx, system)
+		if err != nil {
+			return err
+		}
+		recordJobEvent(ctx, job, model.BootLocalEventKind, system.Appliance.Name)
+	case model.PowerOnJobKind:
+		return metal.PowerOn(ctx, system)
+	case model.PowerOffJobKind:
+		return metal.PowerOff(ctx, system, false)
+	case model.ForceOffJobKind:
+		return metal.PowerOff(ctx, system, true)
+	case model.PowerCycleJobKind:
+		return metal.PowerCycle(ctx, system)
+	default:
+		return fmt.Errorf("unknown job kind: %d", job.Kind)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"forester/internal/db"
+	"forester/internal/model"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := map[string]struct {
+		attempts int16
+		want     time.Duration
+	}{
+		"first":  {attempts: 1, want: 10 * time.Second},
+		"second": {attempts: 2, want: 20 * time.Second},
+		"fourth": {attempts: 4, want: 80 * time.Second},
+		"capped": {attempts: 100, want: maxBackoff},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := backoff(10*time.Second, tc.attempts)
+			diff := cmp.Diff(tc.want, got)
+			if diff != "" {
+				t.Fatalf(diff)
+			}
+		})
+	}
+}
+
+func TestPoolKinds(t *testing.T) {
+	seen := map[model.JobKind]int{}
+	for _, p := range []*pool{&powerPool, &importPool} {
+		for _, k := range p.kinds {
+			seen[k]++
+		}
+	}
+
+	for k := model.BootNetworkJobKind; k <= model.ImportImageJobKind; k++ {
+		if seen[k] != 1 {
+			t.Fatalf("job kind %s is executed by %d pools", k.String(), seen[k])
+		}
+	}
+}
+
+// eventDaoMock records created events
+type eventDaoMock struct {
+	db.InstallationEventDao
+	events []*model.InstallationEvent
+}
+
+func (dao *eventDaoMock) Create(ctx context.Context, e *model.InstallationEvent) error {
+	dao.events = append(dao.events, e)
+	return nil
+}
+
+func TestRecordJobEvent(t *testing.T) {
+	dao := &eventDaoMock{}
+	orig := db.GetInstallationEventDao
+	db.GetInstallationEventDao = func(ctx context.Context) db.InstallationEventDao { return dao }
+	t.Cleanup(func() { db.GetInstallationEventDao = orig })
+
+	ctx := context.Background()
+	recordJobEvent(ctx, &model.Job{Kind: model.BootLocalJobKind}, model.BootLocalEventKind, "power command")
+	if len(dao.events) != 0 {
+		t.Fatalf("event recorded for a job with no installation: %+v", dao.events[0])
+	}
+
+	instID := int64(42)
+	recordJobEvent(ctx, &model.Job{Kind: model.BootNetworkJobKind, InstallationID: &instID}, model.BootNetworkEventKind, "deploy")
+	if len(dao.events) != 1 || dao.events[0].InstallationID != instID || dao.events[0].Kind != model.BootNetworkEventKind {
+		t.Fatalf("unexpected events: %+v", dao.events)
+	}
+}
@@ -6,9 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"net"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -49,76 +49,98 @@ func updateDomainBootDeviceXML(ctx context.Context, xmlString, device string) (s
 
 var ErrUnsupportedLibvirtScheme = errors.New("unsupported libvirt URI scheme")
 
-func libvirtFromURI(ctx context.Context, uri string) (*libvirt.Libvirt, error) {
+// libvirtFromAppliance creates libvirt client for appliance URI, credentials are not
+// used, remote connections must be allowed without authentication or via TLS certificates.
+func libvirtFromAppliance(ctx context.Context, app *model.Appliance) (*libvirt.Libvirt, error) {
 	var dialer socket.Dialer
-	var v *libvirt.Libvirt
 
-	slog.DebugContext(ctx, "connecting to libvirt", "uri", uri)
-	parsed, err := url.Parse(uri)
+	parsed, err := url.Parse(app.URI)
 	if err != nil {
 		return nil, fmt.Errorf("cannot parse: %w", err)
 	}
+	slog.DebugContext(ctx, "connecting to libvirt", "uri", parsed.Redacted())
 
 	if parsed.Scheme == "qemu" {
-		v = libvirt.NewWithDialer(dialers.NewLocal())
+		dialer = dialers.NewLocal()
 	} else if parsed.Scheme == "unix" {
 		dialer = dialers.NewLocal(dialers.WithSocket(parsed.Path))
-		v = libvirt.NewWithDialer(dialer)
 	} else if parsed.Scheme == "tcp" {
-		host, _, _ := net.SplitHostPort(parsed.Host)
-		dialer = dialers.NewRemote(host, dialers.UsePort(parsed.Port()))
-		v = libvirt.NewWithDialer(dialer)
+		dialer = dialers.NewRemote(parsed.Hostname(), dialers.UsePort(parsed.Port()))
+	} else if parsed.Scheme == "tls" {
+		var opts []dialers.TLSOption
+		if parsed.Port() != "" {
+			opts = append(opts, dialers.UseTLSPort(parsed.Port()))
+		}
+		dialer = dialers.NewTLS(parsed.Hostname(), opts...)
 	} else {
 		return nil, ErrUnsupportedLibvirtScheme
 	}
 
-	return v, nil
+	return libvirt.NewWithDialer(dialer), nil
 }
 
-func bootDevice(ctx context.Context, system *model.SystemAppliance, device string) error {
+// redactedURI returns appliance URI without password for logs and errors
+func redactedURI(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return "(invalid)"
+	}
+
+	return parsed.Redacted()
+}
+
+// libvirtDomain connects to the appliance of a system and looks up its domain,
+// the returned connection must be disconnected by the caller.
+func libvirtDomain(ctx context.Context, system *model.SystemAppliance) (*libvirt.Libvirt, libvirt.Domain, error) {
 	daoApp := db.GetApplianceDao(ctx)
 	app, err := daoApp.FindByID(ctx, *system.ApplianceID)
 	if err != nil {
-		return fmt.Errorf("cannot find appliance with id %d: %w", system.ApplianceID, err)
+		return nil, libvirt.Domain{}, fmt.Errorf("cannot find appliance with id %d: %w", system.ApplianceID, err)
 	}
 
-	v, err := libvirtFromURI(ctx, app.URI)
+	v, err := libvirtFromAppliance(ctx, app)
 	if err != nil {
-		return fmt.Errorf("URI '%s' error: %w", app.URI, err)
+		return nil, libvirt.Domain{}, fmt.Errorf("URI '%s' error: %w", redactedURI(app.URI), err)
 	}
 	if err := v.Connect(); err != nil {
-		return fmt.Errorf("cannot connect: %w", err)
+		return nil, libvirt.Domain{}, fmt.Errorf("cannot connect: %w", err)
 	}
-	defer v.Disconnect()
 
 	uid := uuid.MustParse(*system.UID)
 	d, err := v.DomainLookupByUUID(libvirt.UUID(uid))
 	if err != nil {
-		return fmt.Errorf("cannot lookup %s: %w", uid.String(), err)
-	}
-
-	xmlString, err := v.DomainGetXMLDesc(d, 0)
-	if err != nil {
-		return fmt.Errorf("cannot get domain: %w", err)
+		_ = v.Disconnect()
+		return nil, libvirt.Domain{}, fmt.Errorf("cannot lookup %s: %w", uid.String(), err)
 	}
 
-	newXML, err := updateDomainBootDeviceXML(ctx, xmlString, device)
+	return v, d, nil
+}
 
+func domainPowerState(v *libvirt.Libvirt, d libvirt.Domain) (PowerState, error) {
+	state, _, err := v.DomainGetState(d, 0)
 	if err != nil {
-		return fmt.Errorf("cannot update domain XML: %w", err)
+		return UnknownPowerState, fmt.Errorf("cannot get domain state: %w", err)
 	}
 
-	d, err = v.DomainDefineXML(newXML)
-	if err != nil {
-		return fmt.Errorf("cannot redefine domain: %w", err)
+	switch libvirt.DomainState(state) {
+	case libvirt.DomainRunning, libvirt.DomainBlocked, libvirt.DomainPaused, libvirt.DomainPmsuspended:
+		return OnPowerState, nil
+	case libvirt.DomainShutdown:
+		return PoweringOffState, nil
+	case libvirt.DomainShutoff, libvirt.DomainCrashed:
+		return OffPowerState, nil
 	}
-	state, _, err := v.DomainGetState(d, 0)
+	return UnknownPowerState, nil
+}
+
+// cycleDomain starts a domain, running domain is destroyed first.
+func cycleDomain(ctx context.Context, v *libvirt.Libvirt, d libvirt.Domain) error {
+	state, err := domainPowerState(v, d)
 	if err != nil {
-		return fmt.Errorf("cannot get domain state: %w", err)
+		return err
 	}
 
-	if state == 1 {
-		// domain is running: changes in boot order require full power off
+	if state == OnPowerState || state == PoweringOffState {
 		slog.InfoContext(ctx, "force power cycling domain", "name", d.Name)
 		err = v.DomainDestroy(d)
 		if err != nil {
@@ -137,10 +159,126 @@ func bootDevice(ctx context.Context, system *model.SystemAppliance, device strin
 	return nil
 }
 
+var ErrDomainPoweringOff = errors.New("domain is still shutting down")
+
+var (
+	// shutdownTimeout is how long a domain which is shutting down is waited for before start
+	shutdownTimeout = 2 * time.Minute
+
+	// shutdownPollInterval is the interval of domain state checks during shutdown
+	shutdownPollInterval = 2 * time.Second
+)
+
+// waitDomainShutoff waits until a domain which is shutting down is off.
+func waitDomainShutoff(ctx context.Context, v *libvirt.Libvirt, d libvirt.Domain) error {
+	deadline := time.Now().Add(shutdownTimeout)
+	for {
+		state, err := domainPowerState(v, d)
+		if err != nil {
+			return err
+		}
+		if state != PoweringOffState {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: %s after %s", ErrDomainPoweringOff, d.Name, shutdownTimeout)
+		}
+
+		slog.DebugContext(ctx, "waiting for domain shutdown", "name", d.Name)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(shutdownPollInterval):
+		}
+	}
+}
+
+// powerOnDomain starts a domain unless it is running, domain which is shutting down
+// is started once it is off.
+func powerOnDomain(ctx context.Context, v *libvirt.Libvirt, d libvirt.Domain) error {
+	state, err := domainPowerState(v, d)
+	if err != nil {
+		return err
+	}
+	if state == OnPowerState {
+		slog.InfoContext(ctx, "domain is already running", "name", d.Name)
+		return nil
+	}
+
+	if state == PoweringOffState {
+		if err := waitDomainShutoff(ctx, v, d); err != nil {
+			return err
+		}
+	}
+
+	slog.InfoContext(ctx, "creating domain", "name", d.Name)
+	err = v.DomainCreate(d)
+	if err != nil {
+		return fmt.Errorf("cannot create domain: %w", err)
+	}
+
+	return nil
+}
+
+// powerOffDomain shuts down or destroys a domain unless it is off.
+func powerOffDomain(ctx context.Context, v *libvirt.Libvirt, d libvirt.Domain, force bool) error {
+	state, err := domainPowerState(v, d)
+	if err != nil {
+		return err
+	}
+	if state == OffPowerState {
+		slog.InfoContext(ctx, "domain is not running", "name", d.Name)
+		return nil
+	}
+
+	if force {
+		slog.InfoContext(ctx, "destroying domain", "name", d.Name)
+		err = v.DomainDestroy(d)
+		if err != nil {
+			return fmt.Errorf("cannot destroy domain: %w", err)
+		}
+	} else {
+		slog.InfoContext(ctx, "shutting down domain", "name", d.Name)
+		err = v.DomainShutdown(d)
+		if err != nil {
+			return fmt.Errorf("cannot shutdown domain: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func bootDevice(ctx context.Context, system *model.SystemAppliance, device string) error {
+	v, d, err := libvirtDomain(ctx, system)
+	if err != nil {
+		return err
+	}
+	defer v.Disconnect()
+
+	xmlString, err := v.DomainGetXMLDesc(d, 0)
+	if err != nil {
+		return fmt.Errorf("cannot get domain: %w", err)
+	}
+
+	newXML, err := updateDomainBootDeviceXML(ctx, xmlString, device)
+
+	if err != nil {
+		return fmt.Errorf("cannot update domain XML: %w", err)
+	}
+
+	d, err = v.DomainDefineXML(newXML)
+	if err != nil {
+		return fmt.Errorf("cannot redefine domain: %w", err)
+	}
+
+	// changes in boot order require full power off
+	return cycleDomain(ctx, v, d)
+}
+
 func (m LibvirtMetal) Enlist(ctx context.Context, app *model.Appliance, pattern string) ([]*EnlistResult, error) {
-	v, err := libvirtFromURI(ctx, app.URI)
+	v, err := libvirtFromAppliance(ctx, app)
 	if err != nil {
-		return nil, fmt.Errorf("URI '%s' error: %w", app.URI, err)
+		return nil, fmt.Errorf("URI '%s' error: %w", redactedURI(app.URI), err)
 	}
 
 	if err := v.Connect(); err != nil {
@@ -184,6 +322,12 @@ func (m LibvirtMetal) Enlist(ctx context.Context, app *model.Appliance, pattern
 				"vm_emulator":   domain.Devices.Emulator,
 				"vm_bootloader": domain.Bootloader,
 			}
+			if domain.OS != nil && domain.OS.Type != nil && domain.OS.Type.Arch != "" {
+				facts[model.ArchFact] = domain.OS.Type.Arch
+			}
+			if domain.OS != nil && domain.OS.Loader != nil {
+				facts[model.SecureBootFact] = strconv.FormatBool(domain.OS.Loader.Secure == "yes")
+			}
 
 			er := &EnlistResult{
 				HwAddrs: addrs,
@@ -217,3 +361,43 @@ func (m LibvirtMetal) BootNetwork(ctx context.Context, system *model.SystemAppli
 func (m LibvirtMetal) BootLocal(ctx context.Context, system *model.SystemAppliance) error {
 	return bootDevice(ctx, system, "hd")
 }
+
+func (m LibvirtMetal) PowerState(ctx context.Context, system *model.SystemAppliance) (PowerState, error) {
+	v, d, err := libvirtDomain(ctx, system)
+	if err != nil {
+		return UnknownPowerState, err
+	}
+	defer v.Disconnect()
+
+	return domainPowerState(v, d)
+}
+
+func (m LibvirtMetal) PowerOn(ctx context.Context, system *model.SystemAppliance) error {
+	v, d, err := libvirtDomain(ctx, system)
+	if err != nil {
+		return err
+	}
+	defer v.Disconnect()
+
+	return powerOnDomain(ctx, v, d)
+}
+
+func (m LibvirtMetal) PowerOff(ctx context.Context, system *model.SystemAppliance, force bool) error {
+	v, d, err := libvirtDomain(ctx, system)
+	if err != nil {
+		return err
+	}
+	defer v.Disconnect()
+
+	return powerOffDomain(ctx, v, d, force)
+}
+
+func (m LibvirtMetal) PowerCycle(ctx context.Context, system *model.SystemAppliance) error {
+	v, d, err := libvirtDomain(ctx, system)
+	if err != nil {
+		return err
+	}
+	defer v.Disconnect()
+
+	return cycleDomain(ctx, v, d)
+}
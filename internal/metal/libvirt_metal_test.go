@@ -0,0 +1,230 @@
+package metal
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	libvirt "github.com/digitalocean/go-libvirt"
+	"github.com/digitalocean/go-libvirt/socket/dialers"
+	"github.com/stretchr/testify/require"
+)
+
+// libvirt remote protocol procedures handled by libvirtdMockup
+const (
+	remoteProcDomainCreate   = 9
+	remoteProcDomainDestroy  = 12
+	remoteProcDomainShutdown = 33
+	remoteProcAuthList       = 66
+	remoteProcDomainGetState = 212
+)
+
+// libvirtdMockup serves a single domain over libvirt remote protocol and records domain calls.
+type libvirtdMockup struct {
+	mu    sync.Mutex
+	state libvirt.DomainState
+	calls []string
+
+	// shutdownPolls is the number of state queries a shutting down domain needs to
+	// power off, negative value keeps it shutting down
+	shutdownPolls int
+}
+
+func (f *libvirtdMockup) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		header := make([]byte, 28)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		args := make([]byte, binary.BigEndian.Uint32(header)-28)
+		if _, err := io.ReadFull(conn, args); err != nil {
+			return
+		}
+
+		reply, failure := f.call(binary.BigEndian.Uint32(header[12:]))
+		var status uint32
+		if failure != "" {
+			status, reply = 1, xdrError(failure)
+		}
+
+		binary.BigEndian.PutUint32(header, uint32(28+len(reply)))
+		binary.BigEndian.PutUint32(header[16:], 1) // reply
+		binary.BigEndian.PutUint32(header[24:], status)
+		if _, err := conn.Write(append(header, reply...)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *libvirtdMockup) call(proc uint32) ([]byte, string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch proc {
+	case remoteProcAuthList:
+		return xdrInts(0), ""
+	case remoteProcDomainGetState:
+		if f.state == libvirt.DomainShutdown && f.shutdownPolls >= 0 {
+			if f.shutdownPolls == 0 {
+				f.state = libvirt.DomainShutoff
+			}
+			f.shutdownPolls--
+		}
+		return xdrInts(int32(f.state), 0), ""
+	case remoteProcDomainCreate:
+		f.calls = append(f.calls, "create")
+		if f.state != libvirt.DomainShutoff {
+			return nil, "Requested operation is not valid: domain is already running"
+		}
+		f.state = libvirt.DomainRunning
+	case remoteProcDomainDestroy:
+		f.calls = append(f.calls, "destroy")
+		f.state = libvirt.DomainShutoff
+	case remoteProcDomainShutdown:
+		f.calls = append(f.calls, "shutdown")
+		f.state = libvirt.DomainShutdown
+	}
+
+	return nil, ""
+}
+
+func xdrInts(values ...int32) []byte {
+	buf := &bytes.Buffer{}
+	for _, v := range values {
+		_ = binary.Write(buf, binary.BigEndian, v)
+	}
+	return buf.Bytes()
+}
+
+// xdrError encodes remote_error with a message
+func xdrError(message string) []byte {
+	buf := bytes.NewBuffer(xdrInts(55, 10, 1, int32(len(message))))
+	buf.WriteString(message)
+	buf.Write(make([]byte, (4-len(message)%4)%4))
+	// level, dom, str1, str2, str3, int1, int2, net
+	buf.Write(xdrInts(2, 0, 0, 0, 0, 0, 0, 0))
+	return buf.Bytes()
+}
+
+func connectLibvirtdMockup(t *testing.T, f *libvirtdMockup) *libvirt.Libvirt {
+	client, server := net.Pipe()
+	go f.serve(server)
+
+	v := libvirt.NewWithDialer(dialers.NewAlreadyConnected(client))
+	require.NoError(t, v.Connect())
+	t.Cleanup(func() { _ = v.Disconnect() })
+
+	return v
+}
+
+func TestLibvirtPower(t *testing.T) {
+	shutdownPollInterval = time.Millisecond
+	shutdownTimeout = 100 * time.Millisecond
+
+	type operation func(ctx context.Context, v *libvirt.Libvirt, d libvirt.Domain) error
+	powerOn := func(ctx context.Context, v *libvirt.Libvirt, d libvirt.Domain) error { return powerOnDomain(ctx, v, d) }
+
+	tests := map[string]struct {
+		state         libvirt.DomainState
+		shutdownPolls int
+		operation     operation
+		calls         []string
+		want          libvirt.DomainState
+		err           error
+	}{
+		"on when off": {
+			state:     libvirt.DomainShutoff,
+			operation: powerOn,
+			calls:     []string{"create"},
+			want:      libvirt.DomainRunning,
+		},
+		"on when on": {
+			state:     libvirt.DomainRunning,
+			operation: powerOn,
+			want:      libvirt.DomainRunning,
+		},
+		"on when powering off": {
+			state:         libvirt.DomainShutdown,
+			shutdownPolls: 3,
+			operation:     powerOn,
+			calls:         []string{"create"},
+			want:          libvirt.DomainRunning,
+		},
+		"on when stuck powering off": {
+			state:         libvirt.DomainShutdown,
+			shutdownPolls: -1,
+			operation:     powerOn,
+			want:          libvirt.DomainShutdown,
+			err:           ErrDomainPoweringOff,
+		},
+		"off graceful": {
+			state: libvirt.DomainRunning,
+			operation: func(ctx context.Context, v *libvirt.Libvirt, d libvirt.Domain) error {
+				return powerOffDomain(ctx, v, d, false)
+			},
+			calls: []string{"shutdown"},
+			want:  libvirt.DomainShutdown,
+		},
+		"off forced": {
+			state: libvirt.DomainRunning,
+			operation: func(ctx context.Context, v *libvirt.Libvirt, d libvirt.Domain) error {
+				return powerOffDomain(ctx, v, d, true)
+			},
+			calls: []string{"destroy"},
+			want:  libvirt.DomainShutoff,
+		},
+		"off when off": {
+			state: libvirt.DomainShutoff,
+			operation: func(ctx context.Context, v *libvirt.Libvirt, d libvirt.Domain) error {
+				return powerOffDomain(ctx, v, d, true)
+			},
+			want: libvirt.DomainShutoff,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := &libvirtdMockup{state: tc.state, shutdownPolls: tc.shutdownPolls}
+			v := connectLibvirtdMockup(t, f)
+
+			err := tc.operation(context.Background(), v, libvirt.Domain{Name: "vm1"})
+			require.ErrorIs(t, err, tc.err)
+			require.Equal(t, tc.calls, f.calls)
+			require.Equal(t, tc.want, f.state)
+		})
+	}
+}
+
+func TestLibvirtPowerState(t *testing.T) {
+	tests := map[libvirt.DomainState]PowerState{
+		libvirt.DomainRunning:  OnPowerState,
+		libvirt.DomainPaused:   OnPowerState,
+		libvirt.DomainShutdown: PoweringOffState,
+		libvirt.DomainShutoff:  OffPowerState,
+		libvirt.DomainCrashed:  OffPowerState,
+		libvirt.DomainNostate:  UnknownPowerState,
+	}
+
+	for state, want := range tests {
+		f := &libvirtdMockup{state: state, shutdownPolls: -1}
+		v := connectLibvirtdMockup(t, f)
+
+		got, err := domainPowerState(v, libvirt.Domain{Name: "vm1"})
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+}
+
+func TestLibvirtCreateRunning(t *testing.T) {
+	f := &libvirtdMockup{state: libvirt.DomainRunning}
+	v := connectLibvirtdMockup(t, f)
+
+	err := v.DomainCreate(libvirt.Domain{Name: "vm1"})
+	require.ErrorContains(t, err, "already running")
+}
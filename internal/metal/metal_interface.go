@@ -11,6 +11,34 @@ type Metal interface {
 	Enlist(ctx context.Context, app *model.Appliance, pattern string) ([]*EnlistResult, error)
 	BootNetwork(ctx context.Context, system *model.SystemAppliance) error
 	BootLocal(ctx context.Context, system *model.SystemAppliance) error
+	PowerState(ctx context.Context, system *model.SystemAppliance) (PowerState, error)
+	PowerOn(ctx context.Context, system *model.SystemAppliance) error
+	PowerOff(ctx context.Context, system *model.SystemAppliance, force bool) error
+	PowerCycle(ctx context.Context, system *model.SystemAppliance) error
+}
+
+type PowerState int16
+
+const (
+	UnknownPowerState PowerState = iota
+	OnPowerState      PowerState = iota
+	OffPowerState     PowerState = iota
+	PoweringOnState   PowerState = iota
+	PoweringOffState  PowerState = iota
+)
+
+func (ps PowerState) String() string {
+	switch ps {
+	case OnPowerState:
+		return "on"
+	case OffPowerState:
+		return "off"
+	case PoweringOnState:
+		return "powering on"
+	case PoweringOffState:
+		return "powering off"
+	}
+	return "unknown"
 }
 
 type EnlistResult struct {
@@ -27,6 +55,10 @@ var redfishMetal Metal = RedfishMetal{}
 
 var redfishManualMetal Metal = RedfishMetal{Manual: true}
 
+var redfishVMediaMetal Metal = RedfishMetal{VirtualMedia: true}
+
+var ipmiMetal Metal = IpmiMetal{}
+
 func ForKind(kind model.ApplianceKind) Metal {
 	switch kind {
 	case model.LibvirtApplianceKind:
@@ -35,6 +67,10 @@ func ForKind(kind model.ApplianceKind) Metal {
 		return redfishMetal
 	case model.RedfishManualApplianceKind:
 		return redfishManualMetal
+	case model.RedfishVMediaApplianceKind:
+		return redfishVMediaMetal
+	case model.IpmiApplianceKind:
+		return ipmiMetal
 	}
 	return noopMetal
 }
@@ -48,28 +84,63 @@ var ErrSystemWithNoAppliance = errors.New("system has no appliance associated")
 
 var ErrSystemWithNoUID = errors.New("system has no UID set")
 
-func BootNetwork(ctx context.Context, system *model.SystemAppliance) error {
+// forSystem returns metal implementation for a system with an appliance and UID.
+func forSystem(system *model.SystemAppliance) (Metal, error) {
 	if system.ApplianceID == nil {
-		return ErrSystemWithNoAppliance
+		return nil, ErrSystemWithNoAppliance
 	}
 
 	if system.UID == nil {
-		return ErrSystemWithNoUID
+		return nil, ErrSystemWithNoUID
 	}
 
-	metal := ForKind(system.Appliance.Kind)
+	return ForKind(system.Appliance.Kind), nil
+}
+
+func BootNetwork(ctx context.Context, system *model.SystemAppliance) error {
+	metal, err := forSystem(system)
+	if err != nil {
+		return err
+	}
 	return metal.BootNetwork(ctx, system)
 }
 
 func BootLocal(ctx context.Context, system *model.SystemAppliance) error {
-	if system.ApplianceID == nil {
-		return ErrSystemWithNoAppliance
+	metal, err := forSystem(system)
+	if err != nil {
+		return err
 	}
+	return metal.BootLocal(ctx, system)
+}
 
-	if system.UID == nil {
-		return ErrSystemWithNoUID
+func GetPowerState(ctx context.Context, system *model.SystemAppliance) (PowerState, error) {
+	metal, err := forSystem(system)
+	if err != nil {
+		return UnknownPowerState, err
 	}
+	return metal.PowerState(ctx, system)
+}
 
-	metal := ForKind(system.Appliance.Kind)
-	return metal.BootLocal(ctx, system)
+func PowerOn(ctx context.Context, system *model.SystemAppliance) error {
+	metal, err := forSystem(system)
+	if err != nil {
+		return err
+	}
+	return metal.PowerOn(ctx, system)
+}
+
+func PowerOff(ctx context.Context, system *model.SystemAppliance, force bool) error {
+	metal, err := forSystem(system)
+	if err != nil {
+		return err
+	}
+	return metal.PowerOff(ctx, system, force)
+}
+
+func PowerCycle(ctx context.Context, system *model.SystemAppliance) error {
+	metal, err := forSystem(system)
+	if err != nil {
+		return err
+	}
+	return metal.PowerCycle(ctx, system)
 }
@@ -23,3 +23,23 @@ func (m NoopMetal) BootLocal(ctx context.Context, system *model.SystemAppliance)
 	slog.InfoContext(ctx, "noop operation", "function", "BootLocal")
 	return nil
 }
+
+func (m NoopMetal) PowerState(ctx context.Context, system *model.SystemAppliance) (PowerState, error) {
+	slog.InfoContext(ctx, "noop operation", "function", "PowerState")
+	return UnknownPowerState, nil
+}
+
+func (m NoopMetal) PowerOn(ctx context.Context, system *model.SystemAppliance) error {
+	slog.InfoContext(ctx, "noop operation", "function", "PowerOn")
+	return nil
+}
+
+func (m NoopMetal) PowerOff(ctx context.Context, system *model.SystemAppliance, force bool) error {
+	slog.InfoContext(ctx, "noop operation", "function", "PowerOff")
+	return nil
+}
+
+func (m NoopMetal) PowerCycle(ctx context.Context, system *model.SystemAppliance) error {
+	slog.InfoContext(ctx, "noop operation", "function", "PowerCycle")
+	return nil
+}
@@ -2,9 +2,12 @@ package metal
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"math"
+	"net/url"
 	"regexp"
 	"slices"
 	"strconv"
@@ -14,33 +17,82 @@ import (
 	"github.com/stmcginnis/gofish/redfish"
 
 	"forester/internal/config"
+	"forester/internal/db"
 	"forester/internal/logging"
 	"forester/internal/model"
 )
 
 type RedfishMetal struct {
+	// Manual disables all power operations
 	Manual bool
+
+	// VirtualMedia boots the generated boot.iso via virtual CD instead of network boot
+	VirtualMedia bool
+}
+
+// redactPatterns match secrets in HTTP dumps: authentication headers and password
+// of session creation requests
+var redactPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?mi)^((?:X-Auth-Token|Authorization):[ \t]*)[^\r\n]*()`),
+	regexp.MustCompile(`("Password"\s*:\s*")(?:[^"\\]|\\.)*(")`),
+}
+
+// redactWriter masks secrets of HTTP dumps before writing them to the log.
+type redactWriter struct {
+	w io.Writer
+}
+
+func (rw redactWriter) Write(p []byte) (int, error) {
+	out := p
+	for _, re := range redactPatterns {
+		out = re.ReplaceAll(out, []byte(`${1}****${2}`))
+	}
+
+	_, err := rw.w.Write(out)
+	return len(p), err
 }
 
 func configFromApp(ctx context.Context, app *model.Appliance) gofish.ClientConfig {
 	sw := logging.SlogDualWriter{Logger: slog.Default(), Level: slog.LevelInfo, Context: ctx}
-	return gofish.ClientConfig{
+	cfg := gofish.ClientConfig{
 		Endpoint:   app.URI,
+		Username:   app.Username,
+		Password:   app.Password,
 		Insecure:   true,
-		DumpWriter: sw,
+		DumpWriter: redactWriter{w: sw},
+	}
+
+	if u, err := url.Parse(app.URI); err == nil && u.User != nil {
+		// credentials embedded in the URI are only used when not stored separately
+		if cfg.Username == "" {
+			cfg.Username = u.User.Username()
+			cfg.Password, _ = u.User.Password()
+		}
+		u.User = nil
+		cfg.Endpoint = u.String()
 	}
+
+	return cfg
 }
 
-func (m RedfishMetal) Enlist(ctx context.Context, app *model.Appliance, pattern string) ([]*EnlistResult, error) {
-	config := configFromApp(ctx, app)
+// redfishOIDFact is the fact holding Redfish resource path of a system
+const redfishOIDFact = "redfish_oid"
 
-	c, err := gofish.Connect(config)
+func (m RedfishMetal) Enlist(ctx context.Context, app *model.Appliance, pattern string) ([]*EnlistResult, error) {
+	c, err := redfishClient(ctx, app)
 	if err != nil {
 		return nil, fmt.Errorf("redfish error: %w", err)
 	}
-	defer c.Logout()
 
 	rSystems, err := c.Service.Systems()
+	if isUnauthorized(err) {
+		evictSession(app, c)
+		c, err = redfishClient(ctx, app)
+		if err != nil {
+			return nil, fmt.Errorf("redfish error: %w", err)
+		}
+		rSystems, err = c.Service.Systems()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("redfish error: %w", err)
 	}
@@ -69,7 +121,7 @@ func (m RedfishMetal) Enlist(ctx context.Context, app *model.Appliance, pattern
 			}
 
 			facts := map[string]string{
-				"redfish_oid":             rSystem.ODataID,
+				redfishOIDFact:            rSystem.ODataID,
 				"redfish_model":           rSystem.Model,
 				"redfish_name":            rSystem.Name,
 				"redfish_description":     rSystem.Description,
@@ -104,22 +156,107 @@ func (m RedfishMetal) Enlist(ctx context.Context, app *model.Appliance, pattern
 	return result, nil
 }
 
-func (m RedfishMetal) BootNetwork(ctx context.Context, system *model.SystemAppliance) error {
-	if m.Manual {
-		return nil
+// redfishSystem connects to the appliance and finds the computer system by its UUID, the
+// returned client shares the appliance session and must not be logged out by the caller.
+func redfishSystem(ctx context.Context, system *model.SystemAppliance) (*gofish.APIClient, *redfish.ComputerSystem, error) {
+	c, rSystem, err := findRedfishSystem(ctx, system)
+	if isUnauthorized(err) {
+		// cached session was closed or timed out on the BMC side
+		slog.DebugContext(ctx, "redfish session rejected, reconnecting", "appliance", system.Appliance.Name)
+		evictSession(&system.Appliance, c)
+		c, rSystem, err = findRedfishSystem(ctx, system)
+	}
+	if err != nil {
+		return nil, nil, err
 	}
 
-	cfg := configFromApp(ctx, &system.Appliance)
+	slog.DebugContext(ctx, "found redfish system",
+		"id", rSystem.ID,
+		"uuid", rSystem.UUID,
+		"uid", *system.UID,
+		"power_state", rSystem.PowerState,
+		"reset_types", rSystem.SupportedResetTypes,
+		"boot_override", rSystem.Boot.BootSourceOverrideMode,
+	)
+	return c, rSystem, nil
+}
 
-	c, err := gofish.Connect(cfg)
+func findRedfishSystem(ctx context.Context, system *model.SystemAppliance) (*gofish.APIClient, *redfish.ComputerSystem, error) {
+	c, err := redfishClient(ctx, &system.Appliance)
 	if err != nil {
-		return fmt.Errorf("redfish error: %w", err)
+		return nil, nil, fmt.Errorf("redfish error: %w", err)
+	}
+
+	// resource path is stored during enlistment, avoid fetching all systems
+	if oid := system.Facts.FactsMap()[redfishOIDFact]; oid != "" {
+		rSystem, err := redfish.GetComputerSystem(c, oid)
+		if err == nil && rSystem.UUID == *system.UID {
+			return c, rSystem, nil
+		} else if isUnauthorized(err) {
+			return c, nil, fmt.Errorf("redfish error: %w", err)
+		}
+		slog.DebugContext(ctx, "redfish system not found by resource path", "oid", oid, "uid", *system.UID, "err", err)
 	}
-	defer c.Logout()
 
 	rSystems, err := c.Service.Systems()
 	if err != nil {
-		return fmt.Errorf("redfish error: %w", err)
+		return c, nil, fmt.Errorf("redfish error: %w", err)
+	}
+
+	for _, rSystem := range rSystems {
+		if rSystem.UUID == *system.UID {
+			return c, rSystem, nil
+		}
+		slog.DebugContext(ctx, "checking redfish system", "id", rSystem.ID, "uuid", rSystem.UUID, "uid", *system.UID)
+	}
+
+	return c, nil, fmt.Errorf("%w: %s", ErrRedfishSystemNotFound, *system.UID)
+}
+
+var ErrRedfishSystemNotFound = errors.New("redfish system not found")
+
+// resetSystem performs hard restart of a system or power on when it is off.
+func resetSystem(ctx context.Context, rSystem *redfish.ComputerSystem) error {
+	var err error
+	if rSystem.PowerState == redfish.OffPowerState {
+		err = rSystem.Reset(redfish.OnResetType)
+	} else if slices.Contains(rSystem.SupportedResetTypes, redfish.ForceRestartResetType) {
+		err = rSystem.Reset(redfish.ForceRestartResetType)
+	} else if slices.Contains(rSystem.SupportedResetTypes, redfish.PowerCycleResetType) {
+		err = rSystem.Reset(redfish.PowerCycleResetType)
+	} else if slices.Contains(rSystem.SupportedResetTypes, redfish.ForceOffResetType) || len(rSystem.SupportedResetTypes) == 0 {
+		if rSystem.PowerState == redfish.OnPowerState {
+			err = rSystem.Reset(redfish.ForceOffResetType)
+			if err != nil {
+				return fmt.Errorf("redfish powercycle error: %w", err)
+			}
+
+			// Some very slow sytems might not even poweroff by this time
+			slog.DebugContext(ctx, "waiting for power off", "id", rSystem.ID)
+			time.Sleep(time.Second * 10)
+		}
+
+		err = rSystem.Reset(redfish.OnResetType)
+	}
+	if err != nil {
+		return fmt.Errorf("redfish powercycle error: %w", err)
+	}
+
+	return nil
+}
+
+func (m RedfishMetal) BootNetwork(ctx context.Context, system *model.SystemAppliance) error {
+	if m.Manual {
+		return nil
+	}
+
+	c, rSystem, err := redfishSystem(ctx, system)
+	if err != nil {
+		return err
+	}
+
+	if m.VirtualMedia {
+		return bootVirtualMedia(ctx, c, rSystem, system)
 	}
 
 	uri := fmt.Sprintf("%s/boot/shim.efi", config.BaseURL())
@@ -133,56 +270,126 @@ func (m RedfishMetal) BootNetwork(ctx context.Context, system *model.SystemAppli
 		BootSourceOverrideEnabled: redfish.OnceBootSourceOverrideEnabled,
 	}
 
-	for _, rSystem := range rSystems {
-		if rSystem.UUID == *system.UID {
-			slog.DebugContext(ctx, "found redfish system",
-				"id", rSystem.ID,
-				"uuid", rSystem.UUID,
-				"uid", *system.UID,
-				"reset_types", rSystem.SupportedResetTypes,
-				"boot_override", rSystem.Boot.BootSourceOverrideMode,
-			)
-			if rSystem.Boot.BootSourceOverrideMode == redfish.UEFIBootSourceOverrideMode {
-				// EFI boot
-				bootOverride.BootSourceOverrideTarget = redfish.UefiHTTPBootSourceOverrideTarget
-				// TODO: only set when in rSystem.Boot.AllowableValues (not yet implemented in the library)
-				bootOverride.HTTPBootURI = uri
-			} else {
-				// Legacy (aka BIOS) boot - some systems will actually PXE boot in UEFI mode
-				bootOverride.BootSourceOverrideTarget = redfish.PxeBootSourceOverrideTarget
-			}
+	if rSystem.Boot.BootSourceOverrideMode == redfish.UEFIBootSourceOverrideMode {
+		// EFI boot
+		bootOverride.BootSourceOverrideTarget = redfish.UefiHTTPBootSourceOverrideTarget
+		// TODO: only set when in rSystem.Boot.AllowableValues (not yet implemented in the library)
+		bootOverride.HTTPBootURI = uri
+	} else {
+		// Legacy (aka BIOS) boot - some systems will actually PXE boot in UEFI mode
+		bootOverride.BootSourceOverrideTarget = redfish.PxeBootSourceOverrideTarget
+	}
+
+	err = rSystem.SetBoot(bootOverride)
+	if err != nil {
+		return fmt.Errorf("redfish error: %w", err)
+	}
+
+	return resetSystem(ctx, rSystem)
+}
+
+// virtualMediaCD finds CD or DVD virtual media of a system, media of the system (Redfish 1.13+)
+// are preferred over media of its managers.
+func virtualMediaCD(ctx context.Context, c *gofish.APIClient, rSystem *redfish.ComputerSystem) (*redfish.VirtualMedia, error) {
+	media, err := rSystem.VirtualMedia()
+	if err != nil {
+		return nil, fmt.Errorf("redfish error: %w", err)
+	}
 
-			err := rSystem.SetBoot(bootOverride)
+	if len(media) == 0 {
+		for _, link := range rSystem.ManagedBy {
+			manager, err := redfish.GetManager(c, link)
 			if err != nil {
-				return fmt.Errorf("redfish error: %w", err)
+				return nil, fmt.Errorf("redfish error: %w", err)
 			}
 
-			if slices.Contains(rSystem.SupportedResetTypes, redfish.ForceRestartResetType) {
-				err = rSystem.Reset(redfish.ForceRestartResetType)
-			} else if slices.Contains(rSystem.SupportedResetTypes, redfish.PowerCycleResetType) {
-				err = rSystem.Reset(redfish.PowerCycleResetType)
-			} else if slices.Contains(rSystem.SupportedResetTypes, redfish.ForceOffResetType) || len(rSystem.SupportedResetTypes) == 0 {
-				if rSystem.PowerState == redfish.OnPowerState {
-					err = rSystem.Reset(redfish.ForceOffResetType)
-					if err != nil {
-						return fmt.Errorf("redfish powercycle error: %w", err)
-					}
-
-					// Some very slow sytems might not even poweroff by this time
-					time.Sleep(time.Second * 10)
-				}
-
-				err = rSystem.Reset(redfish.OnResetType)
-			}
+			managerMedia, err := manager.VirtualMedia()
 			if err != nil {
-				return fmt.Errorf("redfish powercycle error: %w", err)
+				return nil, fmt.Errorf("redfish error: %w", err)
 			}
+			media = append(media, managerMedia...)
+		}
+	}
 
-		} else {
-			slog.DebugContext(ctx, "checking redfish system", "id", rSystem.ID, "uuid", rSystem.UUID, "uid", *system.UID)
+	for _, vm := range media {
+		slog.DebugContext(ctx, "checking virtual media", "id", vm.ID, "types", vm.MediaTypes, "inserted", vm.Inserted)
+		if slices.Contains(vm.MediaTypes, redfish.CDMediaType) || slices.Contains(vm.MediaTypes, redfish.DVDMediaType) {
+			return vm, nil
 		}
 	}
 
+	return nil, fmt.Errorf("%w: %s", ErrRedfishVirtualMediaNotFound, rSystem.ID)
+}
+
+var ErrRedfishVirtualMediaNotFound = errors.New("redfish virtual CD media not found")
+
+// bootVirtualMedia inserts boot.iso of the system into virtual CD, sets one-time CD boot
+// override and resets the system. Systems with static network configuration get boot.iso
+// generated with their kernel arguments, no DHCP is needed then.
+func bootVirtualMedia(ctx context.Context, c *gofish.APIClient, rSystem *redfish.ComputerSystem, system *model.SystemAppliance) error {
+	mac := db.NullMAC.String()
+	if len(system.HwAddrs) > 0 {
+		mac = system.HwAddrs[0].String()
+	} else {
+		slog.WarnContext(ctx, "no mac address found for system", "system_id", system.System.ID)
+	}
+
+	platform := "bios"
+	if rSystem.Boot.BootSourceOverrideMode == redfish.UEFIBootSourceOverrideMode {
+		platform = "efi"
+	}
+	uri := fmt.Sprintf("%s/boot/%s/%s/boot.iso", config.BaseURL(), platform, mac)
+
+	vm, err := virtualMediaCD(ctx, c, rSystem)
+	if err != nil {
+		return err
+	}
+
+	if vm.Inserted {
+		// most services refuse to insert into occupied media
+		slog.InfoContext(ctx, "ejecting virtual media", "id", vm.ID, "image", vm.Image)
+		err = vm.EjectMedia()
+		if err != nil {
+			return fmt.Errorf("redfish eject media error: %w", err)
+		}
+	}
+
+	slog.InfoContext(ctx, "inserting virtual media", "id", vm.ID, "image", uri)
+	err = vm.InsertMedia(uri, true, true)
+	if err != nil {
+		return fmt.Errorf("redfish insert media error: %w", err)
+	}
+
+	bootOverride := redfish.Boot{
+		BootSourceOverrideEnabled: redfish.OnceBootSourceOverrideEnabled,
+		BootSourceOverrideTarget:  redfish.CdBootSourceOverrideTarget,
+	}
+	err = rSystem.SetBoot(bootOverride)
+	if err != nil {
+		return fmt.Errorf("redfish error: %w", err)
+	}
+
+	return resetSystem(ctx, rSystem)
+}
+
+// ejectVirtualMedia ejects virtual CD media when inserted.
+func ejectVirtualMedia(ctx context.Context, c *gofish.APIClient, rSystem *redfish.ComputerSystem) error {
+	vm, err := virtualMediaCD(ctx, c, rSystem)
+	if err != nil {
+		return err
+	}
+
+	if !vm.Inserted {
+		slog.DebugContext(ctx, "virtual media not inserted", "id", vm.ID)
+		return nil
+	}
+
+	slog.InfoContext(ctx, "ejecting virtual media", "id", vm.ID, "image", vm.Image)
+	err = vm.EjectMedia()
+	if err != nil {
+		return fmt.Errorf("redfish eject media error: %w", err)
+	}
+
 	return nil
 }
 
@@ -191,6 +398,110 @@ func (m RedfishMetal) BootLocal(ctx context.Context, system *model.SystemApplian
 		return nil
 	}
 
-	slog.InfoContext(ctx, "noop operation", "function", "BootLocal")
+	c, rSystem, err := redfishSystem(ctx, system)
+	if err != nil {
+		return err
+	}
+
+	if m.VirtualMedia {
+		err = ejectVirtualMedia(ctx, c, rSystem)
+		if err != nil {
+			return err
+		}
+	}
+
+	// persistent boot order may start with network boot
+	bootOverride := redfish.Boot{
+		BootSourceOverrideEnabled: redfish.OnceBootSourceOverrideEnabled,
+		BootSourceOverrideTarget:  redfish.HddBootSourceOverrideTarget,
+	}
+	err = rSystem.SetBoot(bootOverride)
+	if err != nil {
+		return fmt.Errorf("redfish error: %w", err)
+	}
+
+	return resetSystem(ctx, rSystem)
+}
+
+func (m RedfishMetal) PowerState(ctx context.Context, system *model.SystemAppliance) (PowerState, error) {
+	_, rSystem, err := redfishSystem(ctx, system)
+	if err != nil {
+		return UnknownPowerState, err
+	}
+
+	switch rSystem.PowerState {
+	case redfish.OnPowerState, redfish.PausedPowerState:
+		return OnPowerState, nil
+	case redfish.OffPowerState:
+		return OffPowerState, nil
+	case redfish.PoweringOnPowerState:
+		return PoweringOnState, nil
+	case redfish.PoweringOffPowerState:
+		return PoweringOffState, nil
+	}
+	return UnknownPowerState, nil
+}
+
+func (m RedfishMetal) PowerOn(ctx context.Context, system *model.SystemAppliance) error {
+	if m.Manual {
+		return nil
+	}
+
+	_, rSystem, err := redfishSystem(ctx, system)
+	if err != nil {
+		return err
+	}
+
+	if rSystem.PowerState == redfish.OnPowerState {
+		slog.InfoContext(ctx, "system is already on", "id", rSystem.ID)
+		return nil
+	}
+
+	err = rSystem.Reset(redfish.OnResetType)
+	if err != nil {
+		return fmt.Errorf("redfish power on error: %w", err)
+	}
+
 	return nil
 }
+
+func (m RedfishMetal) PowerOff(ctx context.Context, system *model.SystemAppliance, force bool) error {
+	if m.Manual {
+		return nil
+	}
+
+	_, rSystem, err := redfishSystem(ctx, system)
+	if err != nil {
+		return err
+	}
+
+	if rSystem.PowerState == redfish.OffPowerState {
+		slog.InfoContext(ctx, "system is already off", "id", rSystem.ID)
+		return nil
+	}
+
+	resetType := redfish.ForceOffResetType
+	if !force && slices.Contains(rSystem.SupportedResetTypes, redfish.GracefulShutdownResetType) {
+		resetType = redfish.GracefulShutdownResetType
+	}
+
+	err = rSystem.Reset(resetType)
+	if err != nil {
+		return fmt.Errorf("redfish power off error: %w", err)
+	}
+
+	return nil
+}
+
+func (m RedfishMetal) PowerCycle(ctx context.Context, system *model.SystemAppliance) error {
+	if m.Manual {
+		return nil
+	}
+
+	_, rSystem, err := redfishSystem(ctx, system)
+	if err != nil {
+		return err
+	}
+
+	return resetSystem(ctx, rSystem)
+}
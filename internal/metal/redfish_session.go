@@ -0,0 +1,214 @@
+package metal
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/stmcginnis/gofish"
+	"github.com/stmcginnis/gofish/common"
+
+	"forester/internal/config"
+	"forester/internal/model"
+)
+
+// redfishSession is a cached BMC session of an appliance. Some BMCs only allow a handful
+// of concurrent sessions so the session is shared by all operations on the appliance.
+type redfishSession struct {
+	// mu serializes session creation for the appliance
+	mu sync.Mutex
+
+	// app is a copy of the appliance the session was created for, sessions are
+	// recreated when connection details change
+	app model.Appliance
+
+	// session is nil when there is no active session
+	session *gofish.Session
+
+	lastUsed time.Time
+}
+
+var redfishSessions = struct {
+	sync.Mutex
+	m map[int64]*redfishSession
+}{m: make(map[int64]*redfishSession)}
+
+func sessionFor(appID int64) *redfishSession {
+	redfishSessions.Lock()
+	defer redfishSessions.Unlock()
+
+	rs, ok := redfishSessions.m[appID]
+	if !ok {
+		rs = &redfishSession{}
+		redfishSessions.m[appID] = rs
+	}
+	return rs
+}
+
+func sameConnection(a, b *model.Appliance) bool {
+	return a.URI == b.URI && a.Username == b.Username && a.Password == b.Password
+}
+
+// redfishClient returns a client of an appliance using its cached session, a new session
+// is created when there is none. Clients must not be logged out by callers.
+func redfishClient(ctx context.Context, app *model.Appliance) (*gofish.APIClient, error) {
+	rs := sessionFor(app.ID)
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.session != nil && !sameConnection(&rs.app, app) {
+		slog.DebugContext(ctx, "appliance changed, dropping redfish session", "appliance", app.Name)
+		logoutSession(ctx, &rs.app, rs.session)
+		rs.session = nil
+	}
+
+	cfg := configFromApp(ctx, app)
+	cfg.Session = rs.session
+	c, err := gofish.ConnectContext(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if rs.session == nil {
+		// services without authentication do not create sessions
+		if s, err := c.GetSession(); err == nil {
+			slog.DebugContext(ctx, "created redfish session", "appliance", app.Name, "session", s.ID)
+			rs.app = *app
+			rs.session = s
+		}
+	}
+	rs.lastUsed = time.Now()
+
+	return c, nil
+}
+
+// evictSession drops cached session of a client which is no longer valid on the BMC side.
+func evictSession(app *model.Appliance, c *gofish.APIClient) {
+	if c == nil {
+		return
+	}
+	s, err := c.GetSession()
+	if err != nil {
+		return
+	}
+
+	rs := sessionFor(app.ID)
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	// the session could be already replaced by another operation
+	if rs.session != nil && rs.session.Token == s.Token {
+		rs.session = nil
+	}
+}
+
+func isUnauthorized(err error) bool {
+	var rerr *common.Error
+	return errors.As(err, &rerr) && rerr.HTTPReturnedStatusCode == http.StatusUnauthorized
+}
+
+func logoutSession(ctx context.Context, app *model.Appliance, s *gofish.Session) {
+	cfg := configFromApp(ctx, app)
+	cfg.Session = s
+	c, err := gofish.ConnectContext(ctx, cfg)
+	if err != nil {
+		slog.WarnContext(ctx, "cannot logout redfish session", "appliance", app.Name, "err", err)
+		return
+	}
+	c.Logout()
+}
+
+// keepaliveSession refreshes the session timeout on the BMC, returns false when the session
+// is no longer valid.
+func keepaliveSession(ctx context.Context, app *model.Appliance, s *gofish.Session) bool {
+	cfg := configFromApp(ctx, app)
+	cfg.Session = s
+	c, err := gofish.ConnectContext(ctx, cfg)
+	if err != nil {
+		slog.WarnContext(ctx, "cannot refresh redfish session", "appliance", app.Name, "err", err)
+		return true
+	}
+
+	resp, err := c.Get(s.ID)
+	if err != nil {
+		var rerr *common.Error
+		if errors.As(err, &rerr) && (rerr.HTTPReturnedStatusCode == http.StatusUnauthorized || rerr.HTTPReturnedStatusCode == http.StatusNotFound) {
+			return false
+		}
+		slog.WarnContext(ctx, "cannot refresh redfish session", "appliance", app.Name, "err", err)
+		return true
+	}
+	resp.Body.Close()
+
+	return true
+}
+
+// SessionKeeper periodically refreshes cached BMC sessions and logs out idle ones.
+type SessionKeeper struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// StartSessionKeeper starts background refreshing of cached BMC sessions.
+func StartSessionKeeper(ctx context.Context) *SessionKeeper {
+	k := SessionKeeper{done: make(chan struct{})}
+	var kctx context.Context
+	kctx, k.cancel = context.WithCancel(ctx)
+
+	go func() {
+		defer close(k.done)
+
+		ticker := time.NewTicker(config.Redfish.Keepalive)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-kctx.Done():
+				return
+			case <-ticker.C:
+				refreshSessions(kctx, config.Redfish.SessionIdle)
+			}
+		}
+	}()
+
+	return &k
+}
+
+// Shutdown stops refreshing and logs out all cached sessions.
+func (k *SessionKeeper) Shutdown() {
+	slog.Debug("logging out redfish sessions")
+	k.cancel()
+	<-k.done
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	refreshSessions(ctx, 0)
+}
+
+// refreshSessions logs out sessions idle for longer than idle duration and refreshes the rest.
+func refreshSessions(ctx context.Context, idle time.Duration) {
+	redfishSessions.Lock()
+	sessions := make([]*redfishSession, 0, len(redfishSessions.m))
+	for _, rs := range redfishSessions.m {
+		sessions = append(sessions, rs)
+	}
+	redfishSessions.Unlock()
+
+	for _, rs := range sessions {
+		rs.mu.Lock()
+		if rs.session != nil {
+			if time.Since(rs.lastUsed) >= idle {
+				slog.DebugContext(ctx, "logging out idle redfish session", "appliance", rs.app.Name, "session", rs.session.ID)
+				logoutSession(ctx, &rs.app, rs.session)
+				rs.session = nil
+			} else if !keepaliveSession(ctx, &rs.app, rs.session) {
+				slog.DebugContext(ctx, "redfish session expired", "appliance", rs.app.Name, "session", rs.session.ID)
+				rs.session = nil
+			}
+		}
+		rs.mu.Unlock()
+	}
+}
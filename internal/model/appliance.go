@@ -12,6 +12,22 @@ type Appliance struct {
 
 	// URI holds connection information
 	URI string `db:"uri"`
+
+	// Username for appliances requiring authentication, can be blank. Stored encrypted
+	// in Credentials.
+	Username string `db:"-"`
+
+	// Password for appliances requiring authentication, can be blank. Stored encrypted
+	// in Credentials.
+	Password string `db:"-"`
+
+	// Credentials holds encrypted Username and Password, nil when not set.
+	Credentials []byte `db:"credentials"`
+
+	// PlainUsername and PlainPassword are plaintext credentials stored by older versions,
+	// they are encrypted into Credentials and cleared on controller start.
+	PlainUsername string `db:"username"`
+	PlainPassword string `db:"password"`
 }
 
 type ApplianceKind int16
@@ -22,6 +38,8 @@ const (
 	LibvirtApplianceKind       ApplianceKind = iota
 	RedfishApplianceKind       ApplianceKind = iota
 	RedfishManualApplianceKind ApplianceKind = iota
+	IpmiApplianceKind          ApplianceKind = iota
+	RedfishVMediaApplianceKind ApplianceKind = iota
 )
 
 func ParseKind(i int16) ApplianceKind {
@@ -36,6 +54,10 @@ func ParseKind(i int16) ApplianceKind {
 		return RedfishApplianceKind
 	case 4:
 		return RedfishManualApplianceKind
+	case 5:
+		return IpmiApplianceKind
+	case 6:
+		return RedfishVMediaApplianceKind
 	default:
 		return -1
 	}
@@ -10,13 +10,68 @@ type Image struct {
 	// Kind is image type
 	Kind ImageKind `db:"kind"`
 
-	// Image ISO SHA256.
+	// Image ISO SHA256, set when upload is complete.
 	IsoSha256 string `db:"iso_sha256"`
 
+	// Expected image ISO SHA256 provided by the client, upload is verified against it
+	// when not empty.
+	ExpectedSha256 string `db:"expected_sha256"`
+
 	// Image liveimg.tar.gz SHA256 (when present otherwise empty string).
 	LiveimgSha256 string `db:"liveimg_sha256"`
+
+	// Status of image processing, only ready images can be deployed.
+	Status ImageStatus `db:"status"`
+
+	// StatusMessage is the error message of a failed image, can be blank.
+	StatusMessage string `db:"status_message"`
+
+	// Progress of the current status in percent.
+	Progress int16 `db:"progress"`
+
+	// Arch is CPU architecture (x86_64, aarch64) detected from the image, blank when unknown.
+	Arch string `db:"arch"`
+
+	// LocalRepo is true when the image contains a package repository served by the controller,
+	// it is used as installation source of netboot (RPM) images.
+	LocalRepo bool `db:"local_repo"`
+
+	// ContainerRef is a registry reference (e.g. quay.io/fedora/fedora-bootc:40) installed
+	// via ostreecontainer instead of the OCI layout from the ISO, blank when not set.
+	ContainerRef string `db:"container_ref"`
+
+	// BootcRef is a registry reference the installed system is switched to via bootc switch,
+	// blank when not set.
+	BootcRef string `db:"bootc_ref"`
+
+	// SignatureVerify enables container signature verification of ostreecontainer and
+	// bootc switch.
+	SignatureVerify bool `db:"signature_verify"`
+
+	// Meta is image metadata (distro, version, arch, variant, build_time, media) detected
+	// from the image, never nil.
+	Meta map[string]string `db:"meta"`
+
+	// SecureBoot is the result of signature verification of EFI binaries against the
+	// configured Secure Boot CA.
+	SecureBoot SecureBootStatus `db:"secure_boot"`
+
+	// SecureBootMessage is the verification error, can be blank.
+	SecureBootMessage string `db:"secure_boot_message"`
+
+	// KernelArgs are kernel command line arguments of network boot and boot.iso, see
+	// MergeKernelArgs.
+	KernelArgs []string `db:"kernel_args"`
 }
 
+const (
+	X86_64Arch  = "x86_64"
+	Aarch64Arch = "aarch64"
+)
+
+// ArchFact is a system fact with CPU architecture in the same format as image architecture
+const ArchFact = "arch"
+
 type ImageKind int16
 
 const (
@@ -40,3 +95,88 @@ func ParseImageKind(i int16) ImageKind {
 		return -1
 	}
 }
+
+type ImageStatus int16
+
+const (
+	ReservedImageStatus   ImageStatus = iota
+	UploadingImageStatus  ImageStatus = iota
+	ExtractingImageStatus ImageStatus = iota
+	GeneratingImageStatus ImageStatus = iota
+	ReadyImageStatus      ImageStatus = iota
+	FailedImageStatus     ImageStatus = iota
+)
+
+// Deployable returns true when the ISO is uploaded and kind, architecture and Secure Boot
+// status are detected, boot.iso can still be generating.
+func (i *Image) Deployable() bool {
+	return i.IsoSha256 != "" && (i.Status == GeneratingImageStatus || i.Status == ReadyImageStatus)
+}
+
+func ParseImageStatus(i int16) ImageStatus {
+	switch i {
+	case 0:
+		return ReservedImageStatus
+	case 1:
+		return UploadingImageStatus
+	case 2:
+		return ExtractingImageStatus
+	case 3:
+		return GeneratingImageStatus
+	case 4:
+		return ReadyImageStatus
+	case 5:
+		return FailedImageStatus
+	default:
+		return -1
+	}
+}
+
+func (is ImageStatus) String() string {
+	switch is {
+	case UploadingImageStatus:
+		return "uploading"
+	case ExtractingImageStatus:
+		return "extracting"
+	case GeneratingImageStatus:
+		return "generating"
+	case ReadyImageStatus:
+		return "ready"
+	case FailedImageStatus:
+		return "failed"
+	}
+	return ""
+}
+
+type SecureBootStatus int16
+
+const (
+	UnverifiedSecureBootStatus SecureBootStatus = iota
+	VerifiedSecureBootStatus   SecureBootStatus = iota
+	FailedSecureBootStatus     SecureBootStatus = iota
+)
+
+func ParseSecureBootStatus(i int16) SecureBootStatus {
+	switch i {
+	case 0:
+		return UnverifiedSecureBootStatus
+	case 1:
+		return VerifiedSecureBootStatus
+	case 2:
+		return FailedSecureBootStatus
+	default:
+		return -1
+	}
+}
+
+func (sb SecureBootStatus) String() string {
+	switch sb {
+	case UnverifiedSecureBootStatus:
+		return "unverified"
+	case VerifiedSecureBootStatus:
+		return "verified"
+	case FailedSecureBootStatus:
+		return "failed"
+	}
+	return ""
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageDeployable(t *testing.T) {
+	tests := map[string]struct {
+		image      Image
+		deployable bool
+	}{
+		"reserved":   {image: Image{Status: ReservedImageStatus}},
+		"uploading":  {image: Image{Status: UploadingImageStatus}},
+		"extracting": {image: Image{IsoSha256: "abc", Status: ExtractingImageStatus}},
+		"generating": {image: Image{IsoSha256: "abc", Status: GeneratingImageStatus}, deployable: true},
+		"ready":      {image: Image{IsoSha256: "abc", Status: ReadyImageStatus}, deployable: true},
+		"failed":     {image: Image{IsoSha256: "abc", Status: FailedImageStatus}},
+		"no iso":     {image: Image{Status: ReadyImageStatus}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.deployable, tc.image.Deployable())
+		})
+	}
+}
@@ -37,6 +37,9 @@ type Installation struct {
 
 	// Comment, can be blank.
 	Comment string `db:"comment"`
+
+	// KernelArgs are kernel command line arguments of network boot, see MergeKernelArgs.
+	KernelArgs []string `db:"kernel_args"`
 }
 
 type InstallState int16
@@ -0,0 +1,79 @@
+package model
+
+import "time"
+
+type InstallationEvent struct {
+	// Required auto-generated PK.
+	ID int64 `db:"id"`
+
+	// The installation.
+	InstallationID int64 `db:"installation_id"`
+
+	// Kind is the milestone type.
+	Kind EventKind `db:"kind"`
+
+	// CreatedAt is time when the event was recorded.
+	CreatedAt time.Time `db:"created_at"`
+
+	// TraceID of the request which recorded the event, can be blank.
+	TraceID string `db:"trace_id"`
+
+	// Payload is an informative text (e.g. rendered template), can be blank.
+	Payload string `db:"payload"`
+}
+
+type EventKind int16
+
+const (
+	ReservedEventKind    EventKind = iota
+	DeployEventKind      EventKind = iota
+	BootNetworkEventKind EventKind = iota
+	GrubConfigEventKind  EventKind = iota
+	IpxeConfigEventKind  EventKind = iota
+	KickstartEventKind   EventKind = iota
+	DoneEventKind        EventKind = iota
+	BootLocalEventKind   EventKind = iota
+)
+
+func ParseEventKind(i int16) EventKind {
+	switch i {
+	case 0:
+		return ReservedEventKind
+	case 1:
+		return DeployEventKind
+	case 2:
+		return BootNetworkEventKind
+	case 3:
+		return GrubConfigEventKind
+	case 4:
+		return IpxeConfigEventKind
+	case 5:
+		return KickstartEventKind
+	case 6:
+		return DoneEventKind
+	case 7:
+		return BootLocalEventKind
+	default:
+		return -1
+	}
+}
+
+func (ek EventKind) String() string {
+	switch ek {
+	case DeployEventKind:
+		return "deploy"
+	case BootNetworkEventKind:
+		return "bootnet"
+	case GrubConfigEventKind:
+		return "grub"
+	case IpxeConfigEventKind:
+		return "ipxe"
+	case KickstartEventKind:
+		return "kickstart"
+	case DoneEventKind:
+		return "done"
+	case BootLocalEventKind:
+		return "bootlocal"
+	}
+	return ""
+}
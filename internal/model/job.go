@@ -0,0 +1,147 @@
+package model
+
+import "time"
+
+type Job struct {
+	// Required auto-generated PK.
+	ID int64 `db:"id"`
+
+	// Kind is the operation to perform.
+	Kind JobKind `db:"kind"`
+
+	// State of the job.
+	State JobState `db:"state"`
+
+	// The system, nil for image jobs.
+	SystemID *int64 `db:"system_id"`
+
+	// The image of image jobs, can be nil.
+	ImageID *int64 `db:"image_id"`
+
+	// URL of image import jobs, can be blank.
+	URL string `db:"url"`
+
+	// The installation which events are recorded by the job, can be nil.
+	InstallationID *int64 `db:"installation_id"`
+
+	// The appliance at the time of enqueue, used for concurrency limits. Can be nil.
+	ApplianceID *int64 `db:"appliance_id"`
+
+	// TraceID of the request which enqueued the job, can be blank.
+	TraceID string `db:"trace_id"`
+
+	// Attempts is the number of executions so far.
+	Attempts int16 `db:"attempts"`
+
+	// MaxAttempts is the number of executions until the job fails.
+	MaxAttempts int16 `db:"max_attempts"`
+
+	// RunAt is time when job is executed (or retried) at the earliest.
+	RunAt time.Time `db:"run_at"`
+
+	// CreatedAt is time when job was enqueued.
+	CreatedAt time.Time `db:"created_at"`
+
+	// UpdatedAt is time of the last state change.
+	UpdatedAt time.Time `db:"updated_at"`
+
+	// LastError is the error message of the last failed attempt, can be blank.
+	LastError string `db:"last_error"`
+}
+
+type JobKind int16
+
+const (
+	ReservedJobKind    JobKind = iota
+	BootNetworkJobKind JobKind = iota
+	BootLocalJobKind   JobKind = iota
+	PowerOnJobKind     JobKind = iota
+	PowerOffJobKind    JobKind = iota
+	ForceOffJobKind    JobKind = iota
+	PowerCycleJobKind  JobKind = iota
+	ImportImageJobKind JobKind = iota
+)
+
+func ParseJobKind(i int16) JobKind {
+	switch i {
+	case 0:
+		return ReservedJobKind
+	case 1:
+		return BootNetworkJobKind
+	case 2:
+		return BootLocalJobKind
+	case 3:
+		return PowerOnJobKind
+	case 4:
+		return PowerOffJobKind
+	case 5:
+		return ForceOffJobKind
+	case 6:
+		return PowerCycleJobKind
+	case 7:
+		return ImportImageJobKind
+	default:
+		return -1
+	}
+}
+
+func (jk JobKind) String() string {
+	switch jk {
+	case BootNetworkJobKind:
+		return "bootnet"
+	case BootLocalJobKind:
+		return "bootlocal"
+	case PowerOnJobKind:
+		return "poweron"
+	case PowerOffJobKind:
+		return "poweroff"
+	case ForceOffJobKind:
+		return "forceoff"
+	case PowerCycleJobKind:
+		return "powercycle"
+	case ImportImageJobKind:
+		return "importimage"
+	}
+	return ""
+}
+
+type JobState int16
+
+const (
+	ReservedJobState  JobState = iota
+	PendingJobState   JobState = iota
+	RunningJobState   JobState = iota
+	SucceededJobState JobState = iota
+	FailedJobState    JobState = iota
+)
+
+func ParseJobState(i int16) JobState {
+	switch i {
+	case 0:
+		return ReservedJobState
+	case 1:
+		return PendingJobState
+	case 2:
+		return RunningJobState
+	case 3:
+		return SucceededJobState
+	case 4:
+		return FailedJobState
+	default:
+		return -1
+	}
+}
+
+func (js JobState) String() string {
+	switch js {
+	case PendingJobState:
+		return "pending"
+	case RunningJobState:
+		return "running"
+	case SucceededJobState:
+		return "succeeded"
+	case FailedJobState:
+		return "failed"
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"slices"
+	"strings"
+)
+
+// argKey returns key of a kernel argument (the part before the first equal sign)
+func argKey(arg string) string {
+	key, _, _ := strings.Cut(arg, "=")
+	return key
+}
+
+// MergeKernelArgs merges lists of kernel command line arguments in order (defaults, system,
+// image and installation). Arguments are appended unless already present. An argument
+// with a minus prefix removes previous arguments: "-key" removes all arguments with the
+// key with or without a value, "-key=value" removes only the exact argument.
+func MergeKernelArgs(lists ...[]string) []string {
+	result := make([]string, 0)
+	for _, list := range lists {
+		for _, arg := range list {
+			if arg == "" || arg == "-" {
+				continue
+			}
+
+			if remove, ok := strings.CutPrefix(arg, "-"); ok {
+				exact := strings.Contains(remove, "=")
+				filtered := result[:0]
+				for _, a := range result {
+					if (exact && a != remove) || (!exact && argKey(a) != remove) {
+						filtered = append(filtered, a)
+					}
+				}
+				result = filtered
+				continue
+			}
+
+			if !slices.Contains(result, arg) {
+				result = append(result, arg)
+			}
+		}
+	}
+
+	return result
+}
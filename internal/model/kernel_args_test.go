@@ -0,0 +1,33 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeKernelArgs(t *testing.T) {
+	defaults := []string{"ip=dhcp", "inst.text", "inst.sshd"}
+
+	tests := map[string]struct {
+		lists [][]string
+		want  []string
+	}{
+		"defaults only":  {lists: [][]string{defaults}, want: defaults},
+		"append":         {lists: [][]string{defaults, {"console=ttyS1,115200"}}, want: []string{"ip=dhcp", "inst.text", "inst.sshd", "console=ttyS1,115200"}},
+		"duplicate":      {lists: [][]string{defaults, {"inst.text"}}, want: defaults},
+		"remove key":     {lists: [][]string{defaults, {"-ip", "ip=192.168.1.5::192.168.1.1:255.255.255.0::eth0:none"}}, want: []string{"inst.text", "inst.sshd", "ip=192.168.1.5::192.168.1.1:255.255.255.0::eth0:none"}},
+		"remove exact":   {lists: [][]string{{"console=tty0", "console=ttyS0"}, {"-console=tty0"}}, want: []string{"console=ttyS0"}},
+		"remove all":     {lists: [][]string{{"console=tty0", "console=ttyS0"}, {"-console"}}, want: []string{}},
+		"remove flag":    {lists: [][]string{defaults, {"-inst.sshd"}}, want: []string{"ip=dhcp", "inst.text"}},
+		"later layer":    {lists: [][]string{defaults, {"inst.debug"}, nil, {"-inst.debug"}}, want: defaults},
+		"re-add":         {lists: [][]string{defaults, {"-inst.text"}, {"inst.text"}}, want: []string{"ip=dhcp", "inst.sshd", "inst.text"}},
+		"remove missing": {lists: [][]string{defaults, {"-rd.break", ""}}, want: defaults},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.want, MergeKernelArgs(tc.lists...))
+		})
+	}
+}
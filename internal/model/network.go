@@ -0,0 +1,197 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidNetwork = errors.New("invalid network configuration")
+
+// DefaultBondDevice is the name of the bond device when bond slaves are configured
+// without a device
+const DefaultBondDevice = "bond0"
+
+// Network is a static network configuration of a system used during installation
+// instead of DHCP. It is rendered into dracut kernel arguments (ip=, vlan=, bond=,
+// nameserver=) and into the kickstart network command.
+type Network struct {
+	// Device is interface name (or bond name when BondSlaves are set), interface is
+	// picked by the installer when blank.
+	Device string `json:"device,omitempty"`
+
+	// Address is IPv4 or IPv6 address with prefix length (e.g. 192.168.1.10/24).
+	Address string `json:"address"`
+
+	// Gateway is an optional default gateway.
+	Gateway string `json:"gateway,omitempty"`
+
+	// Nameservers are optional DNS servers.
+	Nameservers []string `json:"nameservers,omitempty"`
+
+	// VLAN is an optional 802.1Q VLAN ID on top of the device.
+	VLAN int `json:"vlan,omitempty"`
+
+	// BondSlaves are optional interface names of bond members.
+	BondSlaves []string `json:"bond_slaves,omitempty"`
+
+	// BondOpts are comma-separated bonding options (e.g. mode=active-backup,miimon=100).
+	BondOpts string `json:"bond_opts,omitempty"`
+}
+
+// IsZero returns true when no field is set, blank configuration means DHCP.
+func (n Network) IsZero() bool {
+	return n.Device == "" && n.Address == "" && n.Gateway == "" && len(n.Nameservers) == 0 &&
+		n.VLAN == 0 && len(n.BondSlaves) == 0 && n.BondOpts == ""
+}
+
+func invalidName(str string) bool {
+	return str == "" || strings.ContainsAny(str, " \t\r\n\"';\\:,=")
+}
+
+// Validate checks addresses and interface names, names must not contain characters
+// used as separators in kernel arguments.
+func (n Network) Validate() error {
+	prefix, err := netip.ParsePrefix(n.Address)
+	if err != nil {
+		return fmt.Errorf("%w: address: %w", ErrInvalidNetwork, err)
+	}
+	if n.Gateway != "" {
+		gw, err := netip.ParseAddr(n.Gateway)
+		if err != nil {
+			return fmt.Errorf("%w: gateway: %w", ErrInvalidNetwork, err)
+		}
+		if gw.Is4() != prefix.Addr().Is4() {
+			return fmt.Errorf("%w: gateway %s and address %s families differ", ErrInvalidNetwork, n.Gateway, n.Address)
+		}
+	}
+	for _, ns := range n.Nameservers {
+		if _, err := netip.ParseAddr(ns); err != nil {
+			return fmt.Errorf("%w: nameserver: %w", ErrInvalidNetwork, err)
+		}
+	}
+	if n.Device != "" && invalidName(n.Device) {
+		return fmt.Errorf("%w: device %q", ErrInvalidNetwork, n.Device)
+	}
+	for _, s := range n.BondSlaves {
+		if invalidName(s) {
+			return fmt.Errorf("%w: bond slave %q", ErrInvalidNetwork, s)
+		}
+	}
+	if n.BondOpts != "" && len(n.BondSlaves) == 0 {
+		return fmt.Errorf("%w: bond options without bond slaves", ErrInvalidNetwork)
+	}
+	if strings.ContainsAny(n.BondOpts, " \t\r\n\"';\\:") {
+		return fmt.Errorf("%w: bond options %q", ErrInvalidNetwork, n.BondOpts)
+	}
+	if n.VLAN < 0 || n.VLAN > 4094 {
+		return fmt.Errorf("%w: VLAN ID %d", ErrInvalidNetwork, n.VLAN)
+	}
+	if n.VLAN > 0 && n.Device == "" && len(n.BondSlaves) == 0 {
+		return fmt.Errorf("%w: VLAN requires device", ErrInvalidNetwork)
+	}
+
+	return nil
+}
+
+// parentDevice returns the physical or bond device
+func (n Network) parentDevice() string {
+	if len(n.BondSlaves) > 0 && n.Device == "" {
+		return DefaultBondDevice
+	}
+	return n.Device
+}
+
+// ipDevice returns the device the address is configured on
+func (n Network) ipDevice() string {
+	if n.VLAN > 0 {
+		return n.parentDevice() + "." + strconv.Itoa(n.VLAN)
+	}
+	return n.parentDevice()
+}
+
+// KernelArgs returns dracut arguments of the configuration. The first argument removes
+// default "ip=dhcp" argument, see MergeKernelArgs.
+func (n Network) KernelArgs(hostname string) []string {
+	prefix, err := netip.ParsePrefix(n.Address)
+	if err != nil {
+		return nil
+	}
+
+	result := []string{"-ip"}
+	if len(n.BondSlaves) > 0 {
+		bond := "bond=" + n.parentDevice() + ":" + strings.Join(n.BondSlaves, ",")
+		if n.BondOpts != "" {
+			bond += ":" + n.BondOpts
+		}
+		result = append(result, bond)
+	}
+	if n.VLAN > 0 {
+		result = append(result, "vlan="+n.ipDevice()+":"+n.parentDevice())
+	}
+
+	// ip=<client-IP>:[<peer>]:<gateway-IP>:<netmask>:<client_hostname>:<interface>:none
+	addr, gw, mask := prefix.Addr().String(), n.Gateway, net.IP(net.CIDRMask(prefix.Bits(), 32)).String()
+	if prefix.Addr().Is6() {
+		addr, mask = "["+addr+"]", strconv.Itoa(prefix.Bits())
+		if gw != "" {
+			gw = "[" + gw + "]"
+		}
+	}
+	result = append(result, fmt.Sprintf("ip=%s::%s:%s:%s:%s:none", addr, gw, mask, hostname, n.ipDevice()))
+
+	for _, ns := range n.Nameservers {
+		result = append(result, "nameserver="+ns)
+	}
+
+	return result
+}
+
+// Kickstart returns kickstart network command of the configuration.
+func (n Network) Kickstart(hostname string) string {
+	prefix, err := netip.ParsePrefix(n.Address)
+	if err != nil {
+		return ""
+	}
+
+	device := n.parentDevice()
+	if device == "" {
+		device = "link"
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString("network --bootproto=static --device=" + device)
+	if prefix.Addr().Is4() {
+		sb.WriteString(" --ip=" + prefix.Addr().String())
+		sb.WriteString(" --netmask=" + net.IP(net.CIDRMask(prefix.Bits(), 32)).String())
+		if n.Gateway != "" {
+			sb.WriteString(" --gateway=" + n.Gateway)
+		}
+	} else {
+		sb.WriteString(" --noipv4 --ipv6=" + prefix.String())
+		if n.Gateway != "" {
+			sb.WriteString(" --ipv6gateway=" + n.Gateway)
+		}
+	}
+	if len(n.Nameservers) > 0 {
+		sb.WriteString(" --nameserver=" + strings.Join(n.Nameservers, ","))
+	}
+	if n.VLAN > 0 {
+		sb.WriteString(" --vlanid=" + strconv.Itoa(n.VLAN))
+	}
+	if len(n.BondSlaves) > 0 {
+		sb.WriteString(" --bondslaves=" + strings.Join(n.BondSlaves, ","))
+		if n.BondOpts != "" {
+			sb.WriteString(" --bondopts=" + n.BondOpts)
+		}
+	}
+	sb.WriteString(" --activate --onboot=on")
+	if hostname != "" {
+		sb.WriteString(" --hostname " + hostname)
+	}
+
+	return sb.String()
+}
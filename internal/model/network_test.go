@@ -0,0 +1,76 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetwork(t *testing.T) {
+	tests := map[string]struct {
+		network   Network
+		kernel    []string
+		kickstart string
+	}{
+		"ipv4": {
+			network:   Network{Device: "eno1", Address: "192.168.1.10/24", Gateway: "192.168.1.1", Nameservers: []string{"192.168.1.2", "192.168.1.3"}},
+			kernel:    []string{"-ip", "ip=192.168.1.10::192.168.1.1:255.255.255.0:host:eno1:none", "nameserver=192.168.1.2", "nameserver=192.168.1.3"},
+			kickstart: "network --bootproto=static --device=eno1 --ip=192.168.1.10 --netmask=255.255.255.0 --gateway=192.168.1.1 --nameserver=192.168.1.2,192.168.1.3 --activate --onboot=on --hostname host",
+		},
+		"no device": {
+			network:   Network{Address: "10.0.0.5/8"},
+			kernel:    []string{"-ip", "ip=10.0.0.5:::255.0.0.0:host::none"},
+			kickstart: "network --bootproto=static --device=link --ip=10.0.0.5 --netmask=255.0.0.0 --activate --onboot=on --hostname host",
+		},
+		"ipv6": {
+			network:   Network{Device: "eno1", Address: "2001:db8::10/64", Gateway: "2001:db8::1"},
+			kernel:    []string{"-ip", "ip=[2001:db8::10]::[2001:db8::1]:64:host:eno1:none"},
+			kickstart: "network --bootproto=static --device=eno1 --noipv4 --ipv6=2001:db8::10/64 --ipv6gateway=2001:db8::1 --activate --onboot=on --hostname host",
+		},
+		"vlan": {
+			network:   Network{Device: "eno1", Address: "192.168.1.10/24", VLAN: 100},
+			kernel:    []string{"-ip", "vlan=eno1.100:eno1", "ip=192.168.1.10:::255.255.255.0:host:eno1.100:none"},
+			kickstart: "network --bootproto=static --device=eno1 --ip=192.168.1.10 --netmask=255.255.255.0 --vlanid=100 --activate --onboot=on --hostname host",
+		},
+		"bond vlan": {
+			network:   Network{Address: "192.168.1.10/24", VLAN: 5, BondSlaves: []string{"eno1", "eno2"}, BondOpts: "mode=active-backup,miimon=100"},
+			kernel:    []string{"-ip", "bond=bond0:eno1,eno2:mode=active-backup,miimon=100", "vlan=bond0.5:bond0", "ip=192.168.1.10:::255.255.255.0:host:bond0.5:none"},
+			kickstart: "network --bootproto=static --device=bond0 --ip=192.168.1.10 --netmask=255.255.255.0 --vlanid=5 --bondslaves=eno1,eno2 --bondopts=mode=active-backup,miimon=100 --activate --onboot=on --hostname host",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, tc.network.Validate())
+			require.Equal(t, tc.kernel, tc.network.KernelArgs("host"))
+			require.Equal(t, tc.kickstart, tc.network.Kickstart("host"))
+		})
+	}
+}
+
+func TestNetworkValidate(t *testing.T) {
+	tests := map[string]Network{
+		"no prefix":      {Address: "192.168.1.10"},
+		"bad gateway":    {Address: "192.168.1.10/24", Gateway: "gw"},
+		"family":         {Address: "192.168.1.10/24", Gateway: "2001:db8::1"},
+		"bad nameserver": {Address: "192.168.1.10/24", Nameservers: []string{"dns"}},
+		"bad device":     {Address: "192.168.1.10/24", Device: "eno1:0"},
+		"vlan no device": {Address: "192.168.1.10/24", VLAN: 10},
+		"vlan range":     {Address: "192.168.1.10/24", Device: "eno1", VLAN: 4095},
+		"bond opts":      {Address: "192.168.1.10/24", BondOpts: "mode=1"},
+		"no address":     {Device: "eno1", Gateway: "192.168.1.1"},
+	}
+
+	for name, n := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.ErrorIs(t, n.Validate(), ErrInvalidNetwork)
+		})
+	}
+}
+
+func TestNetworkIsZero(t *testing.T) {
+	require.True(t, Network{}.IsZero())
+	require.False(t, Network{Address: "192.168.1.10/24"}.IsZero())
+	require.False(t, Network{Gateway: "192.168.1.1"}.IsZero())
+	require.False(t, Network{Nameservers: []string{"192.168.1.1"}}.IsZero())
+}
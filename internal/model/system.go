@@ -2,7 +2,9 @@ package model
 
 import (
 	"net"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type System struct {
@@ -32,8 +34,24 @@ type System struct {
 
 	// CustomSnippet, can be blank.
 	CustomSnippet string `db:"custom_snippet"`
+
+	// UpdatedAt is time of the last registration or rename.
+	UpdatedAt time.Time `db:"updated_at"`
+
+	// KernelArgs are kernel command line arguments of network boot, see MergeKernelArgs.
+	KernelArgs []string `db:"kernel_args"`
+
+	// Network is static network configuration used during installation or nil for DHCP.
+	Network *Network `db:"network"`
 }
 
+// DhcpAddressFact is a system fact with IPv4 address used in generated DHCP configuration
+// of servers which require static leases (systemd-networkd, Kea)
+const DhcpAddressFact = "dhcp_address"
+
+// SecureBootFact is a system fact with UEFI Secure Boot state ("true" or "false")
+const SecureBootFact = "secure_boot"
+
 type Fact struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
@@ -51,6 +69,12 @@ func (f *Facts) FactsMap() map[string]string {
 	return result
 }
 
+// SecureBoot returns true when the Secure Boot fact is set to a true value
+func (s System) SecureBoot() bool {
+	sb, _ := strconv.ParseBool(s.Facts.FactsMap()[SecureBootFact])
+	return sb
+}
+
 func (s System) UniqueHwAddrs() []net.HardwareAddr {
 	return s.HwAddrs.Unique()
 }
@@ -1,30 +1,43 @@
 package mux
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
 	"io"
+	"io/fs"
 	"log/slog"
 	"mime"
 	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"path"
+	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
 
 	chi "github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 
-	"forester/internal/config"
 	"forester/internal/db"
+	"forester/internal/img"
 	"forester/internal/model"
 	"forester/internal/tmpl"
 )
 
+var ErrSecureBootIpxe = errors.New("system has Secure Boot enabled, iPXE is not signed, boot via shim and grub instead")
+
 func MountBoot(r *chi.Mux) {
 	paths := []string{
 		"/shim.efi",
 		"/grubx64.efi",
 		"//grubx64.efi", // some grub versions request double slash
+		"/grubaa64.efi",
+		"//grubaa64.efi",
 		"/grubx64.0",
 		"//grubx64.0", // some grub versions request double slash
 		"/.discinfo",
@@ -90,13 +103,14 @@ func serveBootPath(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	root := config.BootPath(i.ImageID)
+	root := openImageFS(r.Context(), i.ImageID)
+	defer root.Close()
 
 	prefix := "/" + strings.Join(slices.DeleteFunc([]string{"boot", platform, origMAC}, func(e string) bool {
 		return e == ""
 	}), "/")
 	slog.InfoContext(r.Context(), "serving root",
-		"directory", root,
+		"image_id", i.ImageID,
 		"system_id", s.ID,
 		"install_uuid", i.UUID,
 		"path", r.URL.Path,
@@ -107,10 +121,103 @@ func serveBootPath(w http.ResponseWriter, r *http.Request) {
 	// the reply is also an HTTP 404 not found error."
 	r.URL.RawPath = r.URL.Path
 
-	fs := http.StripPrefix(prefix, http.FileServer(http.Dir(root)))
+	if path.Base(r.URL.Path) == "boot.iso" && serveSystemBootISO(w, r, root, s, i) {
+		return
+	}
+
+	fs := http.StripPrefix(prefix, http.FileServer(http.FS(root)))
 	fs.ServeHTTP(w, r)
 }
 
+// systemBootISOs holds locks of system boot.iso files being generated, requests for the same
+// file wait for a single generation while other files are generated concurrently
+var systemBootISOs = struct {
+	sync.Mutex
+	m map[string]*fileLock
+}{m: make(map[string]*fileLock)}
+
+type fileLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lockSystemBootISO locks the file and returns a function which unlocks it
+func lockSystemBootISO(file string) func() {
+	systemBootISOs.Lock()
+	l, ok := systemBootISOs.m[file]
+	if !ok {
+		l = &fileLock{}
+		systemBootISOs.m[file] = l
+	}
+	l.refs++
+	systemBootISOs.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+
+		systemBootISOs.Lock()
+		defer systemBootISOs.Unlock()
+		l.refs--
+		if l.refs == 0 {
+			delete(systemBootISOs.m, file)
+		}
+	}
+}
+
+// serveSystemBootISO serves boot.iso generated with kernel arguments of the system when they
+// differ from arguments of the image boot.iso, e.g. virtual media boot of systems with static
+// network configuration needs no DHCP then. The file is kept in the image directory until
+// arguments of the system change. Returns false when the image boot.iso is to be served.
+func serveSystemBootISO(w http.ResponseWriter, r *http.Request, root *imageFS, s *model.System, i *model.Installation) bool {
+	ctx := r.Context()
+	image, err := db.GetImageDao(ctx).FindByID(ctx, i.ImageID)
+	if err != nil {
+		slog.WarnContext(ctx, "cannot find image", "image_id", i.ImageID, "err", err)
+		http.NotFound(w, r)
+		return true
+	}
+	if image.Status != model.ReadyImageStatus {
+		slog.WarnContext(ctx, "boot.iso not generated yet", "image_id", image.ID, "status", image.Status.String())
+		http.Error(w, "boot.iso not generated yet", http.StatusServiceUnavailable)
+		return true
+	}
+
+	args, err := kernelArgs(ctx, s, i)
+	if err != nil {
+		slog.WarnContext(ctx, "cannot load kernel arguments", "system_id", s.ID, "err", err)
+		http.NotFound(w, r)
+		return true
+	}
+	if slices.Equal(args, image.KernelArgs) {
+		return false
+	}
+	args = append(args, "-systemd.hostname", fmt.Sprintf("systemd.hostname=f-%d-%s", s.ID, i.UUID.String()))
+
+	sum := sha256.Sum256([]byte(strings.Join(args, " ")))
+	prefix := filepath.Join(dirPath(i.ImageID), fmt.Sprintf("boot-system-%d-", s.ID))
+	file := fmt.Sprintf("%s%x.iso", prefix, sum[:8])
+
+	unlock := lockSystemBootISO(file)
+	if _, err = os.Stat(file); errors.Is(err, fs.ErrNotExist) {
+		slog.InfoContext(ctx, "generating system boot.iso", "system_id", s.ID, "file", file)
+		old, _ := filepath.Glob(prefix + "*.iso")
+		for _, f := range old {
+			os.Remove(f)
+		}
+		err = img.BuildSystemBootISO(ctx, image.ID, image.Arch, args, root, file)
+	}
+	unlock()
+	if err != nil {
+		slog.ErrorContext(ctx, "cannot generate system boot.iso", "system_id", s.ID, "err", err)
+		http.Error(w, "cannot generate boot.iso", http.StatusInternalServerError)
+		return true
+	}
+
+	http.ServeFile(w, r, file)
+	return true
+}
+
 func HandleMacConfig(w http.ResponseWriter, r *http.Request) {
 	platform := strings.ToLower(chi.URLParam(r, "PLATFORM"))
 	origMAC, err := url.QueryUnescape(chi.URLParam(r, "MAC"))
@@ -121,24 +228,58 @@ func HandleMacConfig(w http.ResponseWriter, r *http.Request) {
 	}
 	mac, _ := net.ParseMAC(origMAC)
 
-	var linux tmpl.GrubLinuxCmd
-	var initrd tmpl.GrubInitrdCmd
-	if platform == "bios" {
-		linux = tmpl.GrubLinuxCmdBIOS
-		initrd = tmpl.GrubInitrdCmdBIOS
-	} else {
-		linux = tmpl.GrubLinuxCmdEFIX64
-		initrd = tmpl.GrubInitrdCmdEFIX64
-	}
-
-	err = WriteGrubConfig(r.Context(), w, mac, linux, initrd)
+	err = WriteGrubConfig(r.Context(), w, mac, platform)
 	if err != nil {
 		renderBootError(err, w, r, tmpl.GrubBootErrorType)
 		return
 	}
 }
 
-func WriteGrubConfig(ctx context.Context, w io.Writer, mac net.HardwareAddr, linux tmpl.GrubLinuxCmd, initrd tmpl.GrubInitrdCmd) error {
+// grubCommands returns kernel and initrd commands for a boot platform (bios, efi, efi64,
+// efiaa64). Systems with Secure Boot always boot via shim and grub EFI binaries, platforms
+// without an EFI directory are then determined by the architecture fact. EFI platforms from
+// the request are kept since enlisted systems have no architecture fact until discovery.
+func grubCommands(platform string, s *model.System) (tmpl.GrubLinuxCmd, tmpl.GrubInitrdCmd) {
+	if s.SecureBoot() && platform != "efi64" && platform != "efiaa64" {
+		platform = "efi64"
+		if s.Facts.FactsMap()[model.ArchFact] == model.Aarch64Arch {
+			platform = "efiaa64"
+		}
+	}
+
+	switch platform {
+	case "bios":
+		return tmpl.GrubLinuxCmdBIOS, tmpl.GrubInitrdCmdBIOS
+	case "efiaa64":
+		return tmpl.GrubLinuxCmdEFIAA64, tmpl.GrubInitrdCmdEFIAA64
+	default:
+		return tmpl.GrubLinuxCmdEFIX64, tmpl.GrubInitrdCmdEFIX64
+	}
+}
+
+// kernelArgs returns static network, system, image and installation kernel arguments in
+// order, they are merged on defaults by the template so removals apply to defaults too
+func kernelArgs(ctx context.Context, s *model.System, i *model.Installation) ([]string, error) {
+	image, err := db.GetImageDao(ctx).FindByID(ctx, i.ImageID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find image %d: %w", i.ImageID, err)
+	}
+
+	var network []string
+	if s.Network != nil {
+		network = s.Network.KernelArgs(ToHostname(s.Name))
+	}
+
+	result := make([]string, 0, len(network)+len(s.KernelArgs)+len(image.KernelArgs)+len(i.KernelArgs))
+	result = append(result, network...)
+	result = append(result, s.KernelArgs...)
+	result = append(result, image.KernelArgs...)
+	result = append(result, i.KernelArgs...)
+
+	return result, nil
+}
+
+func WriteGrubConfig(ctx context.Context, w io.Writer, mac net.HardwareAddr, platform string) error {
 	var err error
 	var s *model.System
 	var i *model.Installation
@@ -149,15 +290,30 @@ func WriteGrubConfig(ctx context.Context, w io.Writer, mac net.HardwareAddr, lin
 		return err
 	}
 
+	args, err := kernelArgs(ctx, s, i)
+	if err != nil {
+		return err
+	}
+
+	linux, initrd := grubCommands(platform, s)
 	params := tmpl.BootKernelParams{
 		SystemID:    s.ID,
 		ImageID:     i.ImageID,
 		InstallUUID: i.UUID.String(),
 		LinuxCmd:    linux,
 		InitrdCmd:   initrd,
+		KernelArgs:  args,
 	}
 
-	err = tmpl.RenderGrubKernel(ctx, w, params)
+	buf := bytes.Buffer{}
+	err = tmpl.RenderGrubKernel(ctx, &buf, params)
+	if err != nil {
+		return err
+	}
+	db.RecordEvent(ctx, i.ID, model.GrubConfigEventKind, buf.String())
+	advanceInstallation(ctx, i, s, model.BootingInstallState)
+
+	_, err = buf.WriteTo(w)
 	if err != nil {
 		return err
 	}
@@ -196,14 +352,31 @@ func WriteIpxeConfig(ctx context.Context, w io.Writer, mac net.HardwareAddr) err
 	if err != nil {
 		return err
 	}
+	if s.SecureBoot() {
+		return fmt.Errorf("%w: %s", ErrSecureBootIpxe, s.Name)
+	}
+
+	args, err := kernelArgs(ctx, s, i)
+	if err != nil {
+		return err
+	}
 
 	params := tmpl.BootKernelParams{
 		SystemID:    s.ID,
 		ImageID:     i.ImageID,
 		InstallUUID: i.UUID.String(),
+		KernelArgs:  args,
+	}
+
+	buf := bytes.Buffer{}
+	err = tmpl.RenderIpxeKernel(ctx, &buf, params)
+	if err != nil {
+		return err
 	}
+	db.RecordEvent(ctx, i.ID, model.IpxeConfigEventKind, buf.String())
+	advanceInstallation(ctx, i, s, model.BootingInstallState)
 
-	err = tmpl.RenderIpxeKernel(ctx, w, params)
+	_, err = buf.WriteTo(w)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,72 @@
+package mux
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"forester/internal/model"
+	"forester/internal/tmpl"
+)
+
+func TestGrubCommands(t *testing.T) {
+	facts := func(kv ...string) model.Facts {
+		var f model.Facts
+		for i := 0; i < len(kv); i += 2 {
+			f.List = append(f.List, model.Fact{Key: kv[i], Value: kv[i+1]})
+		}
+		return f
+	}
+
+	tests := map[string]struct {
+		platform string
+		facts    model.Facts
+		want     tmpl.GrubLinuxCmd
+	}{
+		"bios":                {platform: "bios", want: tmpl.GrubLinuxCmdBIOS},
+		"efi64":               {platform: "efi64", want: tmpl.GrubLinuxCmdEFIX64},
+		"efiaa64":             {platform: "efiaa64", want: tmpl.GrubLinuxCmdEFIAA64},
+		"secure boot off":     {platform: "bios", facts: facts("secure_boot", "false"), want: tmpl.GrubLinuxCmdBIOS},
+		"secure boot x86_64":  {platform: "bios", facts: facts("secure_boot", "true"), want: tmpl.GrubLinuxCmdEFIX64},
+		"secure boot aarch64": {platform: "efi", facts: facts("secure_boot", "true", "arch", "aarch64"), want: tmpl.GrubLinuxCmdEFIAA64},
+		"secure boot efiaa64": {platform: "efiaa64", facts: facts("secure_boot", "true"), want: tmpl.GrubLinuxCmdEFIAA64},
+		"secure boot efi64":   {platform: "efi64", facts: facts("secure_boot", "true", "arch", "aarch64"), want: tmpl.GrubLinuxCmdEFIX64},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			linux, _ := grubCommands(tc.platform, &model.System{Facts: tc.facts})
+			require.Equal(t, tc.want, linux)
+		})
+	}
+}
+
+func TestLockSystemBootISO(t *testing.T) {
+	unlockA := lockSystemBootISO("a.iso")
+
+	// other files are not blocked
+	unlockB := lockSystemBootISO("b.iso")
+	unlockB()
+
+	locked := make(chan struct{})
+	go func() {
+		unlock := lockSystemBootISO("a.iso")
+		close(locked)
+		unlock()
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("the same file locked twice")
+	case <-time.After(10 * time.Millisecond):
+	}
+	unlockA()
+	<-locked
+
+	require.Eventually(t, func() bool {
+		systemBootISOs.Lock()
+		defer systemBootISOs.Unlock()
+		return len(systemBootISOs.m) == 0
+	}, time.Second, time.Millisecond)
+}
@@ -3,14 +3,34 @@ package mux
 import (
 	"encoding/hex"
 	"log/slog"
+	"net"
 	"net/http"
+	"strings"
+	"time"
 
 	chi "github.com/go-chi/chi/v5"
 
 	"forester/internal/db"
+	"forester/internal/model"
 	"forester/internal/tmpl"
 )
 
+// SinceHeader contains database time of a config generation, pass it as "since" query
+// parameter of the next request to get only entries changed in the meantime. Requests
+// older than removedRetention are refused with 410 Gone, fetch the full config then.
+const SinceHeader = "X-Forester-Since"
+
+// deltaMargin is subtracted from the "since" cursor so changes of transactions committed
+// during the config generation are included in the next delta again
+const deltaMargin = time.Minute
+
+// removedRetention is how long removed MAC addresses are kept for delta configs
+const removedRetention = 7 * 24 * time.Hour
+
+// RemovedHeader contains comma-separated MAC addresses removed from systems in the meantime
+// in responses to "since" requests, their entries are to be removed from the configuration
+const RemovedHeader = "X-Forester-Removed"
+
 func MountConf(r *chi.Mux) {
 	r.Get("/iscdhcpd/grub", serveConf("iscdhcpd", "grub"))
 	r.Get("/iscdhcpd/ipxe", serveConf("iscdhcpd", "ipxe"))
@@ -18,6 +38,10 @@ func MountConf(r *chi.Mux) {
 	r.Get("/dnsmasq/ipxe", serveConf("dnsmasq", "ipxe"))
 	r.Get("/libvirt/grub", serveConf("libvirt", "grub"))
 	r.Get("/libvirt/ipxe", serveConf("libvirt", "ipxe"))
+	r.Get("/kea/grub", serveConf("kea", "grub"))
+	r.Get("/kea/ipxe", serveConf("kea", "ipxe"))
+	r.Get("/networkd/grub", serveConf("networkd", "grub"))
+	r.Get("/networkd/ipxe", serveConf("networkd", "ipxe"))
 }
 
 func allZero(s []byte) bool {
@@ -29,32 +53,94 @@ func allZero(s []byte) bool {
 	return true
 }
 
+// dhcpEntries returns entries for all unique MAC addresses of systems, the address fact
+// is assigned to the first MAC address of a system
+func dhcpEntries(systems []*model.System) []tmpl.DhcpEntry {
+	entries := make([]tmpl.DhcpEntry, 0, len(systems)*4)
+	for _, s := range systems {
+		address := s.Facts.FactsMap()[model.DhcpAddressFact]
+		if ip := net.ParseIP(address); ip == nil || ip.To4() == nil {
+			address = ""
+		}
+
+		for _, mac := range s.HwAddrs.Unique() {
+			if allZero(mac) {
+				continue
+			}
+			e := tmpl.DhcpEntry{
+				Tag:        "t" + hex.EncodeToString(mac),
+				MAC:        mac.String(),
+				Hex:        hex.EncodeToString(mac),
+				Hostname:   ToHostname(s.Name),
+				Address:    address,
+				SecureBoot: s.SecureBoot(),
+			}
+			address = ""
+			entries = append(entries, e)
+		}
+	}
+
+	return entries
+}
+
+func joinMACs(macs []net.HardwareAddr) string {
+	result := make([]string, len(macs))
+	for i, mac := range macs {
+		result[i] = mac.String()
+	}
+	return strings.Join(result, ",")
+}
+
 func serveConf(name, format string) func(w http.ResponseWriter, r *http.Request) {
 	f := func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		sDao := db.GetSystemDao(ctx)
-		systems, err := sDao.List(ctx, 1000000, 0)
+		now, err := sDao.Now(ctx)
 		if err != nil {
 			slog.WarnContext(ctx, "error during dnsmasq config generation", "err", err)
-			http.Error(w, "# system list error: ", http.StatusInternalServerError)
+			http.Error(w, "# database time error", http.StatusInternalServerError)
 			return
 		}
 
-		entries := make([]tmpl.DhcpEntry, 0, len(systems)*4)
-		for _, s := range systems {
-			for _, mac := range s.HwAddrs.Unique() {
-				if allZero(mac) {
-					continue
-				}
-				e := tmpl.DhcpEntry{
-					Tag: "t" + hex.EncodeToString(mac),
-					MAC: mac.String(),
-				}
-				entries = append(entries, e)
+		expired := now.Add(-removedRetention)
+		if n, err := sDao.DeleteRemovedBefore(ctx, expired); err != nil {
+			slog.WarnContext(ctx, "cannot prune removed MAC addresses", "err", err)
+		} else if n > 0 {
+			slog.DebugContext(ctx, "pruned removed MAC addresses", "count", n)
+		}
+
+		var systems []*model.System
+		var removed []net.HardwareAddr
+		delta := r.URL.Query().Has("since")
+		if delta {
+			since, perr := time.Parse(time.RFC3339, r.URL.Query().Get("since"))
+			if perr != nil {
+				http.Error(w, "# invalid since parameter, use RFC3339 format", http.StatusBadRequest)
+				return
+			}
+			if since.Before(expired) {
+				http.Error(w, "# since parameter is older than removal retention, fetch full config", http.StatusGone)
+				return
+			}
+			systems, err = sDao.ListUpdatedSince(ctx, since)
+			if err == nil {
+				removed, err = sDao.ListRemovedSince(ctx, since)
 			}
+		} else {
+			systems, err = sDao.List(ctx, 1000000, 0)
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "error during dnsmasq config generation", "err", err)
+			http.Error(w, "# system list error: ", http.StatusInternalServerError)
+			return
 		}
 
-		err = tmpl.RenderDhcpConf(r.Context(), w, name, format, tmpl.DhcpParams{Entries: entries})
+		w.Header().Set(SinceHeader, now.Add(-deltaMargin).UTC().Format(time.RFC3339))
+		if delta {
+			w.Header().Set(RemovedHeader, joinMACs(removed))
+		}
+		params := tmpl.DhcpParams{Entries: dhcpEntries(systems), Delta: delta}
+		err = tmpl.RenderDhcpConf(r.Context(), w, name, format, params)
 		if err != nil {
 			slog.ErrorContext(r.Context(), "cannot render dhcp config template", "err", err)
 			w.WriteHeader(http.StatusInternalServerError)
@@ -1,18 +1,16 @@
 package mux
 
 import (
-	"context"
 	"log/slog"
 	"net/http"
-	"time"
 
 	chi "github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 	"github.com/google/uuid"
 
+	"forester/internal/config"
 	"forester/internal/db"
-	"forester/internal/logging"
-	"forester/internal/metal"
+	"forester/internal/jobs"
 	"forester/internal/model"
 )
 
@@ -24,6 +22,12 @@ func MountDone(r *chi.Mux) {
 	})
 }
 
+// bootsLocal returns true for appliance kinds booted from disk by a local boot job after
+// the installation, systems without power management reboot on their own.
+func bootsLocal(kind model.ApplianceKind) bool {
+	return kind != model.NoopApplianceKind && kind != model.RedfishManualApplianceKind
+}
+
 func HandleDone(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	id, err := uuid.Parse(chi.URLParam(r, "UUID"))
@@ -41,7 +45,6 @@ func HandleDone(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	slog.DebugContext(ctx, "installation done - system will be started soon", "system_id", id)
 	sDao := db.GetSystemDao(ctx)
 	systemAppliance, err := sDao.FindByIDRelated(ctx, inst.SystemID)
 	if err != nil {
@@ -49,35 +52,28 @@ func HandleDone(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
+
+	slog.DebugContext(ctx, "installation done - system will be started soon", "system_id", id)
+	db.RecordEvent(ctx, inst.ID, model.DoneEventKind, r.RemoteAddr)
+	advanceInstallation(ctx, inst, &systemAppliance.System, model.FinishedInstallState)
 	if systemAppliance.ApplianceID == nil {
 		slog.InfoContext(ctx, "system has no appliance associated", "system_id", inst.SystemID)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	// boot libvirt manually
-	if systemAppliance.Appliance.Kind != model.LibvirtApplianceKind {
-		slog.InfoContext(ctx, "system appliance is not libvirt", "system_id", inst.SystemID)
-		w.WriteHeader(http.StatusBadRequest)
+	if !bootsLocal(systemAppliance.Appliance.Kind) {
+		slog.InfoContext(ctx, "system appliance does not perform local boot", "system_id", inst.SystemID)
+		w.WriteHeader(http.StatusOK)
 		return
 	}
 
-	m := metal.ForKind(systemAppliance.Appliance.Kind)
-	// cannot pass request context it will be cancelled
-	bctx := logging.WithTraceId(context.Background(), logging.TraceId(ctx))
-	go bootLocal(bctx, m, systemAppliance)
+	_, err = jobs.Enqueue(ctx, model.BootLocalJobKind, systemAppliance, &inst.ID, config.Jobs.BootLocalDelay)
+	if err != nil {
+		slog.ErrorContext(ctx, "cannot enqueue local boot", "system_id", inst.SystemID, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 
 	w.WriteHeader(http.StatusOK)
 }
-
-func bootLocal(ctx context.Context, m metal.Metal, s *model.SystemAppliance) {
-	slog.InfoContext(ctx, "scheduled system reboot", "system_id", s.System.ID)
-	go func() {
-		time.Sleep(6 * time.Second)
-		slog.InfoContext(ctx, "booting system locally", "system_id", s.System.ID)
-		err := m.BootLocal(ctx, s)
-		if err != nil {
-			slog.InfoContext(ctx, "error during local boot", "system_id", s.System.ID, "error", err.Error())
-		}
-	}()
-}
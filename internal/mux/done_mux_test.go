@@ -0,0 +1,107 @@
+package mux
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	chi "github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"forester/internal/db"
+	"forester/internal/model"
+)
+
+func TestBootsLocal(t *testing.T) {
+	tests := map[model.ApplianceKind]bool{
+		model.NoopApplianceKind:          false,
+		model.RedfishManualApplianceKind: false,
+		model.LibvirtApplianceKind:       true,
+		model.RedfishApplianceKind:       true,
+		model.RedfishVMediaApplianceKind: true,
+		model.IpmiApplianceKind:          true,
+	}
+
+	for kind, want := range tests {
+		t.Run(strconv.Itoa(int(kind)), func(t *testing.T) {
+			require.Equal(t, want, bootsLocal(kind))
+		})
+	}
+}
+
+// installationDaoMock serves a single installation and records its state transitions
+type installationDaoMock struct {
+	db.InstallationDao
+	inst        *model.Installation
+	transitions []model.InstallState
+}
+
+func (dao *installationDaoMock) FindValid(ctx context.Context, id uuid.UUID, state model.InstallState) (*model.Installation, error) {
+	return dao.inst, nil
+}
+
+func (dao *installationDaoMock) Transition(ctx context.Context, id int64, to model.InstallState) error {
+	dao.transitions = append(dao.transitions, to)
+	return nil
+}
+
+// systemDaoMock serves a single system
+type systemDaoMock struct {
+	db.SystemDao
+	system *model.SystemAppliance
+}
+
+func (dao *systemDaoMock) FindByIDRelated(ctx context.Context, id int64) (*model.SystemAppliance, error) {
+	return dao.system, nil
+}
+
+// eventDaoMock discards events
+type eventDaoMock struct {
+	db.InstallationEventDao
+}
+
+func (dao *eventDaoMock) Create(ctx context.Context, e *model.InstallationEvent) error {
+	return nil
+}
+
+func TestHandleDone(t *testing.T) {
+	applianceID := int64(1)
+	tests := map[string]struct {
+		mac  string
+		want []model.InstallState
+	}{
+		"system":    {mac: "aa:bb:cc:dd:ee:ff", want: []model.InstallState{model.FinishedInstallState}},
+		"discovery": {mac: "00:00:00:00:00:00"},
+	}
+
+	origInst, origSys, origEvent := db.GetInstallationDao, db.GetSystemDao, db.GetInstallationEventDao
+	t.Cleanup(func() {
+		db.GetInstallationDao, db.GetSystemDao, db.GetInstallationEventDao = origInst, origSys, origEvent
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			mac, err := net.ParseMAC(tc.mac)
+			require.NoError(t, err)
+			iDao := &installationDaoMock{inst: &model.Installation{ID: 1, SystemID: 2}}
+			sDao := &systemDaoMock{system: &model.SystemAppliance{
+				System:    model.System{ID: 2, HwAddrs: model.HwAddrSlice{mac}, ApplianceID: &applianceID},
+				Appliance: model.Appliance{ID: applianceID, Kind: model.NoopApplianceKind},
+			}}
+			db.GetInstallationDao = func(ctx context.Context) db.InstallationDao { return iDao }
+			db.GetSystemDao = func(ctx context.Context) db.SystemDao { return sDao }
+			db.GetInstallationEventDao = func(ctx context.Context) db.InstallationEventDao { return &eventDaoMock{} }
+
+			r := chi.NewRouter()
+			MountDone(r)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/"+uuid.NewString(), nil))
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, tc.want, iDao.transitions)
+		})
+	}
+}
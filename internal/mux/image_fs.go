@@ -0,0 +1,153 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"sync"
+
+	"forester/internal/iso9660"
+)
+
+// imageAliases are files served under a different name than they have in the ISO, the first
+// existing candidate is served (x86_64 and aarch64 names)
+var imageAliases = map[string][]string{
+	"shim.efi":     {"EFI/BOOT/BOOTX64.EFI", "EFI/BOOT/BOOTAA64.EFI"},
+	"grubx64.efi":  {"EFI/BOOT/grubx64.efi"},
+	"grubaa64.efi": {"EFI/BOOT/grubaa64.efi"},
+}
+
+// imageFS serves files of the image directory (generated files or a tree extracted by
+// xorriso), other files are read directly from the ISO.
+type imageFS struct {
+	dir fs.FS
+	iso *iso9660.FS
+	ref *cachedISO
+}
+
+var _ fs.FS = (*imageFS)(nil)
+
+// cachedISO is a parsed ISO file system shared by all image file systems of an image, the
+// file is closed once it is invalidated and no longer used.
+type cachedISO struct {
+	iso   *iso9660.FS
+	file  *os.File
+	refs  int
+	stale bool
+}
+
+// isoCache holds parsed ISO file systems by ISO path
+var isoCache = struct {
+	sync.Mutex
+	m map[string]*cachedISO
+}{m: make(map[string]*cachedISO)}
+
+// invalidateImageFS drops the parsed ISO of an image from the cache, it must be called
+// before the ISO file is written or removed.
+func invalidateImageFS(imageID int64) {
+	isoCache.Lock()
+	defer isoCache.Unlock()
+
+	c, ok := isoCache.m[isoPath(imageID)]
+	if !ok {
+		return
+	}
+	delete(isoCache.m, isoPath(imageID))
+	c.stale = true
+	if c.refs == 0 {
+		c.file.Close()
+	}
+}
+
+// openImageFS opens file system of an image, it must be closed. When the ISO cannot be read,
+// only the image directory is served. Parsed ISO is cached until invalidateImageFS is called.
+func openImageFS(ctx context.Context, imageID int64) *imageFS {
+	result := &imageFS{dir: os.DirFS(dirPath(imageID))}
+	name := isoPath(imageID)
+
+	isoCache.Lock()
+	if c, ok := isoCache.m[name]; ok {
+		c.refs++
+		isoCache.Unlock()
+		result.iso, result.ref = c.iso, c
+		return result
+	}
+	isoCache.Unlock()
+
+	f, err := os.Open(name)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			slog.WarnContext(ctx, "cannot open ISO image", "image_id", imageID, "err", err)
+		}
+		return result
+	}
+
+	iso, err := iso9660.Open(f)
+	if err != nil {
+		slog.WarnContext(ctx, "cannot read ISO image, serving image directory only", "image_id", imageID, "err", err)
+		f.Close()
+		return result
+	}
+
+	isoCache.Lock()
+	defer isoCache.Unlock()
+	c, ok := isoCache.m[name]
+	if ok {
+		// opened concurrently
+		f.Close()
+	} else {
+		c = &cachedISO{iso: iso, file: f}
+		isoCache.m[name] = c
+	}
+	c.refs++
+	result.iso, result.ref = c.iso, c
+
+	return result
+}
+
+func (ifs *imageFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	f, err := openAlias(ifs.dir, name)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) || ifs.iso == nil {
+		return f, err
+	}
+
+	return openAlias(ifs.iso, name)
+}
+
+// openAlias opens a file or the first existing alias of it
+func openAlias(fsys fs.FS, name string) (fs.File, error) {
+	f, err := fsys.Open(name)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return f, err
+	}
+
+	for _, alias := range imageAliases[name] {
+		af, aerr := fsys.Open(alias)
+		if aerr == nil || !errors.Is(aerr, fs.ErrNotExist) {
+			return af, aerr
+		}
+	}
+	return nil, err
+}
+
+func (ifs *imageFS) Close() error {
+	if ifs.ref == nil {
+		return nil
+	}
+
+	isoCache.Lock()
+	defer isoCache.Unlock()
+	c := ifs.ref
+	ifs.ref = nil
+	c.refs--
+	if c.stale && c.refs == 0 {
+		return c.file.Close()
+	}
+	return nil
+}
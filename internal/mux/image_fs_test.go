@@ -0,0 +1,101 @@
+package mux
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"forester/internal/config"
+	"forester/internal/img"
+)
+
+func TestImageFS(t *testing.T) {
+	config.Images.Directory = t.TempDir()
+	require.NoError(t, ensureDir(1))
+
+	iso, err := os.ReadFile("../../fixtures/iso/fixture-netboot.iso")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(isoPath(1), iso, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dirPath(1), "boot.iso"), []byte("generated"), 0644))
+
+	root := openImageFS(context.Background(), 1)
+	defer root.Close()
+	require.NotNil(t, root.iso)
+
+	for _, name := range []string{"boot.iso", "shim.efi", "grubx64.efi", "images/pxeboot/vmlinuz", ".discinfo"} {
+		_, err := fs.Stat(root, name)
+		require.NoError(t, err, name)
+	}
+	_, err = fs.Stat(root, "missing")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	// files in the ISO are served with range support
+	gz, err := os.Open("../iso9660/testdata/rr.iso.gz")
+	require.NoError(t, err)
+	defer gz.Close()
+	zr, err := gzip.NewReader(gz)
+	require.NoError(t, err)
+	require.NoError(t, ensureDir(2))
+	_, err = img.Copy(context.Background(), isoPath(2), 0, zr)
+	require.NoError(t, err)
+
+	root2 := openImageFS(context.Background(), 2)
+	defer root2.Close()
+	vmlinuz, err := fs.ReadFile(root2, "images/pxeboot/vmlinuz")
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.FileServer(http.FS(root2)))
+	defer srv.Close()
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/images/pxeboot/vmlinuz", nil)
+	require.NoError(t, err)
+	req.Header.Set("Range", "bytes=2040-2059")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	require.Equal(t, vmlinuz[2040:2060], body)
+}
+
+func TestImageFSCache(t *testing.T) {
+	config.Images.Directory = t.TempDir()
+	require.NoError(t, ensureDir(1))
+	iso, err := os.ReadFile("../../fixtures/iso/fixture-netboot.iso")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(isoPath(1), iso, 0644))
+	ctx := context.Background()
+
+	root := openImageFS(ctx, 1)
+	defer root.Close()
+	cached := openImageFS(ctx, 1)
+	require.Same(t, root.iso, cached.iso)
+	require.NoError(t, cached.Close())
+	require.NoError(t, cached.Close())
+
+	// file of an invalidated ISO is closed once it is not used
+	invalidateImageFS(1)
+	reopened := openImageFS(ctx, 1)
+	defer reopened.Close()
+	require.NotSame(t, root.iso, reopened.iso)
+	_, err = fs.Stat(root, ".discinfo")
+	require.NoError(t, err)
+	file := root.ref.file
+	require.NoError(t, root.Close())
+	_, err = file.Stat()
+	require.ErrorIs(t, err, os.ErrClosed)
+
+	invalidateImageFS(1)
+	require.NoError(t, os.Remove(isoPath(1)))
+	removed := openImageFS(ctx, 1)
+	defer removed.Close()
+	require.Nil(t, removed.iso)
+}
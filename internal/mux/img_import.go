@@ -0,0 +1,163 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"forester/internal/db"
+	"forester/internal/img"
+	"forester/internal/jobs"
+	"forester/internal/model"
+)
+
+var ErrImportInProgress = errors.New("image upload or import already in progress")
+
+var ErrImportStatus = errors.New("unexpected HTTP status")
+
+// permanent marks download errors which are not retried
+func permanent(err error) error {
+	return fmt.Errorf("%w: %w", jobs.ErrPermanent, err)
+}
+
+// ImportImage downloads ISO of an image from URL of an import job, verifies its checksum
+// and starts extraction. Interrupted download is resumed by the next attempt of the job,
+// checksum mismatch is not retried.
+func ImportImage(ctx context.Context, job *model.Job) error {
+	if job.ImageID == nil {
+		return permanent(errors.New("job with no image"))
+	}
+
+	dbImage, err := db.GetImageDao(ctx).FindByID(ctx, *job.ImageID)
+	if err != nil {
+		return permanent(fmt.Errorf("cannot find image: %w", err))
+	}
+	if dbImage.Status != model.UploadingImageStatus {
+		slog.InfoContext(ctx, "image is not being uploaded, import skipped", "image_id", dbImage.ID, "status", dbImage.Status)
+		return nil
+	}
+
+	if !lockUpload(dbImage.ID) {
+		return ErrImportInProgress
+	}
+	defer unlockUpload(dbImage.ID)
+
+	slog.InfoContext(ctx, "importing image", "image_id", dbImage.ID, "url", job.URL)
+	err = ensureDir(dbImage.ID)
+	if err == nil {
+		invalidateImageFS(dbImage.ID)
+		err = download(ctx, job.URL, isoPath(dbImage.ID), func(n, total int64) {
+			setImageStatus(ctx, dbImage, model.UploadingImageStatus, percent(n, total), "")
+		})
+	}
+	if err == nil {
+		err = completeUpload(ctx, dbImage)
+		if errors.Is(err, ErrImageChecksum) {
+			err = permanent(err)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, jobs.ErrPermanent) || job.Attempts >= job.MaxAttempts {
+			failImage(ctx, dbImage, "cannot import image", err)
+		} else if ctx.Err() == nil {
+			setImageStatus(ctx, dbImage, model.UploadingImageStatus, dbImage.Progress, fmt.Sprintf("download attempt %d failed, will retry: %s", job.Attempts, err))
+		}
+		return err
+	}
+
+	startExtraction(ctx, dbImage)
+
+	return nil
+}
+
+// download fetches URL into file, partially downloaded file is resumed. Errors which are
+// not worth retrying are marked permanent. Progress is periodically reported when report
+// is not nil.
+func download(ctx context.Context, url, dest string, report func(n, total int64)) error {
+	offset, err := fileSize(dest)
+	if err != nil {
+		return permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return permanent(fmt.Errorf("cannot create request: %w", err))
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot download: %w", err)
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusOK:
+		// range not supported, start over
+		offset = 0
+	case res.StatusCode == http.StatusPartialContent:
+		start, _, _, err := parseContentRange(res.Header.Get("Content-Range"))
+		if err != nil || start != offset {
+			return permanent(fmt.Errorf("unexpected range %q: %w", res.Header.Get("Content-Range"), ErrInvalidContentRange))
+		}
+	case res.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// already downloaded
+		return nil
+	case res.StatusCode >= 500:
+		return fmt.Errorf("%w: %s", ErrImportStatus, res.Status)
+	default:
+		return permanent(fmt.Errorf("%w: %s", ErrImportStatus, res.Status))
+	}
+
+	total := int64(-1)
+	if res.ContentLength >= 0 {
+		total = offset + res.ContentLength
+	}
+
+	pr := &progressReader{ctx: ctx, r: res.Body, n: offset, total: total, report: report, logged: time.Now()}
+	n, err := img.Copy(ctx, dest, offset, pr)
+	if err != nil {
+		return err
+	}
+
+	if res.ContentLength >= 0 && n != res.ContentLength {
+		return fmt.Errorf("cannot download: %w", io.ErrUnexpectedEOF)
+	}
+	slog.InfoContext(ctx, "image downloaded", "size", offset+n)
+
+	return nil
+}
+
+// progressInterval is how often download progress is logged
+const progressInterval = 10 * time.Second
+
+// progressReader logs and reports progress of a download
+type progressReader struct {
+	ctx    context.Context
+	r      io.Reader
+	n      int64
+	total  int64
+	report func(n, total int64)
+	logged time.Time
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	pr.n += int64(n)
+
+	if time.Since(pr.logged) >= progressInterval {
+		pr.logged = time.Now()
+		slog.InfoContext(pr.ctx, "image download progress", "bytes", pr.n, "total", pr.total, "percent", percent(pr.n, pr.total))
+		if pr.report != nil {
+			pr.report(pr.n, pr.total)
+		}
+	}
+
+	return n, err
+}
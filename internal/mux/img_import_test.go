@@ -0,0 +1,71 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"forester/internal/jobs"
+)
+
+func TestDownload(t *testing.T) {
+	content := bytes.Repeat([]byte("forester"), 1024)
+	var requests atomic.Int32
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if requests.Add(1) == 1 {
+			// first attempt is interrupted in the middle of the body
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			_, _ = w.Write(content[:1000])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "image.iso", time.Now(), bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "image.iso")
+	err := download(context.Background(), srv.URL, dest, nil)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, jobs.ErrPermanent)
+
+	// next attempt resumes the download
+	err = download(context.Background(), srv.URL, dest, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"", "bytes=1000-"}, ranges)
+
+	got, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	require.Equal(t, content, got)
+
+	// completed download is not transferred again
+	err = download(context.Background(), srv.URL, dest, nil)
+	require.NoError(t, err)
+	got, err = os.ReadFile(dest)
+	require.NoError(t, err)
+	require.Equal(t, content, got)
+}
+
+func TestDownloadNotFound(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	err := download(context.Background(), srv.URL, filepath.Join(t.TempDir(), "image.iso"), nil)
+	require.ErrorIs(t, err, ErrImportStatus)
+	require.ErrorIs(t, err, jobs.ErrPermanent)
+	require.Equal(t, int32(1), requests.Load())
+}
@@ -7,12 +7,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"io/fs"
 	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	chi "github.com/go-chi/chi/v5"
@@ -25,53 +27,313 @@ import (
 )
 
 func MountImages(r *chi.Mux) {
+	r.Head("/{ID}", uploadStatus)
 	r.Head("/*", serveImagePath)
 	r.Get("/*", serveImagePath)
 	r.Put("/{ID}", uploadImage)
 }
 
-func uploadImage(w http.ResponseWriter, r *http.Request) {
-	if !HasContentType(r, "application/octet-stream") {
-		w.WriteHeader(http.StatusUnsupportedMediaType)
-		return
+// Resumable upload protocol: HEAD /img/{ID} returns number of bytes already stored in
+// Upload-Offset header, PUT /img/{ID} with Content-Range header appends a chunk at that
+// offset. PUT without Content-Range uploads the whole image from the beginning. The image
+// is verified and extracted once the last byte is received.
+const (
+	uploadOffsetHeader   = "Upload-Offset"
+	uploadCompleteHeader = "Upload-Complete"
+)
+
+var ErrInvalidContentRange = errors.New("invalid content range")
+
+// parseContentRange parses header in form of "bytes start-end/total".
+func parseContentRange(value string) (start, end, total int64, err error) {
+	var n int
+	n, err = fmt.Sscanf(value, "bytes %d-%d/%d", &start, &end, &total)
+	if err != nil || n != 3 {
+		return 0, 0, 0, fmt.Errorf("%w: %s", ErrInvalidContentRange, value)
+	}
+
+	if start < 0 || end < start || total <= end {
+		return 0, 0, 0, fmt.Errorf("%w: %s", ErrInvalidContentRange, value)
+	}
+
+	return start, end, total, nil
+}
+
+// uploads holds IDs of images being uploaded, only one upload per image is allowed
+var uploads = struct {
+	sync.Mutex
+	m map[int64]struct{}
+}{m: make(map[int64]struct{})}
+
+func lockUpload(id int64) bool {
+	uploads.Lock()
+	defer uploads.Unlock()
+
+	if _, ok := uploads.m[id]; ok {
+		return false
+	}
+	uploads.m[id] = struct{}{}
+	return true
+}
+
+func unlockUpload(id int64) {
+	uploads.Lock()
+	defer uploads.Unlock()
+
+	delete(uploads.m, id)
+}
+
+// fileSize returns size of a partially uploaded or downloaded file, zero when it does not exist.
+func fileSize(name string) (int64, error) {
+	fi, err := os.Stat(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("cannot stat image: %w", err)
 	}
+
+	return fi.Size(), nil
+}
+
+func findUploadImage(w http.ResponseWriter, r *http.Request) *model.Image {
 	id, err := strconv.ParseInt(chi.URLParam(r, "ID"), 10, 64)
 	if err != nil {
 		slog.ErrorContext(r.Context(), "invalid ID", "err", err)
 		w.WriteHeader(http.StatusBadRequest)
-		return
+		return nil
 	}
 
 	dao := db.GetImageDao(r.Context())
 	dbImage, err := dao.FindByID(r.Context(), id)
-	if err != nil {
+	if errors.Is(err, db.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return nil
+	} else if err != nil {
 		slog.ErrorContext(r.Context(), "cannot find image with this id", "err", err)
 		w.WriteHeader(http.StatusInternalServerError)
+		return nil
+	}
+
+	return dbImage
+}
+
+func uploadStatus(w http.ResponseWriter, r *http.Request) {
+	dbImage := findUploadImage(w, r)
+	if dbImage == nil {
+		return
+	}
+
+	size, err := fileSize(isoPath(dbImage.ID))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "cannot determine upload offset", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set(uploadOffsetHeader, strconv.FormatInt(size, 10))
+	if dbImage.IsoSha256 != "" {
+		w.Header().Set(uploadCompleteHeader, "true")
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func uploadImage(w http.ResponseWriter, r *http.Request) {
+	if !HasContentType(r, "application/octet-stream") {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+
+	dbImage := findUploadImage(w, r)
+	if dbImage == nil {
+		return
+	}
+
+	if dbImage.IsoSha256 != "" {
+		slog.WarnContext(r.Context(), "image already uploaded", "image_id", dbImage.ID)
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	if !lockUpload(dbImage.ID) {
+		slog.WarnContext(r.Context(), "image upload already in progress", "image_id", dbImage.ID)
+		w.WriteHeader(http.StatusConflict)
 		return
 	}
+	defer unlockUpload(dbImage.ID)
 
-	err = ensureDir(dbImage.ID)
+	err := ensureDir(dbImage.ID)
 	if err != nil {
 		slog.ErrorContext(r.Context(), "cannot create dir for ISO", "err", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	n, isoSha256, err := img.Copy(r.Context(), isoPath(dbImage.ID), r.Body)
+	size, err := fileSize(isoPath(dbImage.ID))
 	if err != nil {
-		slog.ErrorContext(r.Context(), "cannot copy image", "err", err)
+		slog.ErrorContext(r.Context(), "cannot determine upload offset", "err", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	slog.DebugContext(r.Context(), "image written", "size", n, "sha256sum", isoSha256)
+
+	// without range the whole image is uploaded
+	var start, end, total int64 = 0, -1, -1
+	if cr := r.Header.Get("Content-Range"); cr != "" {
+		start, end, total, err = parseContentRange(cr)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "cannot parse range", "err", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if start != size {
+			slog.WarnContext(r.Context(), "upload offset mismatch", "offset", size, "start", start)
+			w.Header().Set(uploadOffsetHeader, strconv.FormatInt(size, 10))
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+	}
+
+	invalidateImageFS(dbImage.ID)
+	n, err := img.Copy(r.Context(), isoPath(dbImage.ID), start, r.Body)
+	offset := start + n
+	w.Header().Set(uploadOffsetHeader, strconv.FormatInt(offset, 10))
+	if err != nil {
+		// the written part is kept and the upload can be resumed
+		slog.ErrorContext(r.Context(), "cannot copy image", "err", err, "offset", offset)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	slog.DebugContext(r.Context(), "image chunk written", "size", n, "offset", offset)
+
+	if total >= 0 && offset != end+1 {
+		slog.ErrorContext(r.Context(), "chunk size does not match range", "offset", offset, "end", end)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if offset < total {
+		// more chunks to come
+		setImageStatus(r.Context(), dbImage, model.UploadingImageStatus, percent(offset, total), "")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	err = completeUpload(r.Context(), dbImage)
+	if errors.Is(err, ErrImageChecksum) {
+		failImage(r.Context(), dbImage, "discarding upload", err)
+		w.Header().Set(uploadOffsetHeader, "0")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "cannot complete upload", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	startExtraction(r.Context(), dbImage)
+}
+
+var ErrImageChecksum = errors.New("image checksum mismatch")
+
+// completeUpload verifies checksum of a fully transferred ISO and stores it, the ISO is
+// removed when it does not match the expected checksum.
+func completeUpload(ctx context.Context, dbImage *model.Image) error {
+	// a partial ISO could be parsed while transferring
+	invalidateImageFS(dbImage.ID)
+
+	isoSha256, err := sha256sum(isoPath(dbImage.ID))
+	if err != nil {
+		return fmt.Errorf("cannot calculate ISO sha256: %w", err)
+	}
+	slog.DebugContext(ctx, "image written", "sha256sum", isoSha256)
+
+	if dbImage.ExpectedSha256 != "" && dbImage.ExpectedSha256 != isoSha256 {
+		_ = os.Remove(isoPath(dbImage.ID))
+		return fmt.Errorf("%w: expected %s got %s", ErrImageChecksum, dbImage.ExpectedSha256, isoSha256)
+	}
 
 	dbImage.IsoSha256 = isoSha256
-	err = dao.Update(r.Context(), dbImage)
+	err = db.GetImageDao(ctx).Update(ctx, dbImage)
 	if err != nil {
-		slog.ErrorContext(r.Context(), "could not update ISO sha256", "err", err)
+		return fmt.Errorf("could not update ISO sha256: %w", err)
 	}
 
-	go extractImage(dbImage)
+	return nil
+}
+
+var ErrImageProcessing = errors.New("image is being processed")
+
+var ErrImageNotFailed = errors.New("only failed images with complete upload can be extracted again")
+
+// ExtractImage extracts a failed image again from the already uploaded ISO, e.g. after
+// extraction was interrupted by controller restart.
+func ExtractImage(ctx context.Context, dbImage *model.Image) error {
+	if !lockUpload(dbImage.ID) {
+		return ErrImportInProgress
+	}
+	defer unlockUpload(dbImage.ID)
+
+	current, err := db.GetImageDao(ctx).FindByID(ctx, dbImage.ID)
+	if err != nil {
+		return fmt.Errorf("cannot find image: %w", err)
+	}
+	if current.Status != model.FailedImageStatus || current.IsoSha256 == "" {
+		return fmt.Errorf("%w: %s", ErrImageNotFailed, current.Status.String())
+	}
+
+	slog.InfoContext(ctx, "extracting failed image again", "image_id", current.ID, "name", current.Name)
+	startExtraction(ctx, current)
+
+	return nil
+}
+
+// DeleteImage removes an image record and its directory. The directory is renamed first so
+// a partially removed image is never served, then the record is deleted and the renamed
+// directory is removed. The directory is restored when the record cannot be deleted.
+func DeleteImage(ctx context.Context, dbImage *model.Image) error {
+	if !lockUpload(dbImage.ID) {
+		return ErrImportInProgress
+	}
+	defer unlockUpload(dbImage.ID)
+
+	// extraction is started under the lock, the status read before locking can be stale
+	current, err := db.GetImageDao(ctx).FindByID(ctx, dbImage.ID)
+	if err != nil {
+		return fmt.Errorf("cannot find image: %w", err)
+	}
+	if current.Status == model.ExtractingImageStatus || current.Status == model.GeneratingImageStatus {
+		return fmt.Errorf("%w: %s", ErrImageProcessing, current.Status.String())
+	}
+
+	invalidateImageFS(dbImage.ID)
+	dir := dirPath(dbImage.ID)
+	trash := filepath.Join(config.Images.Directory, fmt.Sprintf(".deleted-%d", dbImage.ID))
+	err = os.Rename(dir, trash)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("cannot rename image directory: %w", err)
+	}
+	renamed := err == nil
+
+	err = db.GetImageDao(ctx).Delete(ctx, dbImage.ID)
+	if err != nil {
+		if renamed {
+			if rerr := os.Rename(trash, dir); rerr != nil {
+				slog.ErrorContext(ctx, "cannot restore image directory", "dir", trash, "err", rerr)
+			}
+		}
+		return fmt.Errorf("cannot delete image: %w", err)
+	}
+
+	if renamed {
+		err = os.RemoveAll(trash)
+		if err != nil {
+			slog.WarnContext(ctx, "cannot remove image directory", "dir", trash, "err", err)
+		}
+	}
+	slog.InfoContext(ctx, "image deleted", "image_id", dbImage.ID, "name", dbImage.Name)
+
+	return nil
 }
 
 func ensureDir(imageId int64) error {
@@ -102,21 +364,64 @@ func sha256sum(file string) (string, error) {
 
 	h := sha256.New()
 	if _, err := io.Copy(h, f); err != nil {
-		log.Fatal(err)
+		return "", fmt.Errorf("cannot read %s: %w", file, err)
 	}
 
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
-func fileExists(name string) (bool, error) {
-	_, err := os.Stat(name)
-	if err == nil {
-		return true, nil
+// setImageStatus updates processing status of an image, errors are only logged.
+func setImageStatus(ctx context.Context, dbImage *model.Image, status model.ImageStatus, progress int16, message string) {
+	dbImage.Status, dbImage.Progress, dbImage.StatusMessage = status, progress, message
+	err := db.GetImageDao(ctx).UpdateStatus(ctx, dbImage.ID, status, progress, message)
+	if err != nil {
+		slog.ErrorContext(ctx, "cannot update image status", "image_id", dbImage.ID, "status", status.String(), "err", err)
 	}
-	if errors.Is(err, os.ErrNotExist) {
-		return false, nil
+}
+
+// failImage logs the error and marks the image as failed.
+func failImage(ctx context.Context, dbImage *model.Image, msg string, err error) {
+	slog.ErrorContext(ctx, msg, "image_id", dbImage.ID, "err", err)
+	setImageStatus(ctx, dbImage, model.FailedImageStatus, dbImage.Progress, fmt.Sprintf("%s: %s", msg, err.Error()))
+}
+
+// percent returns progress in percent, zero when total is not known.
+func percent(n, total int64) int16 {
+	if total <= 0 {
+		return 0
+	}
+	return int16(min(n*100/total, 100))
+}
+
+// verifySecureBoot checks that shim and grub are signed by the configured Secure Boot CA,
+// images are left unverified when no CA is configured. Images failing the verification
+// can still be deployed to systems with Secure Boot disabled.
+func verifySecureBoot(ctx context.Context, fsys fs.FS, dbImage *model.Image) (model.SecureBootStatus, string) {
+	if config.Images.SecureBootCA == "" {
+		return model.UnverifiedSecureBootStatus, ""
 	}
-	return false, err
+
+	roots, err := img.LoadCertPool(config.Images.SecureBootCA)
+	if err != nil {
+		slog.ErrorContext(ctx, "cannot load Secure Boot CA", "image_id", dbImage.ID, "err", err)
+		return model.UnverifiedSecureBootStatus, err.Error()
+	}
+
+	err = img.VerifySecureBoot(fsys, dbImage.Arch, roots)
+	if err != nil {
+		slog.WarnContext(ctx, "image would fail under Secure Boot", "image_id", dbImage.ID, "err", err)
+		return model.FailedSecureBootStatus, err.Error()
+	}
+
+	slog.DebugContext(ctx, "image EFI binaries verified", "image_id", dbImage.ID)
+	return model.VerifiedSecureBootStatus, ""
+}
+
+// startExtraction marks the image as extracting and extracts it in background, it must be
+// called with the upload lock held so DeleteImage never sees a stale status.
+func startExtraction(ctx context.Context, dbImage *model.Image) {
+	setImageStatus(ctx, dbImage, model.ExtractingImageStatus, 0, "")
+	go extractImage(dbImage)
 }
 
 func extractImage(dbImage *model.Image) {
@@ -126,73 +431,114 @@ func extractImage(dbImage *model.Image) {
 	ctx = logging.WithJobId(ctx, logging.NewJobId())
 	imagePath := dirPath(dbImage.ID)
 
-	err := ensureDir(dbImage.ID)
-	if err != nil {
-		slog.ErrorContext(ctx, "error during extraction", "err", err)
-		return
+	// progress is reported per phase (extracting and generating)
+	progress := func(p int16) {
+		setImageStatus(ctx, dbImage, dbImage.Status, p, "")
 	}
 
-	slog.DebugContext(ctx, "extracting ISO image", "img", imagePath)
-	err = img.ExtractToDir(ctx, isoPath(dbImage.ID), imagePath)
+	err := ensureDir(dbImage.ID)
 	if err != nil {
-		slog.ErrorContext(ctx, "error during extraction", "err", err)
+		failImage(ctx, dbImage, "error during extraction", err)
 		return
 	}
 
-	slog.DebugContext(ctx, "generating boot.iso image", "img", imagePath)
-	err = img.GenerateBootISO(ctx, dbImage.ID, imagePath)
+	// files are served directly from the ISO, only boot.iso sources are extracted into
+	// a temporary directory unless the ISO cannot be read in-process
+	root := openImageFS(ctx, dbImage.ID)
+	defer root.Close()
+
+	var sums map[string]string
+	sourcePath := imagePath
+	if root.iso != nil {
+		sourcePath, err = os.MkdirTemp(imagePath, ".genboot-")
+		if err != nil {
+			failImage(ctx, dbImage, "error during extraction", err)
+			return
+		}
+		defer os.RemoveAll(sourcePath)
+
+		slog.DebugContext(ctx, "extracting boot files", "img", imagePath)
+		_, err = img.ExtractToDir(ctx, isoPath(dbImage.ID), sourcePath, img.BootPaths)
+		if err != nil {
+			failImage(ctx, dbImage, "error during extraction", err)
+			return
+		}
+		progress(40)
+
+		sums, err = img.Checksums(ctx, root)
+	} else {
+		slog.DebugContext(ctx, "extracting ISO image", "img", imagePath)
+		sums, err = img.ExtractToDir(ctx, isoPath(dbImage.ID), imagePath, img.ServedPaths)
+	}
 	if err != nil {
-		slog.ErrorContext(ctx, "error during boot.iso generation", "err", err)
+		failImage(ctx, dbImage, "error during extraction", err)
 		return
 	}
+	progress(70)
 
-	err = os.Symlink("./EFI/BOOT/BOOTX64.EFI", filepath.Join(imagePath, "shim.efi"))
-	if err != nil {
-		slog.ErrorContext(ctx, "cannot create shim.efi symlink", "err", err)
-		return
+	dbImage.Meta = img.DetectMeta(root)
+	dbImage.Arch = dbImage.Meta[img.ArchMeta]
+	if dbImage.Arch == "" {
+		slog.WarnContext(ctx, "cannot detect image architecture, assuming x86_64", "image_id", dbImage.ID)
+		dbImage.Arch = model.X86_64Arch
 	}
-	err = os.Symlink("./EFI/BOOT/grubx64.efi", filepath.Join(imagePath, "grubx64.efi"))
-	if err != nil {
-		slog.ErrorContext(ctx, "cannot create grubx64.efi symlink", "err", err)
-		return
+
+	dbImage.SecureBoot, dbImage.SecureBootMessage = verifySecureBoot(ctx, root, dbImage)
+	progress(90)
+
+	// kernel arguments can be updated while the image is uploading or importing
+	if current, err := db.GetImageDao(ctx).FindByID(ctx, dbImage.ID); err == nil {
+		dbImage.KernelArgs = current.KernelArgs
 	}
 
 	// detect installer image
-	sum := sha256exist(ctx, filepath.Join(imagePath, "liveimg.tar.gz"))
-	if sum != "" {
+	if sum, ok := sums["liveimg.tar.gz"]; ok {
 		dbImage.LiveimgSha256 = sum
 		dbImage.Kind = model.ImageInstallerKind
 	}
 
 	// detect container image
-	sum = sha256exist(ctx, filepath.Join(imagePath, "container", "index.json"))
-	if sum != "" {
+	if sum, ok := sums["container/index.json"]; ok {
 		dbImage.LiveimgSha256 = sum
 		dbImage.Kind = model.ContainerInstallerKind
 	}
 
+	// detect generic netboot image (installation tree without payload)
+	if dbImage.Kind == model.UnknownImageKind && img.IsInstallTree(root) {
+		dbImage.Kind = model.RPMInstallerKind
+		dbImage.LocalRepo = img.HasRepository(root)
+	}
+
+	// detected attributes are saved before generating so the image can be deployed
+	dbImage.Status, dbImage.Progress, dbImage.StatusMessage = model.GeneratingImageStatus, 0, ""
 	dao := db.GetImageDao(ctx)
 	err = dao.Update(ctx, dbImage)
 	if err != nil {
-		slog.ErrorContext(ctx, "could not update image", "err", err)
+		failImage(ctx, dbImage, "could not update image", err)
+		return
 	}
-}
 
-func sha256exist(ctx context.Context, imgPath string) string {
-	if ok, err := fileExists(imgPath); ok && (err == nil) {
-		sum, err := sha256sum(imgPath)
-		if err != nil {
-			slog.ErrorContext(ctx, "error calculating SHA256 sum", "err", err, "file", imgPath)
-			return ""
-		}
-
-		slog.DebugContext(ctx, "calculated SHA256", "file", imgPath, "sha256sum", sum)
-		return sum
+	slog.DebugContext(ctx, "generating boot.iso image", "img", imagePath, "arch", dbImage.Arch)
+	err = img.GenerateBootISO(ctx, dbImage.ID, dbImage.Arch, dbImage.KernelArgs, sourcePath, imagePath, progress)
+	if err != nil {
+		failImage(ctx, dbImage, "error during boot.iso generation", err)
+		return
 	}
-	return ""
+
+	setImageStatus(ctx, dbImage, model.ReadyImageStatus, 100, "")
 }
 
 func serveImagePath(w http.ResponseWriter, r *http.Request) {
-	fs := http.StripPrefix("/img", http.FileServer(http.Dir(config.Images.Directory)))
+	id, _, _ := strings.Cut(chi.URLParam(r, "*"), "/")
+	imageID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	root := openImageFS(r.Context(), imageID)
+	defer root.Close()
+
+	fs := http.StripPrefix("/img/"+id, http.FileServer(http.FS(root)))
 	fs.ServeHTTP(w, r)
 }
@@ -0,0 +1,289 @@
+package mux
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	chi "github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+
+	"forester/internal/config"
+	"forester/internal/db"
+	"forester/internal/model"
+)
+
+type imageStatus struct {
+	Status   model.ImageStatus
+	Progress int16
+}
+
+// imageDaoMock keeps images in memory and records their status changes
+type imageDaoMock struct {
+	db.ImageDao
+
+	mu       sync.Mutex
+	images   map[int64]*model.Image
+	statuses []imageStatus
+
+	// deleteErr is returned by Delete when set
+	deleteErr error
+}
+
+func mockImageDao(t *testing.T, images ...*model.Image) *imageDaoMock {
+	dao := &imageDaoMock{images: make(map[int64]*model.Image)}
+	for _, i := range images {
+		dao.images[i.ID] = i
+	}
+
+	orig := db.GetImageDao
+	db.GetImageDao = func(ctx context.Context) db.ImageDao { return dao }
+	t.Cleanup(func() { db.GetImageDao = orig })
+
+	return dao
+}
+
+func (dao *imageDaoMock) FindByID(ctx context.Context, id int64) (*model.Image, error) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	i, ok := dao.images[id]
+	if !ok {
+		return nil, db.ErrNoRows
+	}
+	result := *i
+	return &result, nil
+}
+
+func (dao *imageDaoMock) Update(ctx context.Context, image *model.Image) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	i := *image
+	dao.images[image.ID] = &i
+	dao.statuses = append(dao.statuses, imageStatus{image.Status, image.Progress})
+	return nil
+}
+
+func (dao *imageDaoMock) UpdateStatus(ctx context.Context, id int64, status model.ImageStatus, progress int16, message string) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	i := dao.images[id]
+	i.Status, i.Progress, i.StatusMessage = status, progress, message
+	dao.statuses = append(dao.statuses, imageStatus{status, progress})
+	return nil
+}
+
+func (dao *imageDaoMock) Delete(ctx context.Context, id int64) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	if dao.deleteErr != nil {
+		return dao.deleteErr
+	}
+	if _, ok := dao.images[id]; !ok {
+		return db.ErrAffectedMismatch
+	}
+	delete(dao.images, id)
+	return nil
+}
+
+func TestParseContentRange(t *testing.T) {
+	type result struct {
+		Start, End, Total int64
+		Valid             bool
+	}
+	tests := map[string]struct {
+		input string
+		want  result
+	}{
+		"first":       {input: "bytes 0-99/200", want: result{0, 99, 200, true}},
+		"last":        {input: "bytes 100-199/200", want: result{100, 199, 200, true}},
+		"single byte": {input: "bytes 0-0/1", want: result{0, 0, 1, true}},
+		"empty":       {input: "", want: result{}},
+		"no unit":     {input: "0-99/200", want: result{}},
+		"unknown":     {input: "bytes */200", want: result{}},
+		"reversed":    {input: "bytes 99-0/200", want: result{}},
+		"over total":  {input: "bytes 0-200/200", want: result{}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			start, end, total, err := parseContentRange(tc.input)
+			if err != nil && !errors.Is(err, ErrInvalidContentRange) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := result{start, end, total, err == nil}
+			diff := cmp.Diff(tc.want, got)
+			if diff != "" {
+				t.Fatalf(diff)
+			}
+		})
+	}
+}
+
+func TestExtractImage(t *testing.T) {
+	config.Images.Directory = t.TempDir()
+	require.NoError(t, ensureDir(1))
+	iso, err := os.ReadFile("../../fixtures/iso/fixture-netboot.iso")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(isoPath(1), iso, 0644))
+
+	image := &model.Image{ID: 1, Name: "netboot", IsoSha256: "sum"}
+	dao := mockImageDao(t, image)
+	setImageStatus(context.Background(), image, model.ExtractingImageStatus, 0, "")
+	extractImage(image)
+
+	want := []imageStatus{
+		{model.ExtractingImageStatus, 0},
+		{model.ExtractingImageStatus, 40},
+		{model.ExtractingImageStatus, 70},
+		{model.ExtractingImageStatus, 90},
+		{model.GeneratingImageStatus, 0},
+		{model.GeneratingImageStatus, 80},
+		{model.ReadyImageStatus, 100},
+	}
+	require.Equal(t, want, dao.statuses)
+	require.Equal(t, model.X86_64Arch, dao.images[1].Arch)
+	require.FileExists(t, filepath.Join(dirPath(1), "boot.iso"))
+}
+
+func TestExtractImageFailed(t *testing.T) {
+	config.Images.Directory = t.TempDir()
+
+	image := &model.Image{ID: 1, Name: "missing", IsoSha256: "sum"}
+	dao := mockImageDao(t, image)
+	setImageStatus(context.Background(), image, model.ExtractingImageStatus, 0, "")
+	extractImage(image)
+
+	require.Equal(t, model.ExtractingImageStatus, dao.statuses[0].Status)
+	require.Equal(t, imageStatus{model.FailedImageStatus, 0}, dao.statuses[len(dao.statuses)-1])
+	require.Contains(t, dao.images[1].StatusMessage, "error during extraction")
+}
+
+func TestDeleteImage(t *testing.T) {
+	config.Images.Directory = t.TempDir()
+	require.NoError(t, ensureDir(1))
+	require.NoError(t, os.WriteFile(isoPath(1), []byte("iso"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dirPath(1), "boot.iso"), []byte("boot"), 0644))
+
+	image := &model.Image{ID: 1, Name: "test", Status: model.ReadyImageStatus}
+	processing := &model.Image{ID: 2, Name: "processing", Status: model.GeneratingImageStatus}
+	dao := mockImageDao(t, image, processing)
+	ctx := context.Background()
+
+	require.NoError(t, DeleteImage(ctx, image))
+	require.NoDirExists(t, dirPath(1))
+	require.NotContains(t, dao.images, int64(1))
+	entries, err := os.ReadDir(config.Images.Directory)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	require.ErrorIs(t, DeleteImage(ctx, processing), ErrImageProcessing)
+	require.Contains(t, dao.images, int64(2))
+
+	// directory is restored when the record cannot be deleted
+	failing := &model.Image{ID: 3, Name: "failing", Status: model.ReadyImageStatus}
+	dao.images[3] = failing
+	dao.deleteErr = db.ErrAffectedMismatch
+	require.NoError(t, ensureDir(3))
+	require.NoError(t, os.WriteFile(isoPath(3), []byte("iso"), 0644))
+	require.ErrorIs(t, DeleteImage(ctx, failing), db.ErrAffectedMismatch)
+	require.FileExists(t, isoPath(3))
+}
+
+func putImage(t *testing.T, r http.Handler, contentRange, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPut, "/1", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if contentRange != "" {
+		req.Header.Set("Content-Range", contentRange)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestUploadImageResume(t *testing.T) {
+	config.Images.Directory = t.TempDir()
+	sum := sha256.Sum256([]byte("abcdefgh"))
+	image := &model.Image{ID: 1, Name: "test", ExpectedSha256: hex.EncodeToString(sum[:])}
+	dao := mockImageDao(t, image)
+	r := chi.NewRouter()
+	MountImages(r)
+
+	w := putImage(t, r, "bytes 0-3/8", "abcd")
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, "4", w.Header().Get(uploadOffsetHeader))
+	require.Equal(t, imageStatus{model.UploadingImageStatus, 50}, dao.statuses[len(dao.statuses)-1])
+
+	// interrupted upload is resumed from the stored offset
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/1", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "4", w.Header().Get(uploadOffsetHeader))
+	require.Empty(t, w.Header().Get(uploadCompleteHeader))
+
+	w = putImage(t, r, "bytes 0-3/8", "abcd")
+	require.Equal(t, http.StatusConflict, w.Code)
+	require.Equal(t, "4", w.Header().Get(uploadOffsetHeader))
+
+	w = putImage(t, r, "bytes 4-7/8", "efgh")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "8", w.Header().Get(uploadOffsetHeader))
+
+	// the uploaded file is not an ISO, wait until its extraction fails
+	require.Eventually(t, func() bool {
+		i, _ := dao.FindByID(context.Background(), 1)
+		return i.Status == model.FailedImageStatus
+	}, 5*time.Second, 10*time.Millisecond)
+
+	iso, err := os.ReadFile(isoPath(1))
+	require.NoError(t, err)
+	require.Equal(t, "abcdefgh", string(iso))
+	i, _ := dao.FindByID(context.Background(), 1)
+	require.Equal(t, image.ExpectedSha256, i.IsoSha256)
+
+	w = putImage(t, r, "", "abcdefgh")
+	require.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestUploadImageChecksumMismatch(t *testing.T) {
+	config.Images.Directory = t.TempDir()
+	sum := sha256.Sum256([]byte("expected"))
+	image := &model.Image{ID: 1, Name: "test", ExpectedSha256: hex.EncodeToString(sum[:])}
+	dao := mockImageDao(t, image)
+	r := chi.NewRouter()
+	MountImages(r)
+
+	w := putImage(t, r, "", "corrupted")
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	require.Equal(t, "0", w.Header().Get(uploadOffsetHeader))
+	require.NoFileExists(t, isoPath(1))
+
+	i, err := dao.FindByID(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, model.FailedImageStatus, i.Status)
+	require.Empty(t, i.IsoSha256)
+	require.Contains(t, i.StatusMessage, ErrImageChecksum.Error())
+
+	// upload can start over
+	w = putImage(t, r, "", "expected")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Eventually(t, func() bool {
+		i, _ := dao.FindByID(context.Background(), 1)
+		return i.Status == model.FailedImageStatus && strings.Contains(i.StatusMessage, "extraction")
+	}, 5*time.Second, 10*time.Millisecond)
+	i, _ = dao.FindByID(context.Background(), 1)
+	require.Equal(t, image.ExpectedSha256, i.IsoSha256)
+}
@@ -1,6 +1,7 @@
 package mux
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -15,6 +16,7 @@ import (
 	"github.com/go-chi/render"
 	pgx "github.com/jackc/pgx/v5"
 
+	"forester/internal/config"
 	"forester/internal/db"
 	"forester/internal/model"
 	"forester/internal/tmpl"
@@ -69,9 +71,16 @@ func renderDiscover(ctx context.Context, w io.Writer) error {
 }
 
 func RenderKickstartForSystem(ctx context.Context, system *model.System, w io.Writer) error {
+	_, err := renderKickstartForSystem(ctx, system, w)
+	return err
+}
+
+// renderKickstartForSystem renders kickstart and returns the installation it was rendered
+// for, nil is returned together with discovery kickstart.
+func renderKickstartForSystem(ctx context.Context, system *model.System, w io.Writer) (*model.Installation, error) {
 	if system == nil {
 		slog.DebugContext(ctx, "no system found, missing Anaconda MAC header")
-		return renderDiscover(ctx, w)
+		return nil, renderDiscover(ctx, w)
 	}
 
 	inDao := db.GetInstallationDao(ctx)
@@ -79,14 +88,14 @@ func RenderKickstartForSystem(ctx context.Context, system *model.System, w io.Wr
 	var inst *model.Installation
 	if err != nil {
 		slog.ErrorContext(ctx, "error during finding installations for a system", "id", system.ID, "err", err)
-		return renderDiscover(ctx, w)
+		return nil, renderDiscover(ctx, w)
 	}
 
 	if len(insts) == 0 {
 		slog.WarnContext(ctx, "system found but not installable",
 			"id", system.ID,
 			"name", system.Name)
-		return renderDiscover(ctx, w)
+		return nil, renderDiscover(ctx, w)
 	}
 	inst = insts[0]
 
@@ -99,11 +108,12 @@ func RenderKickstartForSystem(ctx context.Context, system *model.System, w io.Wr
 		slog.DebugContext(ctx, "installing a system without appliance")
 	} else if err != nil {
 		slog.ErrorContext(ctx, "error while fetching appliance for system", "id", system.ID)
-		return err
+		return nil, err
 	}
 
-	// libvirt cannot be restarted due to boot order hook
-	if appliance != nil && appliance.Kind == model.LibvirtApplianceKind {
+	// systems booted from disk by a local boot job are powered off so the job does not
+	// reset them in the middle of a reboot
+	if appliance != nil && bootsLocal(appliance.Kind) {
 		la = tmpl.ShutdownLastAction
 	}
 
@@ -113,28 +123,38 @@ func RenderKickstartForSystem(ctx context.Context, system *model.System, w io.Wr
 	img, err := iDao.FindByID(ctx, inst.ImageID)
 	if err != nil {
 		slog.ErrorContext(ctx, "error loading image for system", "id", system.ID, "image_id", inst.ImageID)
-		return err
+		return nil, err
 	}
 	liveimgSha256 = img.LiveimgSha256
 
 	// load params and snippets
 	params := tmpl.KickstartParams{
-		SystemID:       system.ID,
-		ImageID:        inst.ImageID,
-		ImageKind:      int16(img.Kind),
-		SystemName:     system.Name,
-		SystemHostname: ToHostname(system.Name),
-		InstallUUID:    inst.UUID.String(),
-		LastAction:     la,
-		Snippets:       tmpl.MakeCustomSnippets(),
-		CustomSnippet:  system.CustomSnippet,
-		LiveimgSha256:  liveimgSha256,
+		SystemID:        system.ID,
+		ImageID:         inst.ImageID,
+		ImageKind:       int16(img.Kind),
+		SystemName:      system.Name,
+		SystemHostname:  ToHostname(system.Name),
+		InstallUUID:     inst.UUID.String(),
+		LastAction:      la,
+		Snippets:        tmpl.MakeCustomSnippets(),
+		CustomSnippet:   system.CustomSnippet,
+		LiveimgSha256:   liveimgSha256,
+		ContainerRef:    img.ContainerRef,
+		BootcRef:        img.BootcRef,
+		SignatureVerify: img.SignatureVerify,
+		Image:           img,
+		Network:         system.Network,
+	}
+	if img.Kind == model.RPMInstallerKind {
+		params.LocalRepo = img.LocalRepo
+		params.MirrorURL = config.Images.RPMMirror
+		params.Repos = rpmRepos(ctx)
 	}
 
 	snippets, err := sDao.FindByInstallation(ctx, inst.ID)
 	if err != nil {
 		slog.ErrorContext(ctx, "error loading snippets", "inst_id", inst.ID)
-		return err
+		return nil, err
 	}
 
 	for _, s := range snippets {
@@ -144,10 +164,10 @@ func RenderKickstartForSystem(ctx context.Context, system *model.System, w io.Wr
 	err = tmpl.RenderKickstartInstall(ctx, w, params)
 	if err != nil {
 		slog.ErrorContext(ctx, "error rendering ks snippet", "id", system.ID)
-		return err
+		return nil, err
 	}
 
-	return nil
+	return inst, nil
 }
 
 var headerRegexp = regexp.MustCompile("(?i)^X-RHN-Provisioning-MAC-")
@@ -192,9 +212,25 @@ func HandleKickstart(w http.ResponseWriter, r *http.Request) {
 
 	}
 
-	err = RenderKickstartForSystem(r.Context(), system, w)
+	buf := bytes.Buffer{}
+	inst, err := renderKickstartForSystem(r.Context(), system, &buf)
 	if err != nil {
 		renderKsError(err, w, r)
+		return
+	}
+
+	// only kickstarts served to installers are recorded, not previews
+	if inst != nil {
+		db.RecordEvent(r.Context(), inst.ID, model.KickstartEventKind, buf.String())
+	}
+	_, err = buf.WriteTo(w)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "cannot write kickstart", "err", err)
+		return
+	}
+
+	if inst != nil {
+		advanceInstallation(r.Context(), inst, system, model.InstallingInstallState)
 	}
 }
 
@@ -211,3 +247,19 @@ func renderKsError(ksErr error, w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 	}
 }
+
+// rpmRepos returns additional repositories of netboot images from configuration, invalid
+// entries are skipped.
+func rpmRepos(ctx context.Context) []tmpl.KickstartRepo {
+	var result []tmpl.KickstartRepo
+	for _, repo := range config.Images.RPMRepos {
+		name, url, ok := strings.Cut(repo, "=")
+		if !ok || name == "" || url == "" {
+			slog.WarnContext(ctx, "invalid repository, expected name=URL", "repo", repo)
+			continue
+		}
+		result = append(result, tmpl.KickstartRepo{Name: name, URL: url})
+	}
+
+	return result
+}
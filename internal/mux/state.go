@@ -0,0 +1,40 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"slices"
+
+	"forester/internal/db"
+	"forester/internal/model"
+)
+
+// isDiscovery returns true for the discovery system which is registered with the null
+// hardware address and shares its installation across all unknown systems.
+func isDiscovery(s *model.System) bool {
+	return slices.ContainsFunc(s.HwAddrs, func(a net.HardwareAddr) bool {
+		return bytes.Equal(a, db.NullMAC)
+	})
+}
+
+// advanceInstallation moves installation forward into a new state. Handlers are called
+// repeatedly during a single boot (HEAD and GET requests, retries), so transitions which
+// were already applied are only logged.
+func advanceInstallation(ctx context.Context, inst *model.Installation, s *model.System, to model.InstallState) {
+	if s != nil && isDiscovery(s) {
+		slog.DebugContext(ctx, "not advancing discovery installation", "inst_id", inst.ID, "state", to.String())
+		return
+	}
+
+	err := db.GetInstallationDao(ctx).Transition(ctx, inst.ID, to)
+	if errors.Is(err, db.ErrInvalidTransition) {
+		slog.DebugContext(ctx, "installation state not changed", "inst_id", inst.ID, "state", to.String())
+	} else if err != nil {
+		slog.WarnContext(ctx, "cannot change installation state", "inst_id", inst.ID, "state", to.String(), "err", err)
+	} else {
+		slog.InfoContext(ctx, "installation state changed", "inst_id", inst.ID, "state", to.String())
+	}
+}
@@ -6,15 +6,10 @@ import (
 	"io/fs"
 	"log/slog"
 	"net/http"
-	"os"
-	"path"
-	"path/filepath"
 	"strconv"
 	"strings"
 
 	chi "github.com/go-chi/chi/v5"
-
-	"forester/internal/config"
 )
 
 func MountTar(r *chi.Mux) {
@@ -30,26 +25,28 @@ func serveContainerTar(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	root := path.Clean(path.Join(config.BootPath(imgID), "container"))
-	stat, err := os.Stat(root)
+	root := openImageFS(r.Context(), imgID)
+	defer root.Close()
+
+	stat, err := fs.Stat(root, "container")
 	if err != nil {
-		slog.WarnContext(r.Context(), "directory for image does not exist", "path", root, "err", err)
+		slog.WarnContext(r.Context(), "container directory does not exist", "image_id", imgID, "err", err)
 		http.NotFound(w, r)
 		return
 	}
 
 	if !stat.IsDir() {
-		slog.WarnContext(r.Context(), "image is not container type", "path", root, "ID", imgID, "err", err)
+		slog.WarnContext(r.Context(), "image is not container type", "ID", imgID, "err", err)
 		http.NotFound(w, r)
 		return
 	}
 
-	slog.DebugContext(r.Context(), "serving tar", "path", root)
+	slog.DebugContext(r.Context(), "serving tar", "image_id", imgID)
 	w.Header().Add("Content-Type", "application/x-tar")
 	tw := tar.NewWriter(w)
 	defer tw.Close()
 
-	filepath.WalkDir(root, func(path string, de fs.DirEntry, err error) error {
+	fs.WalkDir(root, "container", func(path string, de fs.DirEntry, err error) error {
 		if err != nil {
 			slog.WarnContext(r.Context(), "walk error", "path", path, "err", err)
 			return err
@@ -59,8 +56,7 @@ func serveContainerTar(w http.ResponseWriter, r *http.Request) {
 			return nil
 		}
 
-		tarName := strings.TrimPrefix(path, root+"/")
-		//slog.DebugContext(r.Context(), tarName, "path", root)
+		tarName := strings.TrimPrefix(path, "container/")
 
 		fi, err := de.Info()
 		if err != nil {
@@ -81,7 +77,7 @@ func serveContainerTar(w http.ResponseWriter, r *http.Request) {
 			return err
 		}
 
-		f, err := os.Open(path)
+		f, err := root.Open(path)
 		if err != nil {
 			slog.WarnContext(r.Context(), "cannot open file", "path", path, "err", err)
 			return err
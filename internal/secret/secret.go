@@ -0,0 +1,81 @@
+// Package secret encrypts sensitive data stored in the database with the controller key.
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"forester/internal/config"
+)
+
+var ErrNoKey = errors.New("credentials key is not configured (APP_CREDENTIALS_KEY)")
+
+var ErrDecrypt = errors.New("cannot decrypt, wrong key or corrupted data")
+
+// version is the first byte of every ciphertext, it allows changing the algorithm later
+const version byte = 1
+
+func aead(key string) (cipher.AEAD, error) {
+	if key == "" {
+		return nil, ErrNoKey
+	}
+
+	// the key is a passphrase of an arbitrary length, AES-256 needs exactly 32 bytes
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, fmt.Errorf("cipher error: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// Encrypt encrypts data with AES-256-GCM using the configured key. Returns ErrNoKey when
+// no key was configured.
+func Encrypt(plain []byte) ([]byte, error) {
+	return encrypt(config.Application.CredentialsKey, plain)
+}
+
+// Decrypt decrypts data previously encrypted by Encrypt.
+func Decrypt(data []byte) ([]byte, error) {
+	return decrypt(config.Application.CredentialsKey, data)
+}
+
+func encrypt(key string, plain []byte) ([]byte, error) {
+	gcm, err := aead(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("nonce error: %w", err)
+	}
+
+	out := append([]byte{version}, nonce...)
+	return gcm.Seal(out, nonce, plain, []byte{version}), nil
+}
+
+func decrypt(key string, data []byte) ([]byte, error) {
+	gcm, err := aead(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 1+gcm.NonceSize() || data[0] != version {
+		return nil, ErrDecrypt
+	}
+
+	nonce, sealed := data[1:1+gcm.NonceSize()], data[1+gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, sealed, data[:1])
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	return plain, nil
+}
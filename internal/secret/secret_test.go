@@ -0,0 +1,32 @@
+package secret
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	data, err := encrypt("key", []byte("secret"))
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "secret")
+
+	plain, err := decrypt("key", data)
+	require.NoError(t, err)
+	require.Equal(t, "secret", string(plain))
+
+	_, err = decrypt("other key", data)
+	require.ErrorIs(t, err, ErrDecrypt)
+
+	data[len(data)-1] ^= 0xff
+	_, err = decrypt("key", data)
+	require.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestNoKey(t *testing.T) {
+	_, err := encrypt("", []byte("secret"))
+	require.ErrorIs(t, err, ErrNoKey)
+
+	_, err = decrypt("", []byte{version})
+	require.ErrorIs(t, err, ErrNoKey)
+}
@@ -1,5 +1,24 @@
 package tmpl
 
+import (
+	"fmt"
+	"strings"
+
+	"forester/internal/model"
+)
+
 func MakeSlice(n int) []struct{} {
 	return make([]struct{}, n)
 }
+
+// KernelArgs merges space separated default kernel arguments with argument lists
+func KernelArgs(defaults string, args ...[]string) string {
+	return strings.Join(model.MergeKernelArgs(append([][]string{strings.Fields(defaults)}, args...)...), " ")
+}
+
+// DefaultKernelArgs returns space separated installer kernel arguments all boot
+// configurations start with, hostname is passed to systemd.
+func DefaultKernelArgs(baseURL, baseHost, syslogPort string, imageID int64, hostname string) string {
+	return fmt.Sprintf("inst.stage2=%s/img/%d ip=dhcp inst.text inst.sshd inst.ks.sendmac inst.ks=%s/ks inst.syslog=%s:%s systemd.hostname=%s",
+		baseURL, imageID, baseURL, baseHost, syslogPort, hostname)
+}
@@ -64,6 +64,14 @@ def gather_serial():
     except Exception:
         return ""
 
+def gather_secure_boot():
+    # EFI variable: 4 bytes of attributes followed by the value
+    try:
+        data = open("/sys/firmware/efi/efivars/SecureBoot-8be4df61-93ca-11d0-aa8d-00a0c90e2c84", "rb").read()
+        return "true" if data[4:5] == b"\x01" else "false"
+    except Exception:
+        return "false"
+
 def gather_facts():
     global log
     result = {
@@ -74,6 +82,8 @@ def gather_facts():
                     # TODO try with psutil package contains a lot of useful stuff
                     "cpuinfo-processor-count": str(open('/proc/cpuinfo').read().count('processor\t:')),
                     "memory-bytes": str(os.sysconf('SC_PAGE_SIZE') * os.sysconf('SC_PHYS_PAGES')),
+                    "arch": os.uname().machine,
+                    "secure_boot": gather_secure_boot(),
                     },
                 },
     }
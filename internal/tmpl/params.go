@@ -25,15 +25,17 @@ type BootErrorParams struct {
 type GrubLinuxCmd string
 
 const (
-	GrubLinuxCmdBIOS   GrubLinuxCmd = "linux /boot/bios"
-	GrubLinuxCmdEFIX64 GrubLinuxCmd = "linuxefi /boot/efix64"
+	GrubLinuxCmdBIOS    GrubLinuxCmd = "linux /boot/bios"
+	GrubLinuxCmdEFIX64  GrubLinuxCmd = "linuxefi /boot/efix64"
+	GrubLinuxCmdEFIAA64 GrubLinuxCmd = "linux /boot/efiaa64"
 )
 
 type GrubInitrdCmd string
 
 const (
-	GrubInitrdCmdBIOS   GrubInitrdCmd = "initrd /boot/bios"
-	GrubInitrdCmdEFIX64 GrubInitrdCmd = "initrdefi /boot/efix64"
+	GrubInitrdCmdBIOS    GrubInitrdCmd = "initrd /boot/bios"
+	GrubInitrdCmdEFIX64  GrubInitrdCmd = "initrdefi /boot/efix64"
+	GrubInitrdCmdEFIAA64 GrubInitrdCmd = "initrd /boot/efiaa64"
 )
 
 type BootKernelParams struct {
@@ -43,6 +45,20 @@ type BootKernelParams struct {
 	InstallUUID string
 	LinuxCmd    GrubLinuxCmd
 	InitrdCmd   GrubInitrdCmd
+
+	// KernelArgs are system, image and installation arguments in order, see MergeKernelArgs
+	KernelArgs []string
+}
+
+type BootISOParams struct {
+	*CommonParams
+	ImageID  int64
+	VolumeID string
+	Arch     string
+	EFI      bool
+
+	// KernelArgs are image arguments applied on defaults
+	KernelArgs []string
 }
 
 type LastAction int
@@ -70,16 +86,32 @@ func (la LastAction) String() string {
 
 type KickstartParams struct {
 	*CommonParams
-	ImageID        int64
-	ImageKind      int16
-	SystemID       int64
-	SystemName     string
-	SystemHostname string
-	InstallUUID    string
-	LastAction     LastAction
-	Snippets       map[string][]string
-	CustomSnippet  string
-	LiveimgSha256  string
+	ImageID         int64
+	ImageKind       int16
+	SystemID        int64
+	SystemName      string
+	SystemHostname  string
+	InstallUUID     string
+	LastAction      LastAction
+	Snippets        map[string][]string
+	CustomSnippet   string
+	LiveimgSha256   string
+	LocalRepo       bool
+	MirrorURL       string
+	Repos           []KickstartRepo
+	ContainerRef    string
+	BootcRef        string
+	SignatureVerify bool
+	Image           *model.Image
+
+	// Network is static network configuration of the system or nil for DHCP
+	Network *model.Network
+}
+
+// KickstartRepo is an additional package repository of netboot images
+type KickstartRepo struct {
+	Name string
+	URL  string
 }
 
 type KickstartErrorParams struct {
@@ -97,11 +129,21 @@ func MakeCustomSnippets() map[string][]string {
 }
 
 type DhcpEntry struct {
-	Tag string
-	MAC string
+	Tag      string
+	MAC      string
+	Hex      string
+	Hostname string
+	Address  string
+
+	// SecureBoot entries are booted via shim and grub even in iPXE configuration
+	SecureBoot bool
 }
 
 type DhcpParams struct {
 	*CommonParams
 	Entries []DhcpEntry
+
+	// Delta is set when only entries changed since a given time are rendered, global
+	// configuration is omitted
+	Delta bool
 }
@@ -22,7 +22,9 @@ var templates *template.Template
 func init() {
 	var err error
 	templates, err = template.New("").Funcs(template.FuncMap{
-		"MakeSlice": MakeSlice,
+		"MakeSlice":         MakeSlice,
+		"KernelArgs":        KernelArgs,
+		"DefaultKernelArgs": DefaultKernelArgs,
 	}).ParseFS(templatesFS, "*.tmpl.*")
 	if err != nil {
 		panic(err)
@@ -73,6 +75,12 @@ func RenderIpxeKernel(ctx context.Context, w io.Writer, params BootKernelParams)
 	return Render(ctx, w, "ipxe_kernel.tmpl.txt", params)
 }
 
+func RenderBootISOGrub(ctx context.Context, w io.Writer, params BootISOParams) error {
+	params.CommonParams = commonParams()
+
+	return Render(ctx, w, "bootiso_grub.tmpl.txt", params)
+}
+
 func RenderBootError(ctx context.Context, w io.Writer, params BootErrorParams) error {
 	params.CommonParams = commonParams()
 
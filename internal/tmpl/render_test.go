@@ -0,0 +1,218 @@
+package tmpl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"forester/internal/model"
+)
+
+func TestRenderBootISOGrub(t *testing.T) {
+	tests := map[string]struct {
+		efi      bool
+		contains []string
+		missing  []string
+	}{
+		"bios": {
+			efi:      false,
+			contains: []string{"(BIOS)", "insmod chain", "\nlinux /images/pxeboot/vmlinuz", "\ninitrd /images/pxeboot/initrd.img"},
+			missing:  []string{"efi_gop", "linuxefi"},
+		},
+		"efi": {
+			efi:      true,
+			contains: []string{"(EFI)", "insmod efi_gop", "\nlinuxefi /images/pxeboot/vmlinuz", "\ninitrdefi /images/pxeboot/initrd.img"},
+			missing:  []string{"insmod chain"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := RenderBootISOGrub(context.Background(), &buf, BootISOParams{ImageID: 7, VolumeID: "FORESTER", EFI: tc.efi, KernelArgs: []string{"-inst.sshd"}})
+			require.NoError(t, err)
+
+			out := buf.String()
+			require.Contains(t, out, "search --no-floppy --set=root -l 'FORESTER'")
+			require.Contains(t, out, "/img/7 ")
+			require.Contains(t, out, "systemd.hostname=f-${net_default_mac}\n")
+			require.NotContains(t, out, "inst.sshd")
+			for _, s := range tc.contains {
+				require.Contains(t, out, s)
+			}
+			for _, s := range tc.missing {
+				require.NotContains(t, out, s)
+			}
+		})
+	}
+}
+
+func TestRenderKernelArgs(t *testing.T) {
+	params := BootKernelParams{
+		ImageID:     7,
+		SystemID:    3,
+		InstallUUID: "uuid",
+		LinuxCmd:    GrubLinuxCmdEFIX64,
+		InitrdCmd:   GrubInitrdCmdEFIX64,
+		KernelArgs:  []string{"-inst.text", "console=ttyS0", "-ip", "ip=dhcp6"},
+	}
+
+	var grub bytes.Buffer
+	err := RenderGrubKernel(context.Background(), &grub, params)
+	require.NoError(t, err)
+	require.Contains(t, grub.String(), "\nlinuxefi /boot/efix64/$net_default_mac/images/pxeboot/vmlinuz inst.stage2=")
+	require.Contains(t, grub.String(), "systemd.hostname=f-3-uuid console=ttyS0 ip=dhcp6\n")
+	require.NotContains(t, grub.String(), "inst.text")
+	require.NotContains(t, grub.String(), "ip=dhcp ")
+
+	var ipxe bytes.Buffer
+	err = RenderIpxeKernel(context.Background(), &ipxe, params)
+	require.NoError(t, err)
+	require.Contains(t, ipxe.String(), "/images/pxeboot/vmlinuz initrd=initrd.img inst.stage2=")
+	require.Contains(t, ipxe.String(), "systemd.hostname=f-3-uuid console=ttyS0 ip=dhcp6\n")
+	require.NotContains(t, ipxe.String(), "inst.text")
+}
+
+func TestRenderKickstartInstallRPM(t *testing.T) {
+	tests := map[string]struct {
+		params   KickstartParams
+		contains []string
+	}{
+		"local repository": {
+			params:   KickstartParams{LocalRepo: true, MirrorURL: "http://mirror/os/"},
+			contains: []string{"\nurl --url=", "/img/7/\n"},
+		},
+		"mirror": {
+			params:   KickstartParams{MirrorURL: "http://mirror/os/", Repos: []KickstartRepo{{Name: "updates", URL: "http://mirror/updates/"}}},
+			contains: []string{"\nurl --url=http://mirror/os/\n", "\nrepo --name=updates --baseurl=http://mirror/updates/\n"},
+		},
+		"no source": {
+			params:   KickstartParams{},
+			contains: []string{"# no installation source", "\nnetwork --bootproto=dhcp --device=link"},
+		},
+		"static network": {
+			params:   KickstartParams{SystemHostname: "host", Network: &model.Network{Device: "eno1", Address: "192.168.1.10/24", Gateway: "192.168.1.1"}},
+			contains: []string{"# network\nnetwork --bootproto=static --device=eno1 --ip=192.168.1.10 --netmask=255.255.255.0 --gateway=192.168.1.1 --activate --onboot=on --hostname host\n# /network"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tc.params.ImageID, tc.params.ImageKind, tc.params.Snippets = 7, 3, MakeCustomSnippets()
+			err := RenderKickstartInstall(context.Background(), &buf, tc.params)
+			require.NoError(t, err)
+
+			out := buf.String()
+			require.NotContains(t, out, "unknown image kind")
+			for _, s := range tc.contains {
+				require.Contains(t, out, s)
+			}
+		})
+	}
+}
+
+func TestRenderKickstartInstallContainer(t *testing.T) {
+	tests := map[string]struct {
+		params   KickstartParams
+		contains []string
+		missing  []string
+	}{
+		"oci layout": {
+			params:   KickstartParams{ImageKind: 2},
+			contains: []string{"/tar/7/container", "--transport=oci --no-signature-verification\n", "reqpart --add-boot"},
+			missing:  []string{"bootc switch", "selinux --enforcing"},
+		},
+		"registry": {
+			params: KickstartParams{ImageKind: 3, ContainerRef: "quay.io/fedora/fedora-bootc:40", BootcRef: "quay.io/example/app:latest", SignatureVerify: true},
+			contains: []string{
+				"\nostreecontainer --url=quay.io/fedora/fedora-bootc:40 --transport=registry\n",
+				"\nbootc switch --mutate-in-place --transport registry --enforce-container-sigpolicy quay.io/example/app:latest\n",
+				"reqpart --add-boot",
+			},
+			missing: []string{"/tar/7/container", "url --url", "no-signature-verification"},
+		},
+		"no container": {
+			params:   KickstartParams{ImageKind: 1, BootcRef: "quay.io/example/app:latest", Image: &model.Image{Meta: map[string]string{"distro": "Fedora", "version": "40"}}},
+			contains: []string{"\n# image distro: Fedora\n# image version: 40\n"},
+			missing:  []string{"bootc switch", "ostreecontainer", "no value"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tc.params.ImageID, tc.params.Snippets = 7, MakeCustomSnippets()
+			err := RenderKickstartInstall(context.Background(), &buf, tc.params)
+			require.NoError(t, err)
+
+			out := buf.String()
+			for _, s := range tc.contains {
+				require.Contains(t, out, s)
+			}
+			for _, s := range tc.missing {
+				require.NotContains(t, out, s)
+			}
+		})
+	}
+}
+
+func TestRenderDhcpConfKea(t *testing.T) {
+	entries := []DhcpEntry{
+		{Tag: "t525400000001", MAC: "52:54:00:00:00:01", Hex: "525400000001", Hostname: "one", Address: "192.168.122.11"},
+		{Tag: "t525400000002", MAC: "52:54:00:00:00:02", Hex: "525400000002", Hostname: "two"},
+	}
+
+	for _, format := range []string{"grub", "ipxe"} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := RenderDhcpConf(context.Background(), &buf, "kea", format, DhcpParams{Entries: entries})
+			require.NoError(t, err)
+			require.True(t, json.Valid(buf.Bytes()), buf.String())
+			require.Contains(t, buf.String(), "pkt4.mac == 0x525400000002")
+			require.Contains(t, buf.String(), `"ip-address": "192.168.122.11"`)
+
+			buf.Reset()
+			err = RenderDhcpConf(context.Background(), &buf, "kea", format, DhcpParams{})
+			require.NoError(t, err)
+			require.True(t, json.Valid(buf.Bytes()), buf.String())
+		})
+	}
+}
+
+func TestRenderDhcpConfDelta(t *testing.T) {
+	entries := []DhcpEntry{{Tag: "t525400000001", MAC: "52:54:00:00:00:01", Hex: "525400000001", Hostname: "one", Address: "192.168.122.11"}}
+
+	for _, name := range []string{"iscdhcpd", "dnsmasq", "libvirt", "networkd"} {
+		t.Run(name, func(t *testing.T) {
+			var full, delta bytes.Buffer
+			require.NoError(t, RenderDhcpConf(context.Background(), &full, name, "ipxe", DhcpParams{Entries: entries}))
+			require.NoError(t, RenderDhcpConf(context.Background(), &delta, name, "ipxe", DhcpParams{Entries: entries, Delta: true}))
+
+			require.Contains(t, delta.String(), "52:54:00:00:00:01")
+			require.Contains(t, full.String(), "ipxe-snponly-x86_64.efi")
+			require.NotContains(t, delta.String(), "bootstrap/ipxe/")
+		})
+	}
+}
+
+func TestRenderDhcpConfSecureBoot(t *testing.T) {
+	entries := []DhcpEntry{{Tag: "t525400000001", MAC: "52:54:00:00:00:01", Hex: "525400000001", Hostname: "one", SecureBoot: true}}
+
+	for _, name := range []string{"iscdhcpd", "dnsmasq", "libvirt", "kea"} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, RenderDhcpConf(context.Background(), &buf, name, "ipxe", DhcpParams{Entries: entries}))
+
+			require.Contains(t, buf.String(), "boot/efi64/52:54:00:00:00:01/shim.efi")
+			require.Contains(t, buf.String(), "/boot/efiaa64/52:54:00:00:00:01/shim.efi")
+			require.NotContains(t, buf.String(), "52:54:00:00:00:01/script.ipxe")
+			if name == "kea" {
+				require.True(t, json.Valid(buf.Bytes()), buf.String())
+			}
+		})
+	}
+}